@@ -2,50 +2,31 @@
 package handlers
 
 import (
+	"dao_vote/back-end/models"
 	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
 	"dao_vote/back-end/utils"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"net/http"
+	"strconv"
 )
 
 // WalletStrength представляет структуру для добавления силы голоса кошелька
 type WalletStrength struct {
-	WalletAddress string `json:"wallet_address" binding:"required"`
+	WalletAddress string `json:"wallet_address" binding:"required,decimal_or_evm_address"`
 	VotePower     int    `json:"vote_power" binding:"required"`
 }
 
-// AddWalletHandler добавляет новый адрес кошелька и силу голоса в базу данных
+// AddWalletHandler добавляет новый адрес кошелька и силу голоса в базу данных (разрешение wallets.write)
 func AddWalletHandler(c *gin.Context) {
 	logrus.Info("AddWalletHandler called")
-
-	// Логирование заголовков запроса для отладки
-	headers := c.Request.Header
-	for name, values := range headers {
-		for _, value := range values {
-			logrus.Infof("Header: %s=%s", name, value)
-		}
-	}
-
-	// Получаем пользователя из контекста
-	user, exists := c.Get("user")
-	if !exists {
-		logrus.Warn("User not found in context")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authorized"})
-		return
-	}
-
-	logrus.Infof("User found: %v", user)
-
-	// Проверяем, имеет ли пользователь права администратора
-	if !isAdmin(user.(User)) {
-		logrus.Warn("User does not have admin privileges")
-		c.JSON(http.StatusForbidden, gin.H{"error": "User does not have admin privileges"})
+	user, ok := currentUser(c)
+	if !ok {
 		return
 	}
 
-	logrus.Info("User is authorized and has admin privileges")
-
 	utils.HandleRequest(c, func(c *gin.Context) error {
 		logrus.Info("Handling request inside AddWalletHandler")
 		var wallet WalletStrength
@@ -56,6 +37,7 @@ func AddWalletHandler(c *gin.Context) {
 		}
 
 		logrus.Infof("Request body: %v", wallet)
+		wallet.WalletAddress, _ = utils.NormalizeAddress(wallet.WalletAddress)
 
 		// Проверяем, существует ли уже запись с таким wallet_address
 		if _, err := repository.GetVoteStrength(wallet.WalletAddress); err == nil {
@@ -65,11 +47,13 @@ func AddWalletHandler(c *gin.Context) {
 		}
 
 		logrus.Infof("Wallet address not found, proceeding to add: %v", wallet.WalletAddress)
-		if err := repository.AddWalletStrength(wallet.WalletAddress, wallet.VotePower); err != nil {
+		member := models.Member{WalletAddress: wallet.WalletAddress, VotePower: wallet.VotePower}
+		if err := repository.AddMember(member, changeMeta(user, "")); err != nil {
 			logrus.Errorf("Failed to add wallet: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add wallet"})
 			return err
 		}
+		audit(c, "wallet.add", "wallet", member.WalletAddress, nil, member)
 
 		logrus.Info("Wallet added successfully")
 		c.JSON(http.StatusCreated, gin.H{"message": "Wallet added successfully"})
@@ -78,33 +62,36 @@ func AddWalletHandler(c *gin.Context) {
 	})
 }
 
-// DeleteWalletHandler удаляет адрес кошелька и силу голоса из базы данных
+// DeleteWalletHandler удаляет адрес кошелька и силу голоса из базы данных (разрешение wallets.write)
 func DeleteWalletHandler(c *gin.Context) {
 	logrus.Info("DeleteWalletHandler called")
-	user, exists := c.Get("user")
-	if !exists {
-		logrus.Warn("User not authorized")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authorized"})
-		return
-	}
-
-	logrus.Infof("User found: %v", user)
-	if !isAdmin(user.(User)) {
-		logrus.Warn("User does not have admin privileges")
-		c.JSON(http.StatusForbidden, gin.H{"error": "User does not have admin privileges"})
+	user, ok := currentUser(c)
+	if !ok {
 		return
 	}
-
-	logrus.Info("User is authorized and has admin privileges")
 	utils.HandleRequest(c, func(c *gin.Context) error {
 		logrus.Info("Handling request inside DeleteWalletHandler")
-		walletAddress := c.Param("wallet_address")
+		walletAddress, err := utils.NormalizeAddress(c.Param("wallet_address"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wallet address"})
+			return nil
+		}
 		logrus.Infof("Wallet address to delete: %v", walletAddress)
-		if err := repository.DeleteWalletStrength(walletAddress); err != nil {
+		var before interface{}
+		if votePower, err := repository.GetVoteStrength(walletAddress); err == nil {
+			before = models.Member{WalletAddress: walletAddress, VotePower: votePower}
+		}
+		err = repository.RemoveMember(walletAddress, changeMeta(user, ""))
+		if errors.Is(err, repository.ErrMemberNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
+			return nil
+		}
+		if err != nil {
 			logrus.Errorf("Failed to delete wallet: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete wallet"})
 			return err
 		}
+		audit(c, "wallet.delete", "wallet", walletAddress, before, nil)
 
 		logrus.Info("Wallet deleted successfully")
 		c.JSON(http.StatusOK, gin.H{"message": "Wallet deleted successfully"})
@@ -113,55 +100,82 @@ func DeleteWalletHandler(c *gin.Context) {
 	})
 }
 
-// GetTableNamesHandler Обработчик маршрута для получения названий таблиц
+// GetTableNamesHandler Обработчик маршрута для получения таблиц, доступных для просмотра, с их колонками
 func GetTableNamesHandler(c *gin.Context) {
-	user, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authorized"})
-		return
-	}
-
-	if !isAdmin(user.(User)) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "User does not have admin privileges"})
-		return
-	}
-
 	utils.HandleRequest(c, func(c *gin.Context) error {
-		tableNames, err := repository.GetTableNames()
+		tables, err := services.ListBrowsableTables()
 		if err != nil {
 			logrus.Errorf("Failed to get table names: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get table names"})
 			return err
 		}
 
-		c.JSON(http.StatusOK, gin.H{"table_names": tableNames})
+		tableNames := make([]string, 0, len(tables))
+		for _, table := range tables {
+			tableNames = append(tableNames, table.Name)
+		}
+		audit(c, "tables.list", "table", "", nil, nil)
+		c.JSON(http.StatusOK, gin.H{"table_names": tableNames, "tables": tables})
 		return nil
 	})
 }
 
-// GetTableElementsHandler обработчик маршрута для получения элементов в таблице по названию
+// GetTableElementsHandler обработчик маршрута для получения страницы строк таблицы из списка разрешенных.
+// Поддерживает limit/offset или cursor, сортировку sort=col|-col и фильтры filter[col]=value.
 func GetTableElementsHandler(c *gin.Context) {
-	user, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authorized"})
+	tableName := c.Param("table_name")
+	query, err := parseTableQuery(c)
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if !isAdmin(user.(User)) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "User does not have admin privileges"})
+	page, err := services.BrowseTable(tableName, query)
+	switch {
+	case errors.Is(err, services.ErrTableNotBrowsable):
+		utils.JSONResponse(c, http.StatusNotFound, gin.H{"error": "Table not found"})
+		return
+	case errors.Is(err, services.ErrInvalidTableQuery):
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		logrus.Errorf("Failed to get elements from table %s: %v", tableName, err)
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to get elements from table"})
 		return
 	}
 
-	tableName := c.Param("table_name")
-	utils.HandleRequest(c, func(c *gin.Context) error {
-		elements, err := repository.GetTableElements(tableName)
-		if err != nil {
-			logrus.Errorf("Failed to get elements from table %s: %v", tableName, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get elements from table"})
-			return err
-		}
+	audit(c, "table.read", "table", tableName, nil, gin.H{"rows": len(page.Rows), "filters": query.Filters, "sort": query.Sort})
+	c.JSON(http.StatusOK, page)
+}
 
-		c.JSON(http.StatusOK, gin.H{"elements": elements})
-		return nil
-	})
+// Ограничения размера страницы строк таблицы
+const (
+	defaultTableRowsLimit = 50
+	maxTableRowsLimit     = 500
+)
+
+// parseTableQuery разбирает параметры страницы строк таблицы
+func parseTableQuery(c *gin.Context) (models.TableQuery, error) {
+	query := models.TableQuery{
+		Filters: c.QueryMap("filter"),
+		Sort:    c.Query("sort"),
+		Cursor:  c.Query("cursor"),
+		Limit:   defaultTableRowsLimit,
+	}
+
+	var err error
+	if value := c.Query("limit"); value != "" {
+		if query.Limit, err = strconv.Atoi(value); err != nil || query.Limit <= 0 {
+			return query, errors.New("invalid limit")
+		}
+		if query.Limit > maxTableRowsLimit {
+			query.Limit = maxTableRowsLimit
+		}
+	}
+	if value := c.Query("offset"); value != "" {
+		if query.Offset, err = strconv.Atoi(value); err != nil || query.Offset < 0 {
+			return query, errors.New("invalid offset")
+		}
+	}
+	return query, nil
 }
@@ -0,0 +1,156 @@
+// Package handlers Обработчик API ключей сервисного доступа
+package handlers
+
+import (
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"dao_vote/back-end/utils"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+)
+
+// APIKeyHeader заголовок, в котором сервис передает API ключ
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyRole название роли, через которую пользователю API ключа передаются разрешения ключа
+const apiKeyRole = "api_key"
+
+// authenticateAPIKey проверяет API ключ и сохраняет в контексте пользователя с разрешениями ключа
+func authenticateAPIKey(c *gin.Context, value string) {
+	key, err := services.AuthenticateAPIKey(value, c.ClientIP())
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrAPIKeyIPNotAllowed):
+		logrus.Warnf("API key %d used from disallowed IP %s", key.ID, c.ClientIP())
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		c.Abort()
+		return
+	case errors.Is(err, services.ErrInvalidAPIKey), errors.Is(err, services.ErrAPIKeyRevoked), errors.Is(err, services.ErrAPIKeyExpired):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Abort()
+		return
+	default:
+		logrus.Errorf("Failed to verify API key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify API key"})
+		c.Abort()
+		return
+	}
+
+	c.Set("user", apiKeyUser(key))
+	logrus.Debugf("Сервис авторизован API ключом %d (%s)", key.ID, key.Name)
+	c.Next()
+}
+
+// apiKeyUser возвращает пользователя для сервиса, авторизованного API ключом.
+// ID пользователя отрицательный, чтобы не пересекаться с пользователями поставщика удостоверений.
+func apiKeyUser(key models.APIKey) User {
+	role := Role{Name: apiKeyRole}
+	for _, scope := range key.Scopes {
+		role.Permissions = append(role.Permissions, Permission{Name: scope})
+	}
+	return User{
+		ID:       -key.ID,
+		Login:    "api_key:" + key.Name,
+		Nick:     key.Name,
+		Wallet:   key.Wallet,
+		Roles:    []Role{role},
+		APIKeyID: key.ID,
+	}
+}
+
+// upstreamToken возвращает токен для запросов во внешний API от имени пользователя.
+// Сервис, авторизованный API ключом, использует сервисный токен DDAPPS_SERVICE_TOKEN.
+// У участника, вошедшего подписью кошелька, токена внешнего API нет, и действия от его имени
+// не выполняются: сервисный аккаунт не должен подменять кошелек участника.
+func upstreamToken(c *gin.Context) string {
+	if user, exists := c.Get("user"); exists {
+		if user.(User).WalletSessionID != 0 {
+			return ""
+		}
+		if user.(User).APIKeyID != 0 {
+			return services.ServiceToken()
+		}
+	}
+	return c.GetHeader("Authorization")
+}
+
+// ListAPIKeysHandler обрабатывает GET /admin/api-keys запрос
+func ListAPIKeysHandler(c *gin.Context) {
+	keys, err := services.ListAPIKeys()
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to list API keys"})
+		logrus.Errorf("Failed to list API keys: %v", err)
+		return
+	}
+	utils.JSONResponse(c, http.StatusOK, keys)
+}
+
+// CreateAPIKeyHandler обрабатывает POST /admin/api-keys запрос. Значение ключа возвращается только в этом ответе.
+func CreateAPIKeyHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Wallet != "" {
+		req.Wallet, _ = utils.NormalizeAddress(req.Wallet)
+	}
+
+	key, err := services.CreateAPIKey(req, user.ID)
+	if errors.Is(err, services.ErrInvalidAPIKeyRequest) {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		logrus.Errorf("Failed to create API key: %v", err)
+		return
+	}
+
+	utils.JSONResponse(c, http.StatusCreated, key)
+	audit(c, "api_key.create", "api_key", strconv.Itoa(key.ID), nil, key)
+	logrus.Infof("API key %d (%s) created by user %d with scopes %v", key.ID, key.Name, user.ID, key.Scopes)
+}
+
+// RevokeAPIKeyHandler обрабатывает POST /admin/api-keys/:id/revoke запрос
+func RevokeAPIKeyHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	before, _ := repository.GetAPIKeyByID(id)
+	key, err := services.RevokeAPIKey(id)
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		utils.JSONResponse(c, http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		logrus.Errorf("Failed to revoke API key: %v", err)
+		return
+	}
+
+	utils.JSONResponse(c, http.StatusOK, key)
+	audit(c, "api_key.revoke", "api_key", strconv.Itoa(id), before, key)
+	logrus.Infof("API key %d revoked by user %d", id, user.ID)
+}
@@ -0,0 +1,128 @@
+// Package handlers Журнал действий администраторов и идентификаторы запросов
+package handlers
+
+import (
+	"crypto/rand"
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/services"
+	"dao_vote/back-end/utils"
+	"encoding/hex"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"regexp"
+	"strconv"
+)
+
+// RequestIDHeader заголовок с идентификатором запроса
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey ключ идентификатора запроса в контексте gin
+const requestIDKey = "request_id"
+
+// requestIDPattern допустимый идентификатор запроса клиента; иначе идентификатор генерируется
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestIDMiddleware принимает идентификатор запроса из заголовка X-Request-ID или генерирует новый
+// и возвращает его в том же заголовке ответа
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			buf := make([]byte, 16)
+			if _, err := rand.Read(buf); err != nil {
+				logrus.Errorf("Failed to generate request ID: %v", err)
+			}
+			requestID = hex.EncodeToString(buf)
+		}
+		c.Set(requestIDKey, requestID)
+		c.Writer.Header().Set(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// RequestID возвращает идентификатор текущего запроса
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// audit записывает действие пользователя из контекста в журнал действий администраторов.
+// Ошибка записи журнала не отменяет уже выполненное действие и только логируется.
+func audit(c *gin.Context, action, targetType, targetID string, before, after interface{}) {
+	entry := models.AuditEntry{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		RequestID:  RequestID(c),
+		IP:         c.ClientIP(),
+	}
+	if value, exists := c.Get("user"); exists {
+		user := value.(User)
+		entry.ActorID, entry.Actor = user.ID, user.Login
+	}
+	if _, err := services.RecordAudit(entry, before, after); err != nil {
+		logrus.Errorf("Failed to record audit entry %s %s/%s: %v", action, targetType, targetID, err)
+	}
+}
+
+// ListAuditLogHandler обрабатывает GET /admin/audit-log запрос для получения журнала действий администраторов
+func ListAuditLogHandler(c *gin.Context) {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, err := services.ListAuditLog(filter)
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to list audit log"})
+		logrus.Errorf("Failed to list audit log: %v", err)
+		return
+	}
+	utils.JSONResponse(c, http.StatusOK, gin.H{"entries": entries, "limit": filter.Limit, "offset": filter.Offset})
+}
+
+// Ограничения размера страницы журнала действий
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+// parseAuditFilter считывает фильтр журнала действий из параметров запроса
+func parseAuditFilter(c *gin.Context) (models.AuditFilter, error) {
+	filter := models.AuditFilter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		RequestID:  c.Query("request_id"),
+		Limit:      defaultAuditLimit,
+	}
+
+	var err error
+	if value := c.Query("actor_id"); value != "" {
+		if filter.ActorID, err = strconv.Atoi(value); err != nil {
+			return filter, errors.New("invalid actor_id")
+		}
+	}
+	if value := c.Query("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit <= 0 {
+			return filter, errors.New("invalid limit")
+		}
+		if filter.Limit > maxAuditLimit {
+			filter.Limit = maxAuditLimit
+		}
+	}
+	if value := c.Query("offset"); value != "" {
+		if filter.Offset, err = strconv.Atoi(value); err != nil || filter.Offset < 0 {
+			return filter, errors.New("invalid offset")
+		}
+	}
+	if filter.From, err = parseDateParam(c.Query("from")); err != nil {
+		return filter, errors.New("invalid from date")
+	}
+	if filter.To, err = parseDateParam(c.Query("to")); err != nil {
+		return filter, errors.New("invalid to date")
+	}
+	return filter, nil
+}
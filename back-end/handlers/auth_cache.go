@@ -0,0 +1,118 @@
+// Package handlers Кэш проверки токенов авторизации
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Настройки кэша проверки токенов
+var (
+	AuthCacheTTL         = time.Minute      // Срок хранения пользователя для действительного токена
+	AuthCacheNegativeTTL = 10 * time.Second // Срок хранения отказа для недействительного токена
+	AuthCacheStaleTTL    = 5 * time.Minute  // Сколько просроченная запись используется при недоступности сервиса авторизации
+	AuthCacheMaxEntries  = 10000            // Максимальное количество записей в кэше
+)
+
+// authCacheEntry результат проверки токена
+type authCacheEntry struct {
+	user      User
+	valid     bool
+	expiresAt time.Time
+}
+
+// authCall проверка токена, ожидаемая параллельными запросами с тем же токеном
+type authCall struct {
+	done chan struct{}
+	user User
+	err  error
+}
+
+// tokenCache хранит результаты проверки токенов по sha256 токена, чтобы сами токены не хранились в памяти
+type tokenCache struct {
+	mu       sync.Mutex
+	entries  map[string]authCacheEntry
+	inflight map[string]*authCall
+}
+
+// authCache кэш проверки токенов AuthMiddleware
+var authCache = newTokenCache()
+
+// newTokenCache создает пустой кэш проверки токенов
+func newTokenCache() *tokenCache {
+	return &tokenCache{
+		entries:  make(map[string]authCacheEntry),
+		inflight: make(map[string]*authCall),
+	}
+}
+
+// tokenKey возвращает ключ кэша для токена
+func tokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// verify возвращает пользователя по токену из кэша или от поставщика удостоверений Identity;
+// fresh сообщает, что пользователь только что получен от поставщика удостоверений.
+// Параллельные запросы с одним токеном ожидают одну проверку. Если сервис авторизации недоступен,
+// используется последняя успешная проверка не старше AuthCacheStaleTTL после истечения срока.
+func (tc *tokenCache) verify(token string) (user User, fresh bool, err error) {
+	key := tokenKey(token)
+	now := time.Now()
+
+	tc.mu.Lock()
+	entry, cached := tc.entries[key]
+	if cached && now.Before(entry.expiresAt) {
+		tc.mu.Unlock()
+		if !entry.valid {
+			return User{}, false, ErrInvalidToken
+		}
+		return entry.user, false, nil
+	}
+	if call, ok := tc.inflight[key]; ok {
+		tc.mu.Unlock()
+		<-call.done
+		return call.user, false, call.err
+	}
+	call := &authCall{done: make(chan struct{})}
+	tc.inflight[key] = call
+	tc.mu.Unlock()
+
+	call.user, call.err = Identity.ResolveToken(token)
+	fresh = call.err == nil
+
+	tc.mu.Lock()
+	switch {
+	case call.err == nil:
+		tc.entries[key] = authCacheEntry{user: call.user, valid: true, expiresAt: now.Add(AuthCacheTTL)}
+	case errors.Is(call.err, ErrInvalidToken):
+		tc.entries[key] = authCacheEntry{expiresAt: now.Add(AuthCacheNegativeTTL)}
+	case cached && entry.valid && now.Before(entry.expiresAt.Add(AuthCacheStaleTTL)):
+		call.user, call.err = entry.user, nil
+	}
+	delete(tc.inflight, key)
+	tc.evict(now)
+	tc.mu.Unlock()
+	close(call.done)
+
+	return call.user, fresh, call.err
+}
+
+// evict удаляет записи, которые больше не могут быть использованы, а при переполнении очищает кэш.
+// Вызывается под tc.mu.
+func (tc *tokenCache) evict(now time.Time) {
+	if len(tc.entries) <= AuthCacheMaxEntries {
+		return
+	}
+	for key, entry := range tc.entries {
+		if !now.Before(entry.expiresAt.Add(AuthCacheStaleTTL)) {
+			delete(tc.entries, key)
+		}
+	}
+	if len(tc.entries) > AuthCacheMaxEntries {
+		tc.entries = make(map[string]authCacheEntry)
+	}
+}
@@ -2,16 +2,14 @@
 package handlers
 
 import (
-	"bytes"
 	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
 	"dao_vote/back-end/utils"
-	"encoding/json"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
-	"io/ioutil"
 	"net/http"
 	"strconv"
-	"strings"
 )
 
 // AuthRequest представляет структуру запроса для авторизации
@@ -33,16 +31,18 @@ type UserMeResponse struct {
 
 // User представляет структуру пользователя
 type User struct {
-	ID            int            `json:"id"`
-	Login         string         `json:"login"`
-	Email         string         `json:"email"`
-	Phone         string         `json:"phone"`
-	Nick          string         `json:"nick"`
-	Locale        string         `json:"locale"`
-	Avatar        string         `json:"avatar"`
-	Wallet        string         `json:"wallet"`
-	Roles         []Role         `json:"roles"`
-	Subscriptions []Subscription `json:"subscriptions"`
+	ID              int            `json:"id"`
+	Login           string         `json:"login"`
+	Email           string         `json:"email"`
+	Phone           string         `json:"phone"`
+	Nick            string         `json:"nick"`
+	Locale          string         `json:"locale"`
+	Avatar          string         `json:"avatar"`
+	Wallet          string         `json:"wallet"`
+	Roles           []Role         `json:"roles"`
+	Subscriptions   []Subscription `json:"subscriptions"`
+	APIKeyID        int            `json:"api_key_id,omitempty"`        // API ключ, которым авторизован сервис
+	WalletSessionID int            `json:"wallet_session_id,omitempty"` // Сессия участника, вошедшего подписью кошелька
 }
 
 // Role представляет структуру роли пользователя
@@ -78,7 +78,7 @@ type Subscription struct {
 	UpdatedAt       string  `json:"updated_at"`
 }
 
-// UserLoginHandler обрабатывает запрос авторизации пользователя
+// UserLoginHandler обрабатывает запрос авторизации пользователя через поставщика удостоверений Identity
 func UserLoginHandler(c *gin.Context) {
 	utils.HandleRequest(c, func(c *gin.Context) error {
 		var authReq AuthRequest
@@ -88,40 +88,18 @@ func UserLoginHandler(c *gin.Context) {
 			return err
 		}
 
-		// Подготовка запроса к внешнему API
-		jsonData, err := json.Marshal(authReq)
-		if err != nil {
-			logrus.Errorf("Failed to marshal auth request: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process request"})
-			return err
+		authResp, err := Identity.Login(authReq)
+		if errors.Is(err, ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid login or password"})
+			return nil
 		}
-
-		// Отправка запроса к внешнему API
-		resp, err := http.Post("https://backend.ddapps.io/api/v1/auth/user_login", "application/json", bytes.NewBuffer(jsonData))
 		if err != nil {
-			logrus.Errorf("Failed to send request to external API: %v", err)
+			logrus.Errorf("Failed to log in: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to authentication service"})
-			return err
+			return nil
 		}
-		defer resp.Body.Close()
 
-		// Чтение и обработка ответа от внешнего API
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			logrus.Errorf("Failed to read response body: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read authentication response"})
-			return err
-		}
-
-		var authResp AuthResponse
-		if err := json.Unmarshal(body, &authResp); err != nil {
-			logrus.Errorf("Failed to unmarshal authentication response: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process authentication response"})
-			return err
-		}
-
-		// Логирование полученного токена
-		logrus.Infof("Получен токен: %s", authResp.Token)
+		logrus.Infof("Пользователь %s авторизован", authReq.Login)
 
 		// Возвращение ответа клиенту
 		c.JSON(http.StatusOK, authResp)
@@ -133,101 +111,119 @@ func UserLoginHandler(c *gin.Context) {
 func UserMeHandler(c *gin.Context) {
 	utils.HandleRequest(c, func(c *gin.Context) error {
 		token := c.GetHeader("Authorization")
-
-		// Добавление префикса "Bearer" к токену
-		if token != "" {
-			token = "Bearer " + token
+		if token == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization token is required"})
+			return nil
 		}
 
-		// Подготовка запроса к внешнему API
-		client := &http.Client{}
-		req, err := http.NewRequest("GET", "https://backend.ddapps.io/api/v1/auth/me?with_user_information=1", nil)
-		if err != nil {
-			logrus.Errorf("Failed to create request: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-			return err
+		if services.IsWalletSessionToken(token) {
+			user, err := resolveWalletSession(token)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization token"})
+				return nil
+			}
+			c.JSON(http.StatusOK, UserMeResponse{Data: user})
+			return nil
 		}
-		req.Header.Set("Authorization", token)
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Accept", "application/json")
 
-		// Отправка запроса к внешнему API
-		resp, err := client.Do(req)
-		if err != nil {
-			logrus.Errorf("Failed to send request: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to user service"})
-			return err
+		user, _, err := authCache.verify(token)
+		if errors.Is(err, ErrInvalidToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization token"})
+			return nil
 		}
-		defer resp.Body.Close()
-
-		// Чтение и обработка ответа от внешнего API
-		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			logrus.Errorf("Failed to read response body: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read user information"})
-			return err
-		}
-
-		var userMeResp UserMeResponse
-		if err := json.Unmarshal(body, &userMeResp); err != nil {
-			logrus.Errorf("Failed to unmarshal user information: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process user information"})
-			return err
+			logrus.Errorf("Failed to verify token: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to connect to user service"})
+			return nil
 		}
 
 		// Сохранение информации о пользователе в хранилище
-		repository.SaveUser(repository.User{
-			ID:            userMeResp.Data.ID,
-			Login:         userMeResp.Data.Login,
-			Email:         userMeResp.Data.Email,
-			Phone:         userMeResp.Data.Phone,
-			Nick:          userMeResp.Data.Nick,
-			Locale:        userMeResp.Data.Locale,
-			Avatar:        userMeResp.Data.Avatar,
-			Wallet:        userMeResp.Data.Wallet,
-			Roles:         extractRoles(userMeResp.Data.Roles),
-			Subscriptions: extractSubscriptions(userMeResp.Data.Subscriptions),
-		})
+		saveUser(user)
 
 		// Логирование полученной информации о пользователе
-		logrus.Infof("Получена информация о пользователе: %+v", userMeResp.Data)
+		logrus.Infof("Получена информация о пользователе: %+v", user)
 
 		// Возвращение ответа клиенту
-		c.JSON(resp.StatusCode, userMeResp)
+		c.JSON(http.StatusOK, UserMeResponse{Data: user})
 		return nil
 	})
 }
 
-// GetUserByIDHandler обрабатывает запрос для получения информации о пользователе по его ID
+// saveUser сохраняет пользователя, полученного от поставщика удостоверений, в таблицу users
+func saveUser(user User) {
+	if user.ID <= 0 || user.APIKeyID != 0 {
+		return
+	}
+	wallet := user.Wallet
+	if normalized, err := utils.NormalizeAddress(wallet); err == nil {
+		wallet = normalized
+	}
+	err := repository.SaveUser(repository.User{
+		ID:            user.ID,
+		Login:         user.Login,
+		Email:         user.Email,
+		Phone:         user.Phone,
+		Nick:          user.Nick,
+		Locale:        user.Locale,
+		Avatar:        user.Avatar,
+		Wallet:        wallet,
+		Roles:         extractRoles(user.Roles),
+		Subscriptions: extractSubscriptions(user.Subscriptions),
+	})
+	if err != nil {
+		logrus.Errorf("Failed to save user %d: %v", user.ID, err)
+	}
+}
+
+// GetUserByIDHandler обрабатывает GET /users/:id запрос для получения сохраненного пользователя
 func GetUserByIDHandler(c *gin.Context) {
-	utils.HandleRequest(c, func(c *gin.Context) error {
-		id := c.Param("id")
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
 
-		// Преобразование ID в число
-		userID, err := strconv.Atoi(id)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
-			return err
-		}
+	user, err := repository.GetUserByID(userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		utils.JSONResponse(c, http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		logrus.Errorf("Failed to get user %d: %v", userID, err)
+		return
+	}
 
-		// Получение информации о пользователе из хранилища
-		user, err := repository.GetUserByID(userID)
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return err
-		}
+	utils.JSONResponse(c, http.StatusOK, user)
+}
 
-		// Возвращение информации о пользователе
-		c.JSON(http.StatusOK, user)
-		return nil
-	})
+// ListUsersHandler обрабатывает GET /users?wallet= запрос для поиска сохраненных пользователей по кошельку
+func ListUsersHandler(c *gin.Context) {
+	wallet, err := utils.NormalizeAddress(c.Query("wallet"))
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid or missing wallet"})
+		return
+	}
+
+	users, err := repository.GetUsersByWallet(wallet)
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to list users"})
+		logrus.Errorf("Failed to list users by wallet %s: %v", wallet, err)
+		return
+	}
+
+	utils.JSONResponse(c, http.StatusOK, gin.H{"users": users})
 }
 
-// Вспомогательная функция для извлечения ролей пользователя
-func extractRoles(roles []Role) []string {
-	var result []string
+// Вспомогательная функция для извлечения ролей пользователя вместе с разрешениями
+func extractRoles(roles []Role) []repository.UserRole {
+	var result []repository.UserRole
 	for _, role := range roles {
-		result = append(result, role.Name)
+		userRole := repository.UserRole{Name: role.Name, Permissions: []string{}}
+		for _, permission := range role.Permissions {
+			userRole.Permissions = append(userRole.Permissions, permission.Name)
+		}
+		result = append(result, userRole)
 	}
 	return result
 }
@@ -260,77 +256,51 @@ func extractSubscriptions(subscriptions []Subscription) []repository.Subscriptio
 	return result
 }
 
-// AuthMiddleware проверяет JWT токен и извлекает информацию о пользователе
+// AuthMiddleware проверяет токен через поставщика удостоверений Identity и извлекает информацию о пользователе.
+// Результат проверки кэшируется, см. authCache. Вместо токена сервис может передать API ключ в заголовке X-API-Key,
+// а участник DAO - токен сессии кошелька, полученный входом подписью (см. WalletSignInHandler).
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if apiKey := c.GetHeader(APIKeyHeader); apiKey != "" {
+			authenticateAPIKey(c, apiKey)
+			return
+		}
+
 		token := c.GetHeader("Authorization")
 		if token == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization token is required"})
 			c.Abort()
 			return
 		}
-
-		// Добавление префикса "Bearer" к токену
-		if token != "" {
-			token = "Bearer " + token
-		}
-
-		// Подготовка запроса к внешнему API для проверки токена и получения информации о пользователе
-		client := &http.Client{}
-		req, err := http.NewRequest("GET", "https://backend.ddapps.io/api/v1/auth/me?with_user_information=1", nil)
-		if err != nil {
-			logrus.Errorf("Failed to create request: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-			c.Abort()
+		if services.IsWalletSessionToken(token) {
+			authenticateWalletSession(c, token)
 			return
 		}
-		req.Header.Set("Authorization", token)
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Accept", "application/json")
 
-		// Отправка запроса к внешнему API
-		resp, err := client.Do(req)
-		if err != nil {
-			logrus.Errorf("Failed to send request: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to authentication service"})
+		user, fresh, err := authCache.verify(token)
+		if errors.Is(err, ErrInvalidToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization token"})
 			c.Abort()
 			return
 		}
-		defer resp.Body.Close()
-
-		// Чтение и обработка ответа от внешнего API
-		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			logrus.Errorf("Failed to read response body: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read authentication response"})
+			logrus.Errorf("Failed to verify token: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to connect to authentication service"})
 			c.Abort()
 			return
 		}
 
-		var userMeResp UserMeResponse
-		if err := json.Unmarshal(body, &userMeResp); err != nil {
-			logrus.Errorf("Failed to unmarshal user information: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process user information"})
-			c.Abort()
-			return
+		// Пользователь, заново полученный от поставщика удостоверений, сохраняется в таблицу users
+		if fresh {
+			saveUser(user)
 		}
 
 		// Сохранение информации о пользователе в контексте запроса
-		c.Set("user", userMeResp.Data)
+		c.Set("user", user)
 
 		// Логирование полученной информации о пользователе
-		logrus.Infof("Пользователь авторизован: %+v", userMeResp.Data)
+		logrus.Debugf("Пользователь авторизован: %+v", user)
 
 		c.Next()
 	}
 }
-
-// Вспомогательная функция для проверки роли "admin" (нечувствительная к регистру)
-func isAdmin(user User) bool {
-	for _, role := range user.Roles {
-		if strings.ToLower(role.Name) == "admin" {
-			return true
-		}
-	}
-	return false
-}
@@ -0,0 +1,151 @@
+// Package handlers Обработчик тайного голосования commit-reveal
+package handlers
+
+import (
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"dao_vote/back-end/utils"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// CommitVoteHandler обрабатывает POST /votes/:id/commit запрос для подачи хэш-обязательства голоса
+func CommitVoteHandler(c *gin.Context) {
+	vote, voter, ok := commitRevealContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.CommitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		logrus.Errorf("Invalid request body: %v", err)
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		logrus.Errorf("Validation error: %v", err)
+		return
+	}
+
+	commitment, err := services.SubmitCommitment(vote, voter, req.Commitment)
+	if err != nil {
+		respondCommitRevealError(c, err)
+		return
+	}
+
+	utils.JSONResponse(c, http.StatusCreated, commitment)
+	logrus.Infof("Commitment accepted for vote %d from %s", vote.ID, voter)
+}
+
+// RevealVoteHandler обрабатывает POST /votes/:id/reveal запрос для раскрытия голоса, поданного через API
+func RevealVoteHandler(c *gin.Context) {
+	vote, voter, ok := commitRevealContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.RevealRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		logrus.Errorf("Invalid request body: %v", err)
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		logrus.Errorf("Validation error: %v", err)
+		return
+	}
+
+	commitment, err := services.RevealVote(vote, voter, req)
+	if err != nil {
+		respondCommitRevealError(c, err)
+		return
+	}
+
+	utils.JSONResponse(c, http.StatusOK, commitment)
+	logrus.Infof("Vote %d revealed by %s", vote.ID, voter)
+}
+
+// commitRevealContext получает голосование и кошелек текущего пользователя для запросов commit-reveal
+func commitRevealContext(c *gin.Context) (models.VoteInfo, string, bool) {
+	voteID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid VoteID"})
+		logrus.Errorf("Invalid VoteID: %v", err)
+		return models.VoteInfo{}, "", false
+	}
+
+	vote, err := services.GetVote(voteID)
+	if err != nil {
+		utils.JSONResponse(c, http.StatusNotFound, gin.H{"error": err.Error()})
+		logrus.Errorf("VoteInfo not found: %v", err)
+		return models.VoteInfo{}, "", false
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		utils.JSONResponse(c, http.StatusUnauthorized, gin.H{"error": "User not authorized"})
+		return models.VoteInfo{}, "", false
+	}
+	voter := user.(User).Wallet
+	if voter == "" {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "User has no wallet"})
+		return models.VoteInfo{}, "", false
+	}
+	return vote, voter, true
+}
+
+// respondCommitRevealError преобразует ошибку commit-reveal в HTTP ответ
+func respondCommitRevealError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrAlreadyVoted):
+		utils.JSONResponse(c, http.StatusConflict, gin.H{"error": "User has already committed a vote"})
+	case errors.Is(err, repository.ErrCommitmentNotFound):
+		utils.JSONResponse(c, http.StatusNotFound, gin.H{"error": "Commitment not found"})
+	case errors.Is(err, services.ErrNotDAOMember):
+		utils.JSONResponse(c, http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotCommitReveal), errors.Is(err, services.ErrCommitPhaseClosed),
+		errors.Is(err, services.ErrRevealPhaseClosed), errors.Is(err, services.ErrCommitmentMismatch),
+		errors.Is(err, services.ErrInvalidChoice):
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to process ballot"})
+		logrus.Errorf("Failed to process commit-reveal ballot: %v", err)
+	}
+}
+
+// parseBallotWindows считывает из формы окна тайного голосования commit_ends_at и reveal_ends_at (RFC3339)
+func parseBallotWindows(c *gin.Context, vote *models.VoteInfo) error {
+	if vote.BallotType != models.BallotCommitReveal {
+		return nil
+	}
+
+	commitEndsAt, err := time.Parse(time.RFC3339, c.PostForm("commit_ends_at"))
+	if err != nil {
+		return errors.New("commit_ends_at is required for commit_reveal ballots (RFC3339)")
+	}
+	if !commitEndsAt.After(time.Now()) {
+		return errors.New("commit_ends_at must be in the future")
+	}
+	commitEndsAt = commitEndsAt.UTC()
+	vote.CommitEndsAt = &commitEndsAt
+
+	if value := c.PostForm("reveal_ends_at"); value != "" {
+		revealEndsAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return errors.New("reveal_ends_at must be RFC3339")
+		}
+		if !revealEndsAt.After(commitEndsAt) {
+			return errors.New("reveal_ends_at must be after commit_ends_at")
+		}
+		revealEndsAt = revealEndsAt.UTC()
+		vote.RevealEndsAt = &revealEndsAt
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+// Package handlers Правила допуска к действиям по подпискам пользователей
+package handlers
+
+import (
+	"dao_vote/back-end/repository"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// EligibilityRule условие допуска к действию: у пользователя должна быть хотя бы одна действующая
+// подписка с подходящим тегом и уровнем не ниже MinTier
+type EligibilityRule struct {
+	Tags        []string `json:"tags"`         // Подходящие теги подписок (пусто - любой тег)
+	MinTier     int      `json:"min_tier"`     // Минимальный уровень подписки
+	ExemptRoles []string `json:"exempt_roles"` // Роли, на которые правило не распространяется
+}
+
+// EligibilityRules правила допуска по действиям (votes.create, votes.vote, withdraw.create).
+// Действие без правила доступно без подписки.
+var EligibilityRules = map[string]EligibilityRule{}
+
+// subscriptionTimeLayouts форматы дат начала и окончания подписки
+var subscriptionTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+// LoadEligibilityConfig загружает правила допуска из JSON файла ELIGIBILITY_RULES_FILE
+func LoadEligibilityConfig() error {
+	path := os.Getenv("ELIGIBILITY_RULES_FILE")
+	if path == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	rules := map[string]EligibilityRule{}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return fmt.Errorf("invalid eligibility rules config: %w", err)
+	}
+	for action, rule := range rules {
+		if rule.MinTier < 0 {
+			return fmt.Errorf("invalid eligibility rule %s: min_tier must not be negative", action)
+		}
+	}
+	EligibilityRules = rules
+	return nil
+}
+
+// CheckEligibility проверяет допуск пользователя к действию на момент now.
+// При отказе возвращает причину, перечисляющую, чем не подошла каждая подписка.
+func CheckEligibility(user User, action string, now time.Time) (string, bool) {
+	rule, exists := EligibilityRules[action]
+	if !exists {
+		return "", true
+	}
+	for _, role := range user.Roles {
+		for _, exempt := range rule.ExemptRoles {
+			if strings.EqualFold(role.Name, exempt) {
+				return "", true
+			}
+		}
+	}
+
+	if len(user.Subscriptions) == 0 {
+		return fmt.Sprintf("%s requires %s: user has no subscriptions", action, rule.describe()), false
+	}
+	var reasons []string
+	for _, sub := range user.Subscriptions {
+		reason := rule.mismatch(sub, now)
+		if reason == "" {
+			return "", true
+		}
+		reasons = append(reasons, fmt.Sprintf("subscription %q %s", sub.Tag, reason))
+	}
+	return fmt.Sprintf("%s requires %s: %s", action, rule.describe(), strings.Join(reasons, "; ")), false
+}
+
+// describe возвращает требование правила в виде текста для причины отказа
+func (rule EligibilityRule) describe() string {
+	requirement := "an active subscription"
+	if len(rule.Tags) > 0 {
+		requirement += " " + strings.Join(rule.Tags, " or ")
+	}
+	if rule.MinTier > 0 {
+		requirement += fmt.Sprintf(" of tier %d or higher", rule.MinTier)
+	}
+	return requirement
+}
+
+// mismatch возвращает, чем подписка не подходит под правило, или пустую строку
+func (rule EligibilityRule) mismatch(sub Subscription, now time.Time) string {
+	if len(rule.Tags) > 0 {
+		matched := false
+		for _, tag := range rule.Tags {
+			if strings.EqualFold(sub.Tag, tag) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return "has a different tag"
+		}
+	}
+	if sub.Tier < rule.MinTier {
+		return fmt.Sprintf("has tier %d", sub.Tier)
+	}
+
+	if sub.StartsAt != "" {
+		startsAt, err := parseSubscriptionTime(sub.StartsAt)
+		if err != nil {
+			return fmt.Sprintf("has invalid starts_at %q", sub.StartsAt)
+		}
+		if now.Before(startsAt) {
+			return "starts at " + startsAt.UTC().Format(time.RFC3339)
+		}
+	}
+	if sub.EndsAt != "" {
+		endsAt, err := parseSubscriptionTime(sub.EndsAt)
+		if err != nil {
+			return fmt.Sprintf("has invalid ends_at %q", sub.EndsAt)
+		}
+		if !now.Before(endsAt) {
+			return "expired at " + endsAt.UTC().Format(time.RFC3339)
+		}
+	}
+	return ""
+}
+
+// parseSubscriptionTime разбирает дату подписки в одном из форматов subscriptionTimeLayouts (без зоны - UTC)
+func parseSubscriptionTime(value string) (time.Time, error) {
+	var err error
+	for _, layout := range subscriptionTimeLayouts {
+		var parsed time.Time
+		if parsed, err = time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// CheckWalletEligibility проверяет допуск к действию владельца кошелька, подтвержденного подписью без авторизации.
+// Кошелек допускается, если допущен любой сохраненный пользователь с этим кошельком или роль wallet
+// участника, вошедшего подписью кошелька.
+func CheckWalletEligibility(wallet, action string, now time.Time) (string, bool, error) {
+	if _, exists := EligibilityRules[action]; !exists {
+		return "", true, nil
+	}
+	users, err := repository.GetUsersByWallet(wallet)
+	if err != nil {
+		return "", false, err
+	}
+
+	candidates := []User{{Wallet: wallet, Roles: []Role{{Name: walletRole}}}}
+	for _, stored := range users {
+		user := User{ID: stored.ID, Login: stored.Login, Wallet: stored.Wallet}
+		for _, role := range stored.Roles {
+			user.Roles = append(user.Roles, Role{Name: role.Name})
+		}
+		for _, sub := range stored.Subscriptions {
+			user.Subscriptions = append(user.Subscriptions, Subscription(sub))
+		}
+		candidates = append(candidates, user)
+	}
+
+	var reason string
+	for _, user := range candidates {
+		var eligible bool
+		if reason, eligible = CheckEligibility(user, action, now); eligible {
+			return "", true, nil
+		}
+	}
+	return reason, false, nil
+}
+
+// RequireEligibility middleware пропускает запрос, только если пользователь из контекста
+// проходит правило допуска действия. Участник, вошедший подписью кошелька, проверяется по кошельку
+// так же, как подписанный голос. Должен подключаться после AuthMiddleware.
+func RequireEligibility(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := currentUser(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+		var reason string
+		var eligible bool
+		if user.WalletSessionID != 0 {
+			var err error
+			if reason, eligible, err = CheckWalletEligibility(user.Wallet, action, time.Now()); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check eligibility"})
+				logrus.Errorf("Failed to check eligibility of wallet %s: %v", user.Wallet, err)
+				c.Abort()
+				return
+			}
+		} else {
+			reason, eligible = CheckEligibility(user, action, time.Now())
+		}
+		if !eligible {
+			logrus.Warnf("User %d is not eligible for %s: %s", user.ID, action, reason)
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not eligible", "action": action, "reason": reason})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
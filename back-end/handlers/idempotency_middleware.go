@@ -0,0 +1,129 @@
+// Package handlers Middleware идемпотентности запросов
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"dao_vote/back-end/services"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"net/http"
+)
+
+// IdempotencyKeyHeader заголовок с ключом идемпотентности запроса
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength максимальная длина ключа идемпотентности
+const maxIdempotencyKeyLength = 255
+
+// idempotencySideEffectKey ключ контекста, отмечающий выполненное обработчиком внешнее действие
+const idempotencySideEffectKey = "idempotency_side_effect"
+
+// MarkIdempotentSideEffect отмечает, что обработчик выполнил действие, которое нельзя безопасно повторить
+// (например, записал вывод средств и обратился к внешнему API). Ответ такого запроса сохраняется
+// для ключа идемпотентности даже при ошибке сервера.
+func MarkIdempotentSideEffect(c *gin.Context) {
+	c.Set(idempotencySideEffectKey, true)
+}
+
+// idempotencyWriter сохраняет копию тела ответа для повторных запросов
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyWriter) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// IdempotencyMiddleware обрабатывает заголовок Idempotency-Key.
+// Повторный запрос с тем же ключом получает сохраненный ответ первого запроса,
+// а параллельный дубликат - 409, пока первый запрос выполняется.
+func IdempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			c.Abort()
+			return
+		}
+
+		body, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		// Ключ действует в пространстве пользователя, метода и пути запроса
+		userID := 0
+		if user, exists := c.Get("user"); exists {
+			userID = user.(User).ID
+		}
+		scopedKey := fmt.Sprintf("%d:%s %s:%s", userID, c.Request.Method, c.Request.URL.Path, key)
+		hash := sha256.Sum256(append([]byte(c.Request.Method+" "+c.Request.URL.Path+"\n"), body...))
+
+		record, err := services.BeginIdempotentRequest(scopedKey, hex.EncodeToString(hash[:]))
+		switch {
+		case errors.Is(err, services.ErrIdempotencyInFlight):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		case errors.Is(err, services.ErrIdempotencyKeyReused):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		case err != nil:
+			logrus.Errorf("Failed to process Idempotency-Key: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process Idempotency-Key"})
+			c.Abort()
+			return
+		}
+
+		// Повторный запрос получает сохраненный ответ без повторного выполнения обработчика
+		if record != nil {
+			logrus.Infof("Replaying stored response for Idempotency-Key %s", key)
+			if record.Location != "" {
+				c.Header("Location", record.Location)
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.ResponseStatus, record.ContentType, record.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		defer func() {
+			// При панике обработчика до внешнего действия ключ освобождается, чтобы запрос можно было повторить
+			sideEffect := c.GetBool(idempotencySideEffectKey)
+			if recovered := recover(); recovered != nil {
+				if err := services.CompleteIdempotentRequest(scopedKey, http.StatusInternalServerError, sideEffect, nil, "", ""); err != nil {
+					logrus.Errorf("Failed to release Idempotency-Key %s: %v", key, err)
+				}
+				panic(recovered)
+			}
+			if err := services.CompleteIdempotentRequest(scopedKey, writer.Status(), sideEffect, writer.body.Bytes(),
+				writer.Header().Get("Content-Type"), writer.Header().Get("Location")); err != nil {
+				logrus.Errorf("Failed to store response for Idempotency-Key %s: %v", key, err)
+			}
+		}()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,132 @@
+// Package handlers Поставщики удостоверений пользователей
+package handlers
+
+import (
+	"bytes"
+	"dao_vote/back-end/services"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+)
+
+// IdentityProvider выполняет вход пользователя и определяет пользователя по токену
+type IdentityProvider interface {
+	// Login проверяет учетные данные и возвращает токен
+	Login(req AuthRequest) (AuthResponse, error)
+	// ResolveToken возвращает пользователя по токену или ErrInvalidToken
+	ResolveToken(token string) (User, error)
+}
+
+var (
+	// ErrInvalidToken возвращается, если поставщик удостоверений отклонил токен
+	ErrInvalidToken = errors.New("invalid authorization token")
+	// ErrInvalidCredentials возвращается, если логин или пароль неверны
+	ErrInvalidCredentials = errors.New("invalid login or password")
+)
+
+// Identity поставщик удостоверений, используемый обработчиками авторизации и AuthMiddleware
+var Identity IdentityProvider = DDAppsIdentityProvider{}
+
+// LoadIdentityProviderConfig выбирает поставщика удостоверений по переменной окружения IDENTITY_PROVIDER:
+// ddapps (по умолчанию) или local с конфигурацией из файла LOCAL_IDENTITY_FILE
+func LoadIdentityProviderConfig() error {
+	switch name := os.Getenv("IDENTITY_PROVIDER"); name {
+	case "", "ddapps":
+		Identity = DDAppsIdentityProvider{}
+	case "local":
+		path := os.Getenv("LOCAL_IDENTITY_FILE")
+		if path == "" {
+			return errors.New("LOCAL_IDENTITY_FILE is required for the local identity provider")
+		}
+		provider, err := LoadLocalIdentityProvider(path)
+		if err != nil {
+			return err
+		}
+		Identity = provider
+	default:
+		return fmt.Errorf("unknown IDENTITY_PROVIDER %q", name)
+	}
+	return nil
+}
+
+// authClient HTTP клиент для запросов к сервису авторизации
+var authClient = &http.Client{Timeout: 10 * time.Second}
+
+// DDAppsIdentityProvider поставщик удостоверений на основе API Decimal Dapps
+type DDAppsIdentityProvider struct{}
+
+// Login выполняет вход через /auth/user_login
+func (DDAppsIdentityProvider) Login(authReq AuthRequest) (AuthResponse, error) {
+	jsonData, err := json.Marshal(authReq)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+
+	resp, err := authClient.Post(services.DDAppsAPIURL+"/auth/user_login", "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusUnprocessableEntity:
+		return AuthResponse{}, ErrInvalidCredentials
+	default:
+		return AuthResponse{}, fmt.Errorf("authentication service returned status %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	var authResp AuthResponse
+	if err := json.Unmarshal(body, &authResp); err != nil {
+		return AuthResponse{}, err
+	}
+	if authResp.Token == "" {
+		return AuthResponse{}, ErrInvalidCredentials
+	}
+	return authResp, nil
+}
+
+// ResolveToken запрашивает пользователя по токену через /auth/me
+func (DDAppsIdentityProvider) ResolveToken(token string) (User, error) {
+	req, err := http.NewRequest("GET", services.DDAppsAPIURL+"/auth/me?with_user_information=1", nil)
+	if err != nil {
+		return User{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := authClient.Do(req)
+	if err != nil {
+		return User{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return User{}, ErrInvalidToken
+	}
+	if resp.StatusCode != http.StatusOK {
+		return User{}, fmt.Errorf("authentication service returned status %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return User{}, err
+	}
+	var userMeResp UserMeResponse
+	if err := json.Unmarshal(body, &userMeResp); err != nil {
+		return User{}, err
+	}
+	if userMeResp.Data.ID == 0 {
+		return User{}, ErrInvalidToken
+	}
+	return userMeResp.Data, nil
+}
@@ -0,0 +1,40 @@
+// Package handlers Обработчик фоновых задач
+package handlers
+
+import (
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"dao_vote/back-end/utils"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// GetJobHandler обрабатывает GET /jobs/:id запрос для получения статуса фоновой задачи
+func GetJobHandler(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		utils.JSONResponse(c, http.StatusUnauthorized, gin.H{"error": "User not authorized"})
+		return
+	}
+
+	job, err := services.GetJob(c.Param("id"))
+	if errors.Is(err, repository.ErrJobNotFound) {
+		utils.JSONResponse(c, http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to get job"})
+		logrus.Errorf("Failed to get job: %v", err)
+		return
+	}
+
+	// Задача доступна только её автору и пользователям с разрешением jobs.read_all
+	if job.UserID != user.(User).ID && !HasPermission(user.(User), PermJobsReadAll) {
+		utils.JSONResponse(c, http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+
+	utils.JSONResponse(c, http.StatusOK, job)
+}
@@ -0,0 +1,166 @@
+// Package handlers Локальный поставщик удостоверений для разработки и тестов
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// LocalIdentityMinSecretLength минимальная длина секрета для подписи токенов
+const LocalIdentityMinSecretLength = 16
+
+// LocalUser пользователь локального поставщика удостоверений с паролем для входа
+type LocalUser struct {
+	User
+	Password string `json:"password"`
+}
+
+// LocalIdentityConfig конфигурация локального поставщика удостоверений
+type LocalIdentityConfig struct {
+	Secret   string      `json:"secret"`
+	TokenTTL string      `json:"token_ttl"` // Срок действия токена в формате time.ParseDuration, по умолчанию 24h
+	Users    []LocalUser `json:"users"`
+}
+
+// localTokenClaims содержимое токена локального поставщика
+type localTokenClaims struct {
+	UserID    int   `json:"sub"`
+	ExpiresAt int64 `json:"exp"`
+}
+
+// LocalIdentityProvider выдает токены, подписанные HMAC-SHA256, пользователям из конфигурации
+type LocalIdentityProvider struct {
+	secret   []byte
+	tokenTTL time.Duration
+	users    map[int]LocalUser
+	logins   map[string]int
+}
+
+// NewLocalIdentityProvider создает локального поставщика удостоверений
+func NewLocalIdentityProvider(secret string, tokenTTL time.Duration, users []LocalUser) (*LocalIdentityProvider, error) {
+	if len(secret) < LocalIdentityMinSecretLength {
+		return nil, fmt.Errorf("local identity secret must be at least %d characters", LocalIdentityMinSecretLength)
+	}
+	if tokenTTL <= 0 {
+		return nil, errors.New("local identity token TTL must be positive")
+	}
+
+	provider := &LocalIdentityProvider{
+		secret:   []byte(secret),
+		tokenTTL: tokenTTL,
+		users:    make(map[int]LocalUser),
+		logins:   make(map[string]int),
+	}
+	for _, user := range users {
+		if user.ID <= 0 {
+			return nil, fmt.Errorf("local user %q must have a positive id", user.Login)
+		}
+		if _, exists := provider.users[user.ID]; exists {
+			return nil, fmt.Errorf("duplicate local user id %d", user.ID)
+		}
+		provider.users[user.ID] = user
+		for _, login := range []string{user.Login, user.Email} {
+			if login == "" {
+				continue
+			}
+			key := strings.ToLower(login)
+			if _, exists := provider.logins[key]; exists {
+				return nil, fmt.Errorf("duplicate local user login %q", login)
+			}
+			provider.logins[key] = user.ID
+		}
+	}
+	return provider, nil
+}
+
+// LoadLocalIdentityProvider создает локального поставщика удостоверений из JSON файла конфигурации
+func LoadLocalIdentityProvider(path string) (*LocalIdentityProvider, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config LocalIdentityConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid local identity config: %w", err)
+	}
+
+	tokenTTL := 24 * time.Hour
+	if config.TokenTTL != "" {
+		if tokenTTL, err = time.ParseDuration(config.TokenTTL); err != nil {
+			return nil, fmt.Errorf("invalid local identity token_ttl %q", config.TokenTTL)
+		}
+	}
+	return NewLocalIdentityProvider(config.Secret, tokenTTL, config.Users)
+}
+
+// Login проверяет логин (или email) и пароль пользователя и выдает подписанный токен
+func (p *LocalIdentityProvider) Login(req AuthRequest) (AuthResponse, error) {
+	id, ok := p.logins[strings.ToLower(req.Login)]
+	if !ok {
+		return AuthResponse{}, ErrInvalidCredentials
+	}
+	user := p.users[id]
+	if user.Password == "" || subtle.ConstantTimeCompare([]byte(user.Password), []byte(req.Password)) != 1 {
+		return AuthResponse{}, ErrInvalidCredentials
+	}
+	token, err := p.IssueToken(id)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	return AuthResponse{Token: token}, nil
+}
+
+// IssueToken выдает токен пользователю с указанным ID
+func (p *LocalIdentityProvider) IssueToken(userID int) (string, error) {
+	if _, ok := p.users[userID]; !ok {
+		return "", fmt.Errorf("local user %d not found", userID)
+	}
+	payload, err := json.Marshal(localTokenClaims{UserID: userID, ExpiresAt: time.Now().Add(p.tokenTTL).Unix()})
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + p.sign(encoded), nil
+}
+
+// ResolveToken проверяет подпись и срок действия токена и возвращает пользователя
+func (p *LocalIdentityProvider) ResolveToken(token string) (User, error) {
+	token = strings.TrimPrefix(token, "Bearer ")
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(p.sign(encoded))) {
+		return User{}, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return User{}, ErrInvalidToken
+	}
+	var claims localTokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return User{}, ErrInvalidToken
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return User{}, ErrInvalidToken
+	}
+
+	user, ok := p.users[claims.UserID]
+	if !ok {
+		return User{}, ErrInvalidToken
+	}
+	return user.User, nil
+}
+
+// sign возвращает подпись HMAC-SHA256 содержимого токена
+func (p *LocalIdentityProvider) sign(encoded string) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,218 @@
+// Package handlers Обработчик импорта и экспорта реестра участников DAO
+package handlers
+
+import (
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"dao_vote/back-end/utils"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxMemberImportSize максимальный размер импортируемого списка участников
+var MaxMemberImportSize int64 = 10 << 20
+
+// ImportMembersHandler обрабатывает POST /wallets/import запрос. Список участников передается телом запроса
+// (text/csv или application/json) или файлом file в multipart/form-data. Без apply=true возвращается
+// только разница с текущим реестром; с apply=true разница применяется в одной транзакции.
+func ImportMembersHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	apply, _ := strconv.ParseBool(c.DefaultQuery("apply", "false"))
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxMemberImportSize)
+	body, format, err := memberImportSource(c)
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	members, err := services.ParseMembers(format, body)
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reason := c.DefaultQuery("reason", "import")
+	diff, err := services.ImportMembers(members, apply, changeMeta(user, reason))
+	if errors.Is(err, repository.ErrMemberRegistryChanged) {
+		utils.JSONResponse(c, http.StatusConflict, gin.H{"error": "Member registry changed during import, retry"})
+		return
+	}
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to import members"})
+		logrus.Errorf("Failed to import members: %v", err)
+		return
+	}
+
+	utils.JSONResponse(c, http.StatusOK, diff)
+	if apply {
+		audit(c, "wallets.import", "vote_strength", "", nil, diff)
+		logrus.Infof("Member registry imported by user %d: %d added, %d removed, %d changed",
+			user.ID, len(diff.Added), len(diff.Removed), len(diff.Changed))
+	}
+}
+
+// memberImportSource возвращает тело списка участников и его формат. Формат берется из параметра format,
+// расширения загруженного файла или Content-Type.
+func memberImportSource(c *gin.Context) (io.ReadCloser, string, error) {
+	format := strings.ToLower(c.Query("format"))
+	contentType := c.ContentType()
+
+	if contentType == "multipart/form-data" {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, "", errors.New("file is required")
+		}
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		return file, format, nil
+	}
+
+	if format == "" {
+		switch contentType {
+		case "text/csv":
+			format = services.MemberFormatCSV
+		case "application/json":
+			format = services.MemberFormatJSON
+		default:
+			return nil, "", errors.New("unsupported content type, use text/csv, application/json or format parameter")
+		}
+	}
+	return c.Request.Body, format, nil
+}
+
+// changeMeta возвращает автора и причину изменения реестра участников для истории силы голоса
+func changeMeta(user User, reason string) models.VoteStrengthChangeMeta {
+	return models.VoteStrengthChangeMeta{ActorID: user.ID, Actor: user.Login, Reason: reason}
+}
+
+// UpdateWalletHandler обрабатывает PATCH /wallets/:wallet_address запрос для изменения силы голоса участника
+func UpdateWalletHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	wallet, err := utils.NormalizeAddress(c.Param("wallet_address"))
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid wallet address"})
+		return
+	}
+
+	var req models.VoteStrengthUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	change, err := services.UpdateMemberPower(wallet, req, changeMeta(user, ""))
+	if errors.Is(err, repository.ErrMemberNotFound) {
+		utils.JSONResponse(c, http.StatusNotFound, gin.H{"error": "Wallet not found"})
+		return
+	}
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to update wallet"})
+		logrus.Errorf("Failed to update vote power of %s: %v", wallet, err)
+		return
+	}
+	if change.NewVotePower == nil {
+		utils.JSONResponse(c, http.StatusOK, gin.H{"message": "Vote power unchanged", "wallet_address": wallet, "vote_power": req.VotePower})
+		return
+	}
+
+	utils.JSONResponse(c, http.StatusOK, change)
+	audit(c, "wallet.update", "wallet", wallet, gin.H{"vote_power": *change.OldVotePower},
+		gin.H{"vote_power": *change.NewVotePower, "reason": change.Reason})
+	logrus.Infof("Vote power of %s changed from %d to %d by user %d", wallet, *change.OldVotePower, *change.NewVotePower, user.ID)
+}
+
+// GetWalletHistoryHandler обрабатывает GET /wallets/:wallet_address/history запрос для истории изменений силы голоса
+func GetWalletHistoryHandler(c *gin.Context) {
+	wallet, err := utils.NormalizeAddress(c.Param("wallet_address"))
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid wallet address"})
+		return
+	}
+
+	history, err := services.GetVoteStrengthHistory(wallet)
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to get vote power history"})
+		logrus.Errorf("Failed to get vote power history of %s: %v", wallet, err)
+		return
+	}
+	utils.JSONResponse(c, http.StatusOK, gin.H{"wallet_address": wallet, "history": history})
+}
+
+// GetWalletVotePowerHandler обрабатывает GET /wallets/:wallet_address/vote-power?as_of= запрос
+// для получения силы голоса кошелька на момент времени (по умолчанию - сейчас)
+func GetWalletVotePowerHandler(c *gin.Context) {
+	wallet, err := utils.NormalizeAddress(c.Param("wallet_address"))
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid wallet address"})
+		return
+	}
+
+	asOf := time.Now()
+	if value := c.Query("as_of"); value != "" {
+		if asOf, err = time.Parse(time.RFC3339, value); err != nil {
+			utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid as_of, expected RFC 3339 time"})
+			return
+		}
+	}
+
+	power, err := services.GetVoteStrengthAsOf(wallet, asOf)
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to get vote power"})
+		logrus.Errorf("Failed to get vote power of %s as of %s: %v", wallet, asOf, err)
+		return
+	}
+	utils.JSONResponse(c, http.StatusOK, power)
+}
+
+// ExportMembersHandler обрабатывает GET /wallets/export запрос для выгрузки реестра участников в CSV или JSON
+func ExportMembersHandler(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", services.MemberFormatCSV))
+	if format != services.MemberFormatCSV && format != services.MemberFormatJSON {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid format"})
+		return
+	}
+
+	members, err := services.ListMembers()
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to export members"})
+		logrus.Errorf("Failed to export members: %v", err)
+		return
+	}
+
+	audit(c, "wallets.export", "vote_strength", "", nil, gin.H{"format": format, "members": len(members)})
+	c.Header("Content-Disposition", `attachment; filename="vote_strength.`+format+`"`)
+	if format == services.MemberFormatJSON {
+		utils.JSONResponse(c, http.StatusOK, members)
+		return
+	}
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := services.WriteMembersCSV(c.Writer, members); err != nil {
+		logrus.Errorf("Failed to write members CSV: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+// Package handlers Проверка разрешений пользователей на уровне маршрутов
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Разрешения, требуемые маршрутами
+const (
+	PermVotesCreate               = "votes.create"              // Создание голосований
+	PermVotesVote                 = "votes.vote"                // Голосование
+	PermVotesDelete               = "votes.delete"              // Удаление голосований
+	PermVotesReconcile            = "votes.reconcile"           // Сверка голосов с блокчейном
+	PermVotesExport               = "votes.export"              // Выгрузка голосований с итогами для отчетов
+	PermWithdrawCreate            = "withdraw.create"           // Вывод средств
+	PermWithdrawReadAll           = "withdraw.read_all"         // Просмотр выводов всех пользователей
+	PermWithdrawApprove           = "withdraw.approve"          // Одобрение и отклонение крупных выводов
+	PermWithdrawalPoliciesRead    = "withdrawal_policies.read"  // Просмотр политик вывода и журнала отказов
+	PermWithdrawalPoliciesWrite   = "withdrawal_policies.write" // Изменение политик вывода
+	PermWalletsWrite              = "wallets.write"             // Добавление, удаление и импорт кошельков
+	PermWalletsRead               = "wallets.read"              // Выгрузка реестра участников
+	PermTablesRead                = "tables.read"               // Просмотр таблиц базы данных
+	PermJobsReadAll               = "jobs.read_all"             // Просмотр фоновых задач всех пользователей
+	PermAPIKeysManage             = "api_keys.manage"           // Выдача и отзыв API ключей
+	PermUsersRead                 = "users.read"                // Просмотр сохраненных пользователей
+	PermAuditRead                 = "audit.read"                // Просмотр журнала действий администраторов
+	PermissionWildcard            = "*"                         // Все разрешения
+	permissionGroupWildcardSuffix = ".*"                        // Все разрешения группы, например votes.*
+)
+
+// DefaultPermissions разрешения любого авторизованного пользователя
+var DefaultPermissions = []string{PermVotesCreate, PermVotesVote, PermWithdrawCreate}
+
+// RolePermissions разрешения ролей (название роли в нижнем регистре). Дополняют разрешения,
+// переданные поставщиком удостоверений в Role.Permissions.
+var RolePermissions = map[string][]string{
+	"admin": {PermissionWildcard},
+}
+
+// RolePermissionsConfig файл переопределения разрешений ролей
+type RolePermissionsConfig struct {
+	Default []string            `json:"default"` // Если задано, заменяет DefaultPermissions
+	Roles   map[string][]string `json:"roles"`   // Заменяет разрешения перечисленных ролей
+}
+
+// LoadPermissionsConfig переопределяет разрешения ролей из JSON файла ROLE_PERMISSIONS_FILE
+func LoadPermissionsConfig() error {
+	path := os.Getenv("ROLE_PERMISSIONS_FILE")
+	if path == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var config RolePermissionsConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("invalid role permissions config: %w", err)
+	}
+
+	if config.Default != nil {
+		DefaultPermissions = config.Default
+	}
+	for role, permissions := range config.Roles {
+		RolePermissions[strings.ToLower(role)] = permissions
+	}
+	return nil
+}
+
+// UserPermissions возвращает объединение разрешений пользователя: разрешения по умолчанию,
+// разрешения ролей из RolePermissions и разрешения ролей от поставщика удостоверений.
+// Сервис, авторизованный API ключом, имеет только разрешения ключа, а участник, вошедший
+// подписью кошелька, - только разрешения участника DAO.
+func UserPermissions(user User) map[string]bool {
+	permissions := make(map[string]bool)
+	scoped := user.APIKeyID != 0 || user.WalletSessionID != 0
+	if !scoped {
+		for _, permission := range DefaultPermissions {
+			permissions[permission] = true
+		}
+	}
+	for _, role := range user.Roles {
+		if !scoped {
+			for _, permission := range RolePermissions[strings.ToLower(role.Name)] {
+				permissions[permission] = true
+			}
+		}
+		for _, permission := range role.Permissions {
+			permissions[permission.Name] = true
+		}
+	}
+	return permissions
+}
+
+// HasPermission проверяет, что пользователь имеет разрешение напрямую или через * и группу.*
+func HasPermission(user User, permission string) bool {
+	permissions := UserPermissions(user)
+	if permissions[permission] || permissions[PermissionWildcard] {
+		return true
+	}
+	for group := permission; strings.Contains(group, "."); {
+		group = group[:strings.LastIndex(group, ".")]
+		if permissions[group+permissionGroupWildcardSuffix] {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission middleware пропускает запрос, только если пользователь из контекста имеет разрешение.
+// Должен подключаться после AuthMiddleware.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := currentUser(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+		if !HasPermission(user, permission) {
+			logrus.Warnf("User %d denied permission %s for %s %s", user.ID, permission, c.Request.Method, c.FullPath())
+			c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied", "permission": permission})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// currentUser возвращает пользователя из контекста запроса, иначе отвечает 401
+func currentUser(c *gin.Context) (User, bool) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authorized"})
+		return User{}, false
+	}
+	return user.(User), true
+}
@@ -0,0 +1,225 @@
+// Package handlers Потоковая выгрузка голосований с итогами в CSV, JSON Lines и XLSX
+package handlers
+
+import (
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/services"
+	"dao_vote/back-end/utils"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Форматы выгрузки голосований
+const (
+	ExportFormatCSV   = "csv"   // Строка на каждый учтенный голос
+	ExportFormatJSONL = "jsonl" // Объект голосования с голосами участников на каждой строке
+	ExportFormatXLSX  = "xlsx"  // Лист с теми же строками, что и CSV
+)
+
+// exportContentTypes типы содержимого форматов выгрузки
+var exportContentTypes = map[string]string{
+	ExportFormatCSV:   "text/csv; charset=utf-8",
+	ExportFormatJSONL: "application/x-ndjson",
+	ExportFormatXLSX:  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// proposalExportHeader колонки табличной выгрузки голосований
+var proposalExportHeader = []interface{}{
+	"proposal_id", "title", "subtitle", "vote_mode", "ballot_type", "created_at", "status", "resolution",
+	"dao_members", "voted_members", "turnout", "votes_for", "votes_against", "total_transactions",
+	"voter", "vote_power", "choice", "transaction_hash", "source", "error",
+}
+
+// proposalWriter записывает голосования в выбранном формате
+type proposalWriter interface {
+	WriteProposal(proposal models.ProposalExport) error
+	Close() error
+}
+
+// ExportProposalsHandler обрабатывает GET /admin/votes/export: выгружает голосования с итогами, голосами участников
+// и явкой в формате format (csv, jsonl или xlsx) с фильтрами from, to и status (разрешение votes.export).
+// Голосования записываются в ответ по мере подсчета итогов.
+func ExportProposalsHandler(c *gin.Context) {
+	format := c.DefaultQuery("format", ExportFormatCSV)
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "format must be csv, jsonl or xlsx"})
+		return
+	}
+	filter, err := parseProposalExportFilter(c)
+	if err == nil {
+		err = services.ValidateProposalExportFilter(filter)
+	}
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="proposals-%s.%s"`, time.Now().UTC().Format("20060102-150405"), format))
+	c.Status(http.StatusOK)
+	writer, err := newProposalWriter(format, c.Writer)
+	if err != nil {
+		logrus.Errorf("Failed to start proposals export: %v", err)
+		return
+	}
+
+	exported, err := services.ExportProposals(filter, func(proposal models.ProposalExport) error {
+		if err := writer.WriteProposal(proposal); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		// Заголовки уже отправлены: завершающая часть не записывается, поэтому XLSX остается неполным архивом,
+		// а в JSON Lines последней строкой записывается ошибка
+		logrus.Errorf("Proposals export interrupted after %d proposals: %v", exported, err)
+		if format == ExportFormatJSONL {
+			_ = json.NewEncoder(c.Writer).Encode(gin.H{"error": "export interrupted"})
+		}
+		return
+	}
+	if err := writer.Close(); err != nil {
+		logrus.Errorf("Failed to finish proposals export: %v", err)
+		return
+	}
+	audit(c, "votes.export", "vote", "", nil, gin.H{"format": format, "status": filter.Status, "from": filter.From, "to": filter.To, "proposals": exported})
+}
+
+// parseProposalExportFilter разбирает параметры from, to и status выгрузки голосований
+func parseProposalExportFilter(c *gin.Context) (models.ProposalExportFilter, error) {
+	filter := models.ProposalExportFilter{Status: c.Query("status")}
+	var err error
+	if filter.From, err = parseDateParam(c.Query("from")); err != nil {
+		return filter, errors.New("invalid from date")
+	}
+	if filter.To, err = parseDateParam(c.Query("to")); err != nil {
+		return filter, errors.New("invalid to date")
+	}
+	return filter, nil
+}
+
+// newProposalWriter создает запись голосований в формате format
+func newProposalWriter(format string, w io.Writer) (proposalWriter, error) {
+	switch format {
+	case ExportFormatJSONL:
+		return jsonlProposalWriter{encoder: json.NewEncoder(w)}, nil
+	case ExportFormatXLSX:
+		sheet, err := utils.NewXLSXWriter(w, "Proposals")
+		if err != nil {
+			return nil, err
+		}
+		writer := xlsxProposalWriter{sheet: sheet}
+		return writer, sheet.WriteRow(proposalExportHeader)
+	default:
+		writer := csvProposalWriter{csv: csv.NewWriter(w)}
+		return writer, writer.writeRow(proposalExportHeader)
+	}
+}
+
+// spreadsheetFormulaPrefixes начальные символы, с которых табличные редакторы начинают формулу
+const spreadsheetFormulaPrefixes = "=+-@\t\r"
+
+// escapeSpreadsheetFormulas добавляет апостроф к строковым значениям строки, начинающимся с символа формулы,
+// чтобы CSV и XLSX открывались в табличном редакторе как текст
+func escapeSpreadsheetFormulas(row []interface{}) []interface{} {
+	for i, value := range row {
+		if text, ok := value.(string); ok && text != "" && strings.ContainsRune(spreadsheetFormulaPrefixes, rune(text[0])) {
+			row[i] = "'" + text
+		}
+	}
+	return row
+}
+
+// proposalRows возвращает строки табличной выгрузки: по строке на учтенный голос или одну строку без голосов.
+// Строковые значения экранируются от выполнения как формулы.
+func proposalRows(proposal models.ProposalExport) [][]interface{} {
+	var createdAt interface{}
+	if proposal.CreatedAt != nil {
+		createdAt = proposal.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	base := []interface{}{
+		proposal.ID, proposal.Title, proposal.Subtitle, proposal.VoteMode, proposal.BallotType, createdAt, proposal.Status, proposal.Resolution,
+		proposal.DAOMembers, proposal.VotedMembers, proposal.Turnout, proposal.VotesFor, proposal.VotesAgainst, proposal.TotalTransactions,
+	}
+	if len(proposal.Voters) == 0 {
+		return [][]interface{}{escapeSpreadsheetFormulas(append(base, nil, nil, nil, nil, nil, proposal.Error))}
+	}
+
+	rows := make([][]interface{}, 0, len(proposal.Voters))
+	for _, voter := range proposal.Voters {
+		row := append(append([]interface{}{}, base...), voter.Wallet, voter.VotePower, voter.Choice, voter.TransactionHash, voter.Source, proposal.Error)
+		rows = append(rows, escapeSpreadsheetFormulas(row))
+	}
+	return rows
+}
+
+// csvProposalWriter записывает голосования в CSV
+type csvProposalWriter struct {
+	csv *csv.Writer
+}
+
+func (w csvProposalWriter) WriteProposal(proposal models.ProposalExport) error {
+	for _, row := range proposalRows(proposal) {
+		if err := w.writeRow(row); err != nil {
+			return err
+		}
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+func (w csvProposalWriter) writeRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		if value != nil {
+			record[i] = fmt.Sprint(value)
+		}
+	}
+	return w.csv.Write(record)
+}
+
+func (w csvProposalWriter) Close() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// jsonlProposalWriter записывает голосования в JSON Lines
+type jsonlProposalWriter struct {
+	encoder *json.Encoder
+}
+
+func (w jsonlProposalWriter) WriteProposal(proposal models.ProposalExport) error {
+	return w.encoder.Encode(proposal)
+}
+
+func (w jsonlProposalWriter) Close() error {
+	return nil
+}
+
+// xlsxProposalWriter записывает голосования в лист XLSX
+type xlsxProposalWriter struct {
+	sheet *utils.XLSXWriter
+}
+
+func (w xlsxProposalWriter) WriteProposal(proposal models.ProposalExport) error {
+	for _, row := range proposalRows(proposal) {
+		if err := w.sheet.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return w.sheet.Flush()
+}
+
+func (w xlsxProposalWriter) Close() error {
+	return w.sheet.Close()
+}
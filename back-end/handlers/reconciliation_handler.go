@@ -0,0 +1,48 @@
+// Package handlers Обработчик сверки голосов с блокчейном
+package handlers
+
+import (
+	"dao_vote/back-end/services"
+	"dao_vote/back-end/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+)
+
+// GetReconciliationHandler обрабатывает GET /admin/votes/:id/reconciliation запрос для получения отчета сверки
+func GetReconciliationHandler(c *gin.Context) {
+	reconcileVotes(c, false)
+}
+
+// RepairReconciliationHandler обрабатывает POST /admin/votes/:id/reconciliation/repair запрос
+// для восстановления локальных голосов по данным блокчейна
+func RepairReconciliationHandler(c *gin.Context) {
+	reconcileVotes(c, true)
+}
+
+// reconcileVotes выполняет сверку голосования; разрешение votes.reconcile проверяется на уровне маршрута
+func reconcileVotes(c *gin.Context, repair bool) {
+	voteID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid VoteID"})
+		return
+	}
+
+	utils.HandleRequest(c, func(c *gin.Context) error {
+		report, err := services.ReconcileVotes(voteID, repair)
+		if err != nil {
+			logrus.Errorf("Failed to reconcile vote %d: %v", voteID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconcile votes"})
+			return nil
+		}
+
+		if repair {
+			audit(c, "votes.reconcile_repair", "vote", strconv.Itoa(voteID), nil, gin.H{"repaired_records": report.RepairedRecords})
+		}
+		logrus.Infof("Reconciliation of vote %d: matched %d, missing on chain %d, missing locally %d, mismatches %d",
+			voteID, report.Matched, len(report.MissingOnChain), len(report.MissingLocal), len(report.ChoiceMismatches))
+		c.JSON(http.StatusOK, report)
+		return nil
+	})
+}
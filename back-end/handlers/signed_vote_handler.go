@@ -0,0 +1,118 @@
+// Package handlers Обработчик подписанных голосов без транзакций
+package handlers
+
+import (
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/services"
+	"dao_vote/back-end/utils"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GetSignedVoteMessageHandler обрабатывает GET /votes/:id/signed-vote/message запрос
+// для получения канонического сообщения, которое нужно подписать ключом кошелька
+func GetSignedVoteMessageHandler(c *gin.Context) {
+	voteID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid VoteID"})
+		return
+	}
+
+	choice := c.Query("choice")
+	if services.ClassifyChoice(choice) == "" {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid choice"})
+		return
+	}
+
+	timestamp := time.Now().Unix()
+	if value := c.Query("timestamp"); value != "" {
+		if timestamp, err = strconv.ParseInt(value, 10, 64); err != nil {
+			utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid timestamp"})
+			return
+		}
+	}
+
+	utils.JSONResponse(c, http.StatusOK, gin.H{
+		"message":   services.SignedVoteMessage(voteID, choice, timestamp),
+		"timestamp": timestamp,
+	})
+}
+
+// SubmitSignedVoteHandler обрабатывает POST /votes/:id/signed-vote запрос для подачи голоса,
+// подписанного ключом кошелька участника, без отправки транзакции
+func SubmitSignedVoteHandler(c *gin.Context) {
+	voteID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid VoteID"})
+		logrus.Errorf("Invalid VoteID: %v", err)
+		return
+	}
+
+	vote, err := services.GetVote(voteID)
+	if err != nil {
+		utils.JSONResponse(c, http.StatusNotFound, gin.H{"error": err.Error()})
+		logrus.Errorf("VoteInfo not found: %v", err)
+		return
+	}
+
+	var req models.SignedVoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		logrus.Errorf("Invalid request body: %v", err)
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		logrus.Errorf("Validation error: %v", err)
+		return
+	}
+
+	// Подпись проверяется до допуска, чтобы причина отказа раскрывалась только владельцу кошелька
+	signedVote, err := services.VerifySignedVote(vote, req)
+	if err == nil {
+		reason, eligible, eligibilityErr := CheckWalletEligibility(signedVote.Voter, PermVotesVote, time.Now())
+		if eligibilityErr != nil {
+			utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to check eligibility"})
+			logrus.Errorf("Failed to check eligibility of %s: %v", signedVote.Voter, eligibilityErr)
+			return
+		}
+		if !eligible {
+			logrus.Warnf("Wallet %s is not eligible for %s: %s", signedVote.Voter, PermVotesVote, reason)
+			utils.JSONResponse(c, http.StatusForbidden, gin.H{"error": "Not eligible", "action": PermVotesVote, "reason": reason})
+			return
+		}
+		signedVote, err = services.SaveSignedVote(vote, signedVote)
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrAlreadyVoted):
+		utils.JSONResponse(c, http.StatusConflict, gin.H{"error": "User has already voted"})
+		return
+	case errors.Is(err, services.ErrSignerMismatch), errors.Is(err, utils.ErrInvalidSignature):
+		utils.JSONResponse(c, http.StatusUnauthorized, gin.H{"error": err.Error()})
+		logrus.Warnf("Rejected signed vote for vote %d from %s: %v", voteID, req.Voter, err)
+		return
+	case errors.Is(err, services.ErrNotDAOMember):
+		utils.JSONResponse(c, http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrStaleSignature):
+		utils.JSONResponse(c, http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrSignedVotesNotAccepted), errors.Is(err, services.ErrInvalidChoice),
+		errors.Is(err, services.ErrSignatureExpired), errors.Is(err, utils.ErrInvalidPublicKey),
+		errors.Is(err, utils.ErrPublicKeyRequired):
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	default:
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to submit signed vote"})
+		logrus.Errorf("Failed to submit signed vote: %v", err)
+		return
+	}
+
+	utils.JSONResponse(c, http.StatusCreated, signedVote)
+	logrus.Infof("Signed vote accepted: %+v", signedVote)
+}
@@ -0,0 +1,38 @@
+// Package handlers Доверенные прокси для определения IP адреса клиента
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net"
+	"os"
+	"strings"
+)
+
+// TrustedProxies адреса и подсети прокси, заголовкам X-Forwarded-For и X-Real-IP которых можно доверять.
+// Пустой список - заголовки не учитываются, IP клиента определяется по адресу соединения.
+var TrustedProxies []string
+
+// LoadTrustedProxiesConfig загружает доверенные прокси из TRUSTED_PROXIES (IP адреса и подсети CIDR через запятую)
+func LoadTrustedProxiesConfig() error {
+	TrustedProxies = nil
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return fmt.Errorf("invalid TRUSTED_PROXIES entry %q", proxy)
+			}
+		}
+		TrustedProxies = append(TrustedProxies, proxy)
+	}
+	return nil
+}
+
+// ConfigureTrustedProxies задает роутеру доверенные прокси из TrustedProxies.
+// Без этого gin доверяет X-Forwarded-For от любого клиента, и IP адрес можно подделать.
+func ConfigureTrustedProxies(r *gin.Engine) error {
+	return r.SetTrustedProxies(TrustedProxies)
+}
@@ -4,25 +4,34 @@ package handlers
 
 import (
 	"bitbucket.org/decimalteam/dsc-go-sdk/wallet"
-	"bytes"
 	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
 	"dao_vote/back-end/services"
 	"dao_vote/back-end/utils"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	"github.com/sirupsen/logrus"
-	"io/ioutil"
 	"net/http"
 	"strconv"
-	"time"
 )
 
 var validate *validator.Validate
 
 func init() {
 	validate = validator.New()
+
+	// Теги адресов доступны как в validate.Struct, так и в тегах binding
+	if err := utils.RegisterAddressValidators(validate); err != nil {
+		panic(err)
+	}
+	if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		if err := utils.RegisterAddressValidators(engine); err != nil {
+			panic(err)
+		}
+	}
 }
 
 // GetVotingResultsByWallet обрабатывает GET запрос результатов голосования по кошельку
@@ -34,6 +43,11 @@ func GetVotingResultsByWallet(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "wallet_address is required"})
 			return nil
 		}
+		walletAddress, err := utils.NormalizeAddress(walletAddress)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wallet_address"})
+			return nil
+		}
 
 		// Получаем параметр offset из запроса
 		offsetStr := c.Query("offset")
@@ -62,42 +76,18 @@ func CreateVoteHandler(c *gin.Context) {
 	utils.HandleRequest(c, func(c *gin.Context) error {
 		logrus.Info("CreateVoteHandler started")
 
-		// Получение токена из заголовка
-		token := c.GetHeader("Authorization")
-		if token == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization token is required"})
-			logrus.Error("Authorization token is missing")
+		// Пользователь уже проверен AuthMiddleware
+		user, exists := c.Get("user")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authorized"})
+			logrus.Warn("User not found in context")
 			return nil
 		}
-
-		// Запрос информации о текущем пользователе
-		client := &http.Client{}
-		req, err := http.NewRequest("GET", "https://backend.ddapps.io/api/v1/auth/me?with_user_information=1", nil)
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Authorization", token)
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Accept", "application/json")
-
-		resp, err := client.Do(req)
-		if err != nil {
-			return err
-		}
-		defer resp.Body.Close()
-
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return err
-		}
-
-		var userMeResp UserMeResponse
-		if err := json.Unmarshal(body, &userMeResp); err != nil {
-			return err
-		}
-		voter := userMeResp.Data.Wallet
+		voter := user.(User).Wallet
 		logrus.Infof("Retrieved voter wallet: %s", voter)
 
+		var err error
+
 		// Получение данных из формы
 		var vote models.NewVote
 		vote.Title = c.PostForm("title")
@@ -105,6 +95,16 @@ func CreateVoteHandler(c *gin.Context) {
 		vote.Description = c.PostForm("description")
 		vote.Voter = voter
 		vote.Choice = c.PostForm("choice")
+		vote.VoteChangePolicy = c.DefaultPostForm("vote_change_policy", models.VoteChangeReject)
+		vote.VoteMode = c.DefaultPostForm("vote_mode", models.VoteModeOnChain)
+		vote.BallotType = c.DefaultPostForm("ballot_type", models.BallotOpen)
+		vote.VoteCoin = services.NormalizeCoin(c.PostForm("vote_coin"))
+		if value := c.PostForm("min_vote_amount"); value != "" {
+			if vote.MinVoteAmount, err = strconv.ParseFloat(value, 64); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid min_vote_amount"})
+				return nil
+			}
+		}
 		logrus.Infof("Form data received: %+v", vote)
 
 		// Валидация данных голосования
@@ -115,6 +115,23 @@ func CreateVoteHandler(c *gin.Context) {
 		}
 		logrus.Info("VoteInfo data validated")
 
+		// Минимальный депозит без монеты относится к монете по умолчанию
+		if vote.MinVoteAmount > 0 && vote.VoteCoin == "" {
+			vote.VoteCoin = services.DefaultCoin()
+		}
+		if vote.VoteCoin != "" && !services.IsAllowedCoin(vote.VoteCoin) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Coin %s is not allowed", vote.VoteCoin)})
+			return nil
+		}
+
+		// Окна приема обязательств и раскрытия для тайного голосования
+		ballot := models.VoteInfo{BallotType: vote.BallotType}
+		if err := parseBallotWindows(c, &ballot); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			logrus.Errorf("Validation error: %v", err)
+			return nil
+		}
+
 		// Генерация мнемонической фразы для кошелька
 		mnemonicObject, err := wallet.NewMnemonic("")
 		if err != nil {
@@ -139,12 +156,19 @@ func CreateVoteHandler(c *gin.Context) {
 		logrus.Infof("Wallet Address: %s", account.Address())
 
 		voteWithID := models.VoteInfo{
-			Title:         vote.Title,
-			Subtitle:      vote.Subtitle,
-			Description:   vote.Description,
-			Voter:         vote.Voter,
-			Choice:        vote.Choice,
-			WalletAddress: account.Address(),
+			Title:            vote.Title,
+			Subtitle:         vote.Subtitle,
+			Description:      vote.Description,
+			Voter:            vote.Voter,
+			Choice:           vote.Choice,
+			WalletAddress:    account.Address(),
+			VoteChangePolicy: vote.VoteChangePolicy,
+			VoteMode:         vote.VoteMode,
+			BallotType:       vote.BallotType,
+			CommitEndsAt:     ballot.CommitEndsAt,
+			RevealEndsAt:     ballot.RevealEndsAt,
+			VoteCoin:         vote.VoteCoin,
+			MinVoteAmount:    vote.MinVoteAmount,
 		}
 
 		// Получение силы голоса для голосующего
@@ -202,11 +226,13 @@ func DeleteVoteHandler(c *gin.Context) {
 		return
 	}
 
+	before, _ := services.GetVote(id)
 	if err := services.DeleteVote(id); err != nil {
 		utils.JSONResponse(c, http.StatusNotFound, gin.H{"error": err.Error()})
 		logrus.Errorf("Failed to delete vote: %v", err)
 		return
 	}
+	audit(c, "vote.delete", "vote", strconv.Itoa(id), before, nil)
 
 	utils.JSONResponse(c, http.StatusNoContent, gin.H{})
 	logrus.Infof("VoteInfo deleted successfully: %d", id)
@@ -234,246 +260,153 @@ func AddUserVoteHandler(c *gin.Context) {
 	}
 	logrus.Infof("VoteInfo retrieved successfully: %+v", vote)
 
+	if vote.VoteMode == models.VoteModeOffChain {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Proposal accepts only signed votes"})
+		return
+	}
+	if vote.BallotType == models.BallotCommitReveal {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Proposal uses commit-reveal ballots"})
+		return
+	}
+
 	// Сохранение кошелька голосования в памяти
 	walletAddress := vote.WalletAddress
 	logrus.Infof("Wallet address for the vote: %s", walletAddress)
 
-	// Получение силы голоса создателя голосования
-	votePower, err := services.GetVoteStrength(vote.Voter)
+	// Голосующий - авторизованный пользователь, а не создатель голосования
+	user, exists := c.Get("user")
+	if !exists {
+		utils.JSONResponse(c, http.StatusUnauthorized, gin.H{"error": "User not authorized"})
+		logrus.Warn("User not found in context")
+		return
+	}
+	// Транзакцию голоса участника из сессии кошелька нельзя отправить от его имени
+	if user.(User).WalletSessionID != 0 {
+		utils.JSONResponse(c, http.StatusForbidden, gin.H{"error": ErrWalletSessionOnChainVote.Error()})
+		return
+	}
+	if user.(User).Wallet == "" {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "User has no wallet"})
+		logrus.Errorf("User %d has no wallet", user.(User).ID)
+		return
+	}
+	// Кошелек приводится к форме d0…, в которой хранятся сила голоса и голоса пользователей
+	voter, err := utils.NormalizeAddress(user.(User).Wallet)
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid user wallet"})
+		logrus.Errorf("User %d has invalid wallet %q: %v", user.(User).ID, user.(User).Wallet, err)
+		return
+	}
+
+	// Получение силы голоса голосующего
+	votePower, err := services.GetVoteStrength(voter)
 	if err != nil {
 		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Error determining vote strength"})
 		logrus.Errorf("Error determining vote strength: %v", err)
 		return
 	}
-	logrus.Infof("VoteInfo power for voter %s: %d", vote.Voter, votePower)
+	logrus.Infof("VoteInfo power for voter %s: %d", voter, votePower)
 
 	// Получение данных голоса пользователя
-	var userVote models.UserVote
-	if err := c.BindJSON(&userVote); err != nil {
+	var voteReq models.UserVoteRequest
+	if err := c.BindJSON(&voteReq); err != nil {
 		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		logrus.Errorf("Invalid request body: %v", err)
 		return
 	}
-
-	userVote.VoteID = voteID
-	userVote.VotePower = votePower
-	userVote.Voter = vote.Voter // Заполняем поле Voter в структуре UserVote
-
-	// Сохранение голоса пользователя в базе данных
-	id, err := services.AddUserVote(userVote)
-	if err != nil {
-		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to add vote"})
-		logrus.Errorf("Failed to add vote: %v", err)
+	if err := validate.Struct(voteReq); err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		logrus.Errorf("Validation error: %v", err)
+		return
+	}
+	if services.ClassifyChoice(voteReq.Choice) == "" {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": services.ErrInvalidChoice.Error()})
+		return
+	}
+	if err := services.ValidateCallbackURL(voteReq.CallbackURL); err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	userVote.VoterID = id
-	logrus.Infof("User vote added successfully: %+v", userVote)
-
-	// Инициация вывода средств
-	logrus.Info("Initiating withdrawal")
-
-	amount := 1 // Установка количества средств
-	logrus.Infof("Amount for withdrawal: %d", amount)
-
-	token := c.GetHeader("Authorization")
+	token := upstreamToken(c)
 	if token == "" {
 		logrus.Error("Authorization token is missing")
 		utils.JSONResponse(c, http.StatusUnauthorized, gin.H{"error": "Authorization token is required"})
 		return
 	}
-	logrus.Infof("Authorization token: %s", token)
 
-	withdrawReq := models.WithdrawRequest{
-		Amount:  float64(amount),
-		Address: walletAddress,
+	userVote := models.UserVote{
+		VoteID:    voteID,
+		Voter:     voter, // Голос всегда принадлежит кошельку вызывающего пользователя
+		Choice:    voteReq.Choice,
+		VotePower: votePower,
 	}
-	logrus.Infof("Withdraw request data: %+v", withdrawReq)
 
-	// Вызов функции вывода средств
-	response, err := initiateWithdrawal(withdrawReq, token)
-	if err != nil {
-		logrus.Errorf("Failed to initiate withdrawal: %v", err)
-		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to initiate withdrawal"})
+	// Сохранение голоса пользователя в базе данных с учетом политики повторного голосования
+	id, replaced, err := services.CastUserVote(vote, userVote)
+	if errors.Is(err, services.ErrAlreadyVoted) {
+		utils.JSONResponse(c, http.StatusConflict, gin.H{"error": "User has already voted"})
+		logrus.Warnf("Wallet %s has already voted in vote %d", voter, voteID)
 		return
 	}
-	logrus.Infof("Withdraw response: %+v", response)
-
-	transactionID := response.Data.TransactionID
-	if transactionID == 0 {
-		logrus.Error("Invalid transaction ID in response")
-		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Invalid transaction ID in response"})
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to add vote"})
+		logrus.Errorf("Failed to add vote: %v", err)
 		return
 	}
 
-	// Запрос хэша транзакции
-	logrus.Infof("Waiting before requesting transaction hash")
-	time.Sleep(5 * time.Second) // Задержка 5 секунд
-
-	hashResponse, err := getTransactionHash(transactionID, token)
-	if err != nil || hashResponse.Data.Hash == "" {
-		logrus.Errorf("Failed to retrieve transaction hash: %v", err)
-		logrus.Infof("Retrying to retrieve transaction hash after 5 seconds delay")
-		time.Sleep(5 * time.Second) // Повторная задержка 5 секунд
-
-		hashResponse, err = getTransactionHash(transactionID, token)
-		if err != nil {
-			logrus.Errorf("Failed to retrieve transaction hash on second attempt: %v", err)
-			utils.JSONResponse(c, http.StatusOK, gin.H{"message": "Withdrawal successful, but failed to retrieve transaction hash"})
-			return
-		}
+	userVote.VoterID = id
+	if replaced {
+		logrus.Infof("User vote replaced successfully: %+v", userVote)
+	} else {
+		logrus.Infof("User vote added successfully: %+v", userVote)
 	}
-	logrus.Infof("Transaction hash response: %+v", hashResponse)
 
-	transactionHash := hashResponse.Data.Hash
-	logrus.Infof("Transaction hash: %s", transactionHash)
+	// Депозит голоса в монете голосования и не меньше минимальной суммы
+	withdrawReq := services.VoteDeposit(vote, walletAddress)
+	logrus.Infof("Withdraw request data: %+v", withdrawReq)
 
-	if transactionHash == "" {
-		logrus.Error("Invalid transaction hash in response")
-		utils.JSONResponse(c, http.StatusOK, gin.H{"message": "Withdrawal successful, but failed to retrieve transaction hash"})
+	// Отправка транзакции выполняется в фоне, клиент отслеживает её через /jobs/:id
+	job, err := services.SubmitVoteJob(user.(User).ID, userVote, withdrawReq, token, voteReq.CallbackURL)
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to submit vote transaction"})
+		logrus.Errorf("Failed to submit vote job: %v", err)
 		return
 	}
 
 	// Ответ клиенту
-	utils.JSONResponse(c, http.StatusOK, gin.H{
-		"message":          "Withdrawal successful",
-		"transaction_id":   transactionID,
-		"transaction_hash": transactionHash,
+	c.Header("Location", "/jobs/"+job.ID)
+	utils.JSONResponse(c, http.StatusAccepted, gin.H{
+		"message": "Vote accepted",
+		"job_id":  job.ID,
+		"status":  job.Status,
+		"vote":    userVote,
 	})
 	logrus.Info("AddUserVoteHandler completed successfully")
 }
 
-// initiateWithdrawal вызывает существующую функцию вывода средств
-func initiateWithdrawal(req models.WithdrawRequest, token string) (WithdrawResponse, error) {
-	client := &http.Client{}
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		logrus.Errorf("Failed to marshal JSON: %v", err)
-		return WithdrawResponse{}, err
-	}
-
-	logrus.Infof("Initiating HTTP request for withdrawal")
-	httpReq, err := http.NewRequest("POST", "https://backend.ddapps.io/api/v1/withdraw", bytes.NewBuffer(jsonData))
-	if err != nil {
-		logrus.Errorf("Failed to create new HTTP request: %v", err)
-		return WithdrawResponse{}, err
-	}
-
-	httpReq.Header.Set("Authorization", token)
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "application/json")
-
-	// Логгирование содержимого HTTP-запроса
-	logrus.Infof("HTTP Request - URL: %s", httpReq.URL)
-	logrus.Infof("HTTP Request - Method: %s", httpReq.Method)
-	logrus.Infof("HTTP Request - Headers: %v", httpReq.Header)
-	logrus.Infof("HTTP Request - Body: %s", jsonData)
-
-	logrus.Infof("Sending request to external API for withdrawal")
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		logrus.Errorf("Failed to send request to external API: %v", err)
-		return WithdrawResponse{}, err
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		logrus.Errorf("Failed to read response body: %v", err)
-		return WithdrawResponse{}, err
-	}
-
-	logrus.Infof("Response from external API: %s", string(body))
-	if resp.StatusCode != http.StatusOK {
-		return WithdrawResponse{}, fmt.Errorf("error from external API: %s", string(body))
-	}
-
-	var response WithdrawResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		logrus.Errorf("Failed to unmarshal response JSON: %v", err)
-		return WithdrawResponse{}, err
-	}
-
-	return response, nil
-}
-
-// getTransactionHash выполняет запрос для получения хэша транзакции
-func getTransactionHash(transactionID int, token string) (TransactionHashResponse, error) {
-	client := &http.Client{}
-	hashReqURL := fmt.Sprintf("https://backend.ddapps.io/api/v1/transactions/%d", transactionID)
-	hashReq, err := http.NewRequest("GET", hashReqURL, nil)
-	if err != nil {
-		logrus.Errorf("Failed to create new request for transaction hash: %v", err)
-		return TransactionHashResponse{}, err
-	}
-	hashReq.Header.Set("Authorization", token)
-	hashReq.Header.Set("Accept", "application/json")
-
-	logrus.Infof("Sending request to external API for transaction hash: %v", hashReqURL)
-	logrus.Infof("Request URL: %v", hashReqURL)
-	logrus.Infof("Request method: %v", hashReq.Method)
-	logrus.Infof("Request headers: %v", hashReq.Header)
-	logrus.Infof("Request ID: %d", transactionID)
-
-	time.Sleep(5 * time.Second) // Задержка 5 секунд
-	hashResp, err := client.Do(hashReq)
+// GetUserVotesHandler обрабатывает GET /votes/:id/votes запрос для получения всех голосов пользователей для голосования
+func GetUserVotesHandler(c *gin.Context) {
+	voteID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		logrus.Errorf("Failed to send request to external API for transaction hash: %v", err)
-		return TransactionHashResponse{}, err
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid VoteID"})
+		logrus.Errorf("Invalid VoteID: %v", err)
+		return
 	}
-	defer hashResp.Body.Close()
 
-	hashBody, err := ioutil.ReadAll(hashResp.Body)
+	voteResults, err := services.FetchVotes(voteID)
 	if err != nil {
-		logrus.Errorf("Failed to read response body for transaction hash: %v", err)
-		return TransactionHashResponse{}, err
-	}
-
-	logrus.Infof("Response from external API for transaction hash: %s", string(hashBody))
-	if hashResp.StatusCode != http.StatusOK {
-		return TransactionHashResponse{}, fmt.Errorf("error from external API for transaction hash: %s", string(hashBody))
-	}
-
-	var hashResponse TransactionHashResponse
-	if err := json.Unmarshal(hashBody, &hashResponse); err != nil {
-		logrus.Errorf("Failed to unmarshal response JSON for transaction hash: %v", err)
-		return TransactionHashResponse{}, err
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to get vote results"})
+		logrus.Errorf("Failed to get vote results: %v", err)
+		return
 	}
 
-	return hashResponse, nil
-}
-
-// Структуры для обработки ответов от внешнего API
-type WithdrawResponse struct {
-	Type    string `json:"type"`
-	Message string `json:"message"`
-	Data    struct {
-		TransactionID int `json:"transaction_id"`
-	} `json:"data"`
-}
-
-type TransactionHashResponse struct {
-	Data struct {
-		ID             int    `json:"id"`
-		UserID         int    `json:"user_id"`
-		WalletID       int    `json:"wallet_id"`
-		Amount         string `json:"amount"`
-		Payload        string `json:"payload"`
-		DecimalPayload string `json:"decimal_payload"`
-		Coin           string `json:"coin"`
-		Hash           string `json:"hash"`
-		Result         string `json:"result"`
-		Complete       bool   `json:"complete"`
-		Success        bool   `json:"success"`
-		CreatedAt      string `json:"created_at"`
-		UpdatedAt      string `json:"updated_at"`
-		Type           int    `json:"type"`
-		Calculated     int    `json:"calculated"`
-	} `json:"data"`
+	utils.JSONResponse(c, http.StatusOK, voteResults)
+	logrus.Infof("VoteInfo results retrieved successfully: %+v", voteResults)
 }
 
-// GetUserVotesHandler обрабатывает GET /votes/:id/votes запрос для получения всех голосов пользователей для голосования
-func GetUserVotesHandler(c *gin.Context) {
+// GetMyVoteHandler обрабатывает GET /votes/:id/my-vote запрос для получения квитанции голоса текущего пользователя
+func GetMyVoteHandler(c *gin.Context) {
 	voteID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid VoteID"})
@@ -481,13 +414,22 @@ func GetUserVotesHandler(c *gin.Context) {
 		return
 	}
 
-	voteResults, err := services.FetchVotes(voteID)
+	user, exists := c.Get("user")
+	if !exists {
+		utils.JSONResponse(c, http.StatusUnauthorized, gin.H{"error": "User not authorized"})
+		return
+	}
+
+	userVote, err := services.GetUserVote(voteID, user.(User).Wallet)
+	if errors.Is(err, repository.ErrUserVoteNotFound) {
+		utils.JSONResponse(c, http.StatusNotFound, gin.H{"error": "Vote receipt not found"})
+		return
+	}
 	if err != nil {
-		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to get vote results"})
-		logrus.Errorf("Failed to get vote results: %v", err)
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to get vote receipt"})
+		logrus.Errorf("Failed to get vote receipt: %v", err)
 		return
 	}
 
-	utils.JSONResponse(c, http.StatusOK, voteResults)
-	logrus.Infof("VoteInfo results retrieved successfully: %+v", voteResults)
+	utils.JSONResponse(c, http.StatusOK, userVote)
 }
@@ -0,0 +1,98 @@
+// Package handlers Обработчик синхронизации силы голоса с балансами PRO
+package handlers
+
+import (
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"dao_vote/back-end/utils"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+)
+
+// Ограничения размера страницы журнала запусков синхронизации силы голоса
+const (
+	defaultSyncRunsLimit = 50
+	maxSyncRunsLimit     = 500
+)
+
+// SyncVotePowerHandler обрабатывает POST /admin/vote-power/sync запрос. Без apply=true изменения реестра
+// только рассчитываются; с apply=true применяются в одной транзакции. Отчет запуска сохраняется.
+func SyncVotePowerHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	apply, _ := strconv.ParseBool(c.DefaultQuery("apply", "false"))
+
+	run, err := services.SyncVotePower(apply, changeMeta(user, c.DefaultQuery("reason", "vote power sync")))
+	switch {
+	case errors.Is(err, services.ErrExplorerRequest):
+		utils.JSONResponse(c, http.StatusBadGateway, gin.H{"error": "Explorer request failed", "run_id": run.ID})
+		logrus.Errorf("Vote power sync %d failed: %v", run.ID, err)
+		return
+	case errors.Is(err, repository.ErrMemberRegistryChanged):
+		utils.JSONResponse(c, http.StatusConflict, gin.H{"error": "Member registry changed during sync, retry", "run_id": run.ID})
+		return
+	case err != nil:
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to sync vote power"})
+		logrus.Errorf("Vote power sync failed: %v", err)
+		return
+	}
+
+	utils.JSONResponse(c, http.StatusOK, run)
+	if apply {
+		audit(c, "vote_power.sync", "vote_power_sync_run", strconv.Itoa(run.ID), nil, run)
+		logrus.Infof("Vote power sync %d by user %d: %d added, %d removed, %d changed, %d failed",
+			run.ID, user.ID, run.Added, run.Removed, run.Changed, run.Failed)
+	}
+}
+
+// ListVotePowerSyncRunsHandler обрабатывает GET /admin/vote-power/sync-runs запрос для получения сводок запусков
+func ListVotePowerSyncRunsHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultSyncRunsLimit)))
+	if err != nil || limit <= 0 {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	if limit > maxSyncRunsLimit {
+		limit = maxSyncRunsLimit
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "invalid offset"})
+		return
+	}
+
+	runs, err := services.ListVotePowerSyncRuns(limit, offset)
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to list vote power sync runs"})
+		logrus.Errorf("Failed to list vote power sync runs: %v", err)
+		return
+	}
+	utils.JSONResponse(c, http.StatusOK, gin.H{"runs": runs, "limit": limit, "offset": offset})
+}
+
+// GetVotePowerSyncRunHandler обрабатывает GET /admin/vote-power/sync-runs/:id запрос для получения отчета запуска
+// с изменениями реестра и ошибками по кошелькам
+func GetVotePowerSyncRunHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid sync run ID"})
+		return
+	}
+
+	run, err := services.GetVotePowerSyncRun(id)
+	if errors.Is(err, repository.ErrVotePowerSyncRunNotFound) {
+		utils.JSONResponse(c, http.StatusNotFound, gin.H{"error": "Sync run not found"})
+		return
+	}
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to get vote power sync run"})
+		logrus.Errorf("Failed to get vote power sync run %d: %v", id, err)
+		return
+	}
+	utils.JSONResponse(c, http.StatusOK, run)
+}
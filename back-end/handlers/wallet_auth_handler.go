@@ -0,0 +1,148 @@
+// Package handlers Обработчик входа участников DAO подписью кошелька Decimal
+package handlers
+
+import (
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/services"
+	"dao_vote/back-end/utils"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// walletRole название роли, через которую пользователю сессии кошелька передаются разрешения участника DAO
+const walletRole = "wallet"
+
+// walletUserIDOffset смещение ID пользователей сессий кошелька. ID такого пользователя отрицательный,
+// как у API ключей (-id ключа), но лежит в отдельном диапазоне: -(walletUserIDOffset + ID первой сессии кошелька).
+const walletUserIDOffset = 1000000000
+
+// ErrWalletSessionOnChainVote возвращается при попытке отправить голос транзакцией из сессии кошелька
+var ErrWalletSessionOnChainVote = errors.New("wallet sessions cannot send vote transactions, submit a signed vote to POST /votes/:id/signed-vote")
+
+// WalletMemberPermissions разрешения участника DAO, вошедшего подписью кошелька, пока кошелек есть в vote_strength
+var WalletMemberPermissions = []string{PermVotesVote}
+
+// WalletChallengeHandler обрабатывает POST /auth/wallet/challenge запрос для получения nonce и сообщения для подписи
+func WalletChallengeHandler(c *gin.Context) {
+	var req models.WalletChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	challenge, err := services.CreateWalletChallenge(req.Wallet)
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to create sign-in challenge"})
+		logrus.Errorf("Failed to create sign-in challenge for %s: %v", req.Wallet, err)
+		return
+	}
+	utils.JSONResponse(c, http.StatusOK, challenge)
+}
+
+// WalletSignInHandler обрабатывает POST /auth/wallet/login запрос: проверяет подпись сообщения
+// и выдает токен сессии кошелька, который принимает AuthMiddleware
+func WalletSignInHandler(c *gin.Context) {
+	var req models.WalletSignInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := services.SignInWithWallet(req)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrInvalidWalletNonce), errors.Is(err, services.ErrSignerMismatch),
+		errors.Is(err, utils.ErrInvalidSignature):
+		utils.JSONResponse(c, http.StatusUnauthorized, gin.H{"error": err.Error()})
+		logrus.Warnf("Wallet sign-in rejected for %s: %v", req.Wallet, err)
+		return
+	case errors.Is(err, services.ErrNotDAOMember):
+		utils.JSONResponse(c, http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	default:
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		logrus.Errorf("Wallet sign-in failed for %s: %v", req.Wallet, err)
+		return
+	}
+
+	utils.JSONResponse(c, http.StatusOK, resp)
+	logrus.Infof("Кошелек %s вошел подписью, сила голоса %d", resp.Wallet, resp.VotePower)
+}
+
+// WalletLogoutHandler обрабатывает POST /auth/wallet/logout запрос для завершения текущей сессии кошелька
+func WalletLogoutHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if user.WalletSessionID == 0 {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Not a wallet session"})
+		return
+	}
+
+	if err := services.RevokeWalletSession(user.WalletSessionID); err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to end wallet session"})
+		logrus.Errorf("Failed to revoke wallet session %d: %v", user.WalletSessionID, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// resolveWalletSession проверяет токен сессии кошелька и возвращает пользователя участника DAO
+func resolveWalletSession(token string) (User, error) {
+	session, err := services.AuthenticateWalletSession(token)
+	if err != nil {
+		return User{}, err
+	}
+	return walletUser(session), nil
+}
+
+// walletUser возвращает пользователя для сессии кошелька. Разрешения участника DAO выдаются,
+// только пока кошелек есть в vote_strength, поэтому удаление кошелька сразу лишает права голоса.
+func walletUser(session models.WalletSession) User {
+	role := Role{Name: walletRole, Permissions: []Permission{}}
+	if _, err := services.GetVoteStrength(session.Wallet); err == nil {
+		for _, permission := range WalletMemberPermissions {
+			role.Permissions = append(role.Permissions, Permission{Name: permission})
+		}
+	}
+	return User{
+		ID:              -(walletUserIDOffset + session.MemberID),
+		Login:           "wallet:" + session.Wallet,
+		Wallet:          session.Wallet,
+		Roles:           []Role{role},
+		WalletSessionID: session.ID,
+	}
+}
+
+// authenticateWalletSession проверяет токен сессии кошелька и сохраняет в контексте пользователя участника DAO
+func authenticateWalletSession(c *gin.Context, token string) {
+	user, err := resolveWalletSession(token)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrInvalidWalletSession), errors.Is(err, services.ErrWalletSessionExpired),
+		errors.Is(err, services.ErrWalletSessionRevoked):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Abort()
+		return
+	default:
+		logrus.Errorf("Failed to verify wallet session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify wallet session"})
+		c.Abort()
+		return
+	}
+
+	c.Set("user", user)
+	logrus.Debugf("Участник авторизован сессией кошелька %d (%s)", user.WalletSessionID, user.Wallet)
+	c.Next()
+}
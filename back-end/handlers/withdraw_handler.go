@@ -2,19 +2,27 @@
 package handlers
 
 import (
-	"bytes"
 	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
 	"dao_vote/back-end/utils"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
-	"io/ioutil"
 	"net/http"
+	"strconv"
 	"time"
 )
 
-// WithdrawHandler обрабатывает запрос на снятие средств
+// Ограничения размера страницы журнала выводов средств
+const (
+	defaultWithdrawalsLimit = 50
+	maxWithdrawalsLimit     = 500
+)
+
+// WithdrawHandler обрабатывает запрос на снятие средств.
+// Вывод записывается в журнал, итоговый статус отслеживается фоновым опросом.
 func WithdrawHandler(c *gin.Context) {
 	utils.HandleRequest(c, func(c *gin.Context) error {
 		var withdrawReq models.WithdrawRequest
@@ -23,173 +31,258 @@ func WithdrawHandler(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return nil
 		}
-
-		// Подготовка запроса к внешнему API
-		jsonData, err := json.Marshal(withdrawReq)
-		if err != nil {
-			logrus.Errorf("Failed to marshal JSON: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err := validate.Struct(withdrawReq); err != nil {
+			logrus.Errorf("Validation error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return nil
 		}
+		// EVM адрес получателя приводится к форме d0…
+		withdrawReq.Address, _ = utils.NormalizeAddress(withdrawReq.Address)
 
 		// Получение токена из заголовка
-		token := c.GetHeader("Authorization")
+		token := upstreamToken(c)
 		if token == "" {
 			logrus.Error("Authorization token is missing")
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization token is required"})
 			return nil
 		}
 
-		// Логирование токена и запроса на снятие средств
-		logrus.Infof("Authorization token: %s", token)
-		logrus.Infof("Withdraw request data: %s", jsonData)
-
-		// Отправка запроса на снятие средств к внешнему API
-		client := &http.Client{}
-		req, err := http.NewRequest("POST", "https://backend.ddapps.io/api/v1/withdraw", bytes.NewBuffer(jsonData))
-		if err != nil {
-			logrus.Errorf("Failed to create new request: %v", err)
-			return err
-		}
-
-		req.Header.Set("Authorization", token)
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Accept", "application/json")
-
-		logrus.Infof("Sending request to external API for withdrawal")
-		resp, err := client.Do(req)
-		if err != nil {
-			logrus.Errorf("Failed to send request to external API: %v", err)
-			return err
+		user, exists := c.Get("user")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authorized"})
+			return nil
 		}
-		defer resp.Body.Close()
 
-		// Чтение и обработка ответа от внешнего API
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			logrus.Errorf("Failed to read response body: %v", err)
-			return err
+		logrus.Infof("Withdraw request data: %+v", withdrawReq)
+		withdrawal, err := services.CreateWithdrawal(requesterFromUser(user.(User)), withdrawReq, token)
+		if withdrawal.ID != 0 {
+			// Вывод записан в журнал и мог быть передан во внешний API: повтор с тем же ключом получает этот ответ
+			MarkIdempotentSideEffect(c)
 		}
-
-		// Логирование ответа на запрос снятия средств
-		logrus.Infof("Response from external API: %s", string(body))
-		if resp.StatusCode != http.StatusOK {
-			logrus.Errorf("Error from external API: %s", string(body))
-			c.JSON(resp.StatusCode, gin.H{"error": string(body)})
+		if errors.Is(err, services.ErrCoinNotAllowed) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "allowed_coins": services.AllowedCoins})
 			return nil
 		}
-
-		var withdrawResponse struct {
-			Type    string `json:"type"`
-			Message string `json:"message"`
-			Data    struct {
-				TransactionID int `json:"transaction_id"`
-			} `json:"data"`
+		var violation *services.PolicyViolation
+		if errors.As(err, &violation) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":       violation.Reason,
+				"policy_id":   violation.Policy.ID,
+				"policy_type": violation.Policy.Type,
+			})
+			return nil
 		}
-		if err := json.Unmarshal(body, &withdrawResponse); err != nil {
-			logrus.Errorf("Failed to unmarshal response JSON: %v", err)
-			return err
+		if err != nil {
+			if withdrawal.ID == 0 {
+				return err
+			}
+			logrus.Errorf("Withdrawal %d failed: %v", withdrawal.ID, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "withdrawal": withdrawal})
+			return nil
 		}
 
-		logrus.Infof("Withdraw response JSON: %v", withdrawResponse)
-		transactionID := withdrawResponse.Data.TransactionID
-		if transactionID == 0 {
-			logrus.Error("Invalid transaction ID in response")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid transaction ID in response"})
+		c.Header("Location", fmt.Sprintf("/api/v1/withdraw/%d", withdrawal.ID))
+		if withdrawal.Status == models.WithdrawalStatusPendingApproval {
+			c.JSON(http.StatusAccepted, gin.H{
+				"message":    "Withdrawal awaiting admin approval",
+				"withdrawal": withdrawal,
+			})
 			return nil
 		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":        "Withdrawal submitted",
+			"transaction_id": withdrawal.TransactionID,
+			"withdrawal":     withdrawal,
+		})
+		return nil
+	})
+}
 
-		// Задержка перед запросом хэша транзакции
-		time.Sleep(5 * time.Second) // Задержка 5 секунд
+// requesterFromUser формирует данные инициатора вывода средств из пользователя
+func requesterFromUser(user User) models.Requester {
+	requester := models.Requester{UserID: user.ID, Wallet: user.Wallet}
+	for _, role := range user.Roles {
+		requester.Roles = append(requester.Roles, role.Name)
+	}
+	return requester
+}
 
-		// Запрос хэша транзакции
-		hashReqURL := fmt.Sprintf("https://backend.ddapps.io/api/v1/transactions/%d", transactionID)
-		hashReq, err := http.NewRequest("GET", hashReqURL, nil)
-		if err != nil {
-			logrus.Errorf("Failed to create new request for transaction hash: %v", err)
-			// Возвращаем успешный ответ, несмотря на ошибку запроса хэша
-			c.JSON(http.StatusOK, gin.H{"message": "Withdrawal successful, but failed to retrieve transaction hash"})
-			return nil
-		}
-		hashReq.Header.Set("Authorization", token)
-		hashReq.Header.Set("Accept", "application/json")
+// ApproveWithdrawalHandler обрабатывает POST /api/v1/withdraw/:id/approve запрос администратора на одобрение вывода.
+// При достижении кворума вывод передается во внешний API.
+func ApproveWithdrawalHandler(c *gin.Context) {
+	decideWithdrawal(c, "withdrawal.approve", services.ApproveWithdrawal)
+}
 
-		// Логирование запроса на получение хэша транзакции
-		logrus.Infof("Sending request to external API for transaction hash: %v", hashReqURL)
-		logrus.Infof("Request URL: %v", hashReqURL)
-		logrus.Infof("Request method: %v", hashReq.Method)
-		logrus.Infof("Request headers: %v", hashReq.Header)
-		logrus.Infof("Request ID: %d", transactionID)
+// RejectWithdrawalHandler обрабатывает POST /api/v1/withdraw/:id/reject запрос администратора на отклонение вывода
+func RejectWithdrawalHandler(c *gin.Context) {
+	decideWithdrawal(c, "withdrawal.reject", services.RejectWithdrawal)
+}
 
-		hashResp, err := client.Do(hashReq)
-		if err != nil {
-			logrus.Errorf("Failed to send request to external API for transaction hash: %v", err)
-			// Возвращаем успешный ответ, несмотря на ошибку запроса хэша
-			c.JSON(http.StatusOK, gin.H{"message": "Withdrawal successful, but failed to retrieve transaction hash"})
-			return nil
-		}
-		defer hashResp.Body.Close()
+// decideWithdrawal записывает решение администратора по выводу средств и возвращает обновленный вывод
+func decideWithdrawal(c *gin.Context, action string, decide func(models.Requester, int, string) (models.Withdrawal, error)) {
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
 
-		// Чтение и обработка ответа от внешнего API
-		hashBody, err := ioutil.ReadAll(hashResp.Body)
-		if err != nil {
-			logrus.Errorf("Failed to read response body for transaction hash: %v", err)
-			// Возвращаем успешный ответ, несмотря на ошибку запроса хэша
-			c.JSON(http.StatusOK, gin.H{"message": "Withdrawal successful, but failed to retrieve transaction hash"})
-			return nil
-		}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid withdrawal ID"})
+		return
+	}
 
-		// Логирование ответа на запрос хэша транзакции
-		logrus.Infof("Response from external API for transaction hash: %s", string(hashBody))
-		if hashResp.StatusCode != http.StatusOK {
-			logrus.Errorf("Error from external API for transaction hash: %s", string(hashBody))
-			// Возвращаем успешный ответ, несмотря на ошибку запроса хэша
-			c.JSON(http.StatusOK, gin.H{"message": "Withdrawal successful, but failed to retrieve transaction hash"})
-			return nil
+	var req models.ApprovalRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
+	}
+	if err := validate.Struct(req); err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-		var hashResponse struct {
-			Data struct {
-				ID             int    `json:"id"`
-				UserID         int    `json:"user_id"`
-				WalletID       int    `json:"wallet_id"`
-				Amount         string `json:"amount"`
-				Payload        string `json:"payload"`
-				DecimalPayload string `json:"decimal_payload"`
-				Coin           string `json:"coin"`
-				Hash           string `json:"hash"`
-				Result         string `json:"result"`
-				Complete       bool   `json:"complete"`
-				Success        bool   `json:"success"`
-				CreatedAt      string `json:"created_at"`
-				UpdatedAt      string `json:"updated_at"`
-				Type           int    `json:"type"`
-				Calculated     int    `json:"calculated"`
-			} `json:"data"`
-		}
-		if err := json.Unmarshal(hashBody, &hashResponse); err != nil {
-			logrus.Errorf("Failed to unmarshal response JSON for transaction hash: %v", err)
-			// Возвращаем успешный ответ, несмотря на ошибку запроса хэша
-			c.JSON(http.StatusOK, gin.H{"message": "Withdrawal successful, but failed to retrieve transaction hash"})
-			return nil
-		}
+	before, _ := services.GetWithdrawal(id)
+	withdrawal, err := decide(requesterFromUser(admin), id, req.Comment)
+	if err == nil || withdrawal.Status == models.WithdrawalStatusFailed {
+		audit(c, action, "withdrawal", strconv.Itoa(id), before, withdrawal)
+	}
+	switch {
+	case err == nil:
+		utils.JSONResponse(c, http.StatusOK, withdrawal)
+	case errors.Is(err, repository.ErrWithdrawalNotFound):
+		utils.JSONResponse(c, http.StatusNotFound, gin.H{"error": "Withdrawal not found"})
+	case errors.Is(err, services.ErrSelfApproval):
+		utils.JSONResponse(c, http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrWithdrawalNotPending), errors.Is(err, services.ErrAlreadyDecided):
+		utils.JSONResponse(c, http.StatusConflict, gin.H{"error": err.Error(), "status": withdrawal.Status})
+	case errors.Is(err, services.ErrWithdrawalExpired):
+		utils.JSONResponse(c, http.StatusGone, gin.H{"error": err.Error()})
+	case withdrawal.Status == models.WithdrawalStatusFailed:
+		logrus.Errorf("Approved withdrawal %d failed: %v", id, err)
+		utils.JSONResponse(c, http.StatusBadGateway, gin.H{"error": err.Error(), "withdrawal": withdrawal})
+	default:
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to record decision"})
+		logrus.Errorf("Failed to record decision on withdrawal %d: %v", id, err)
+	}
+}
 
-		// Логирование значений полей структуры hashResponse
-		logrus.Infof("Transaction hash response data: %+v", hashResponse.Data)
+// GetWithdrawalHandler обрабатывает GET /api/v1/withdraw/:id запрос для получения статуса вывода средств
+func GetWithdrawalHandler(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		utils.JSONResponse(c, http.StatusUnauthorized, gin.H{"error": "User not authorized"})
+		return
+	}
 
-		// Явное логирование значения хэша
-		transactionHash := hashResponse.Data.Hash
-		logrus.Infof("Transaction hash: %s", transactionHash)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid withdrawal ID"})
+		return
+	}
 
-		if transactionHash == "" {
-			logrus.Error("Invalid transaction hash in response")
-			// Возвращаем успешный ответ, несмотря на ошибку запроса хэша
-			c.JSON(http.StatusOK, gin.H{"message": "Withdrawal successful, but failed to retrieve transaction hash"})
-			return nil
-		}
+	withdrawal, err := services.GetWithdrawal(id)
+	if errors.Is(err, repository.ErrWithdrawalNotFound) {
+		utils.JSONResponse(c, http.StatusNotFound, gin.H{"error": "Withdrawal not found"})
+		return
+	}
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to get withdrawal"})
+		logrus.Errorf("Failed to get withdrawal: %v", err)
+		return
+	}
 
-		// Успешный ответ с хэшем транзакции
-		c.JSON(http.StatusOK, gin.H{"message": "Withdrawal successful", "transaction_id": transactionID, "transaction_hash": transactionHash})
-		return nil
+	// Вывод доступен только его автору и пользователям с разрешением withdraw.read_all
+	if withdrawal.UserID != user.(User).ID && !HasPermission(user.(User), PermWithdrawReadAll) {
+		utils.JSONResponse(c, http.StatusNotFound, gin.H{"error": "Withdrawal not found"})
+		return
+	}
+
+	utils.JSONResponse(c, http.StatusOK, withdrawal)
+}
+
+// ListWithdrawalsHandler обрабатывает GET /api/v1/withdraw запрос для получения журнала выводов средств.
+// Пользователи с разрешением withdraw.read_all видят все выводы, остальные - только свои.
+func ListWithdrawalsHandler(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		utils.JSONResponse(c, http.StatusUnauthorized, gin.H{"error": "User not authorized"})
+		return
+	}
+
+	filter, err := parseWithdrawalFilter(c)
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !HasPermission(user.(User), PermWithdrawReadAll) {
+		filter.AllUsers, filter.UserID = false, user.(User).ID
+	}
+
+	withdrawals, err := services.ListWithdrawals(filter)
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to list withdrawals"})
+		logrus.Errorf("Failed to list withdrawals: %v", err)
+		return
+	}
+
+	utils.JSONResponse(c, http.StatusOK, gin.H{
+		"withdrawals": withdrawals,
+		"limit":       filter.Limit,
+		"offset":      filter.Offset,
 	})
 }
+
+// parseWithdrawalFilter считывает фильтр журнала выводов средств из параметров запроса
+func parseWithdrawalFilter(c *gin.Context) (models.WithdrawalFilter, error) {
+	filter := models.WithdrawalFilter{
+		AllUsers: true,
+		Status:   c.Query("status"),
+		Address:  c.Query("address"),
+		Limit:    defaultWithdrawalsLimit,
+	}
+
+	var err error
+	if value := c.Query("user_id"); value != "" {
+		if filter.UserID, err = strconv.Atoi(value); err != nil {
+			return filter, errors.New("invalid user_id")
+		}
+		filter.AllUsers = false
+	}
+	if value := c.Query("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit <= 0 {
+			return filter, errors.New("invalid limit")
+		}
+		if filter.Limit > maxWithdrawalsLimit {
+			filter.Limit = maxWithdrawalsLimit
+		}
+	}
+	if value := c.Query("offset"); value != "" {
+		if filter.Offset, err = strconv.Atoi(value); err != nil || filter.Offset < 0 {
+			return filter, errors.New("invalid offset")
+		}
+	}
+	if filter.From, err = parseDateParam(c.Query("from")); err != nil {
+		return filter, errors.New("invalid from date")
+	}
+	if filter.To, err = parseDateParam(c.Query("to")); err != nil {
+		return filter, errors.New("invalid to date")
+	}
+	return filter, nil
+}
+
+// parseDateParam разбирает дату в формате RFC3339 или YYYY-MM-DD
+func parseDateParam(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,166 @@
+// Package handlers Обработчик политик вывода средств
+package handlers
+
+import (
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"dao_vote/back-end/utils"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+)
+
+// ListWithdrawalPoliciesHandler обрабатывает GET /admin/withdrawal-policies запрос
+func ListWithdrawalPoliciesHandler(c *gin.Context) {
+	policies, err := services.ListWithdrawalPolicies()
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to list withdrawal policies"})
+		logrus.Errorf("Failed to list withdrawal policies: %v", err)
+		return
+	}
+	utils.JSONResponse(c, http.StatusOK, policies)
+}
+
+// CreateWithdrawalPolicyHandler обрабатывает POST /admin/withdrawal-policies запрос
+func CreateWithdrawalPolicyHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	req, ok := bindWithdrawalPolicyRequest(c)
+	if !ok {
+		return
+	}
+
+	policy, err := services.CreateWithdrawalPolicy(req)
+	if errors.Is(err, services.ErrInvalidPolicy) {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to create withdrawal policy"})
+		logrus.Errorf("Failed to create withdrawal policy: %v", err)
+		return
+	}
+
+	utils.JSONResponse(c, http.StatusCreated, policy)
+	audit(c, "withdrawal_policy.create", "withdrawal_policy", strconv.Itoa(policy.ID), nil, policy)
+	logrus.Infof("Withdrawal policy %d (%s) created by user %d", policy.ID, policy.Type, user.ID)
+}
+
+// UpdateWithdrawalPolicyHandler обрабатывает PUT /admin/withdrawal-policies/:id запрос
+func UpdateWithdrawalPolicyHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid policy ID"})
+		return
+	}
+
+	req, ok := bindWithdrawalPolicyRequest(c)
+	if !ok {
+		return
+	}
+
+	before, _ := repository.GetWithdrawalPolicyByID(id)
+	policy, err := services.UpdateWithdrawalPolicy(id, req)
+	switch {
+	case errors.Is(err, services.ErrInvalidPolicy):
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, repository.ErrWithdrawalPolicyNotFound):
+		utils.JSONResponse(c, http.StatusNotFound, gin.H{"error": "Withdrawal policy not found"})
+		return
+	case err != nil:
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to update withdrawal policy"})
+		logrus.Errorf("Failed to update withdrawal policy: %v", err)
+		return
+	}
+
+	utils.JSONResponse(c, http.StatusOK, policy)
+	audit(c, "withdrawal_policy.update", "withdrawal_policy", strconv.Itoa(id), before, policy)
+	logrus.Infof("Withdrawal policy %d updated by user %d", policy.ID, user.ID)
+}
+
+// DeleteWithdrawalPolicyHandler обрабатывает DELETE /admin/withdrawal-policies/:id запрос
+func DeleteWithdrawalPolicyHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid policy ID"})
+		return
+	}
+
+	before, _ := repository.GetWithdrawalPolicyByID(id)
+	err = services.DeleteWithdrawalPolicy(id)
+	if errors.Is(err, repository.ErrWithdrawalPolicyNotFound) {
+		utils.JSONResponse(c, http.StatusNotFound, gin.H{"error": "Withdrawal policy not found"})
+		return
+	}
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to delete withdrawal policy"})
+		logrus.Errorf("Failed to delete withdrawal policy: %v", err)
+		return
+	}
+
+	utils.JSONResponse(c, http.StatusOK, gin.H{"message": "Withdrawal policy deleted"})
+	audit(c, "withdrawal_policy.delete", "withdrawal_policy", strconv.Itoa(id), before, nil)
+	logrus.Infof("Withdrawal policy %d deleted by user %d", id, user.ID)
+}
+
+// ListPolicyRejectionsHandler обрабатывает GET /admin/withdrawal-policies/rejections запрос
+// для получения журнала выводов, отклоненных политиками
+func ListPolicyRejectionsHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultWithdrawalsLimit)))
+	if err != nil || limit <= 0 {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	if limit > maxWithdrawalsLimit {
+		limit = maxWithdrawalsLimit
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "invalid offset"})
+		return
+	}
+
+	rejections, err := services.ListPolicyRejections(limit, offset)
+	if err != nil {
+		utils.JSONResponse(c, http.StatusInternalServerError, gin.H{"error": "Failed to list policy rejections"})
+		logrus.Errorf("Failed to list policy rejections: %v", err)
+		return
+	}
+	utils.JSONResponse(c, http.StatusOK, gin.H{"rejections": rejections, "limit": limit, "offset": offset})
+}
+
+// bindWithdrawalPolicyRequest считывает и проверяет тело запроса правила политики
+func bindWithdrawalPolicyRequest(c *gin.Context) (models.WithdrawalPolicyRequest, bool) {
+	var req models.WithdrawalPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		logrus.Errorf("Invalid request body: %v", err)
+		return req, false
+	}
+	if err := validate.Struct(req); err != nil {
+		utils.JSONResponse(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return req, false
+	}
+	if req.Address != "" {
+		// Адреса в правилах хранятся в форме d0…, как и адреса в запросах на вывод
+		req.Address, _ = utils.NormalizeAddress(req.Address)
+	}
+	return req, true
+}
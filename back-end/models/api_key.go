@@ -0,0 +1,35 @@
+// Package models API ключи сервисного доступа
+package models
+
+import "time"
+
+// APIKey представляет API ключ сервиса. Сам ключ не хранится, только его хэш.
+type APIKey struct {
+	ID         int        `json:"id"`                     // Уникальный идентификатор ключа
+	Name       string     `json:"name"`                   // Название ключа
+	Prefix     string     `json:"prefix"`                 // Начало ключа для опознания в списке
+	Scopes     []string   `json:"scopes"`                 // Разрешения ключа
+	AllowedIPs []string   `json:"allowed_ips"`            // Разрешенные IP адреса и подсети (пусто - любые)
+	Wallet     string     `json:"wallet,omitempty"`       // Кошелек сервиса
+	CreatedBy  int        `json:"created_by"`             // Администратор, создавший ключ
+	CreatedAt  time.Time  `json:"created_at"`             // Время создания
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`   // Срок действия
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"` // Время последнего использования
+	LastUsedIP string     `json:"last_used_ip,omitempty"` // IP адрес последнего использования
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`   // Время отзыва
+}
+
+// APIKeyRequest представляет запрос на создание API ключа
+type APIKeyRequest struct {
+	Name       string     `json:"name" validate:"required,max=100"`
+	Scopes     []string   `json:"scopes" validate:"required,min=1"`
+	AllowedIPs []string   `json:"allowed_ips"`
+	Wallet     string     `json:"wallet" validate:"omitempty,decimal_or_evm_address"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// CreatedAPIKey представляет созданный API ключ; значение ключа возвращается только один раз
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
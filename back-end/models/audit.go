@@ -0,0 +1,36 @@
+// Package models Журнал действий администраторов
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEntry представляет запись журнала действий администраторов. Значения до и после изменения
+// хранятся в JSON с удаленными секретами.
+type AuditEntry struct {
+	ID         int             `json:"id"`          // Уникальный идентификатор записи
+	ActorID    int             `json:"actor_id"`    // ID пользователя, выполнившего действие
+	Actor      string          `json:"actor"`       // Логин пользователя
+	Action     string          `json:"action"`      // Действие, например wallet.delete
+	TargetType string          `json:"target_type"` // Тип объекта действия, например wallet
+	TargetID   string          `json:"target_id"`   // Идентификатор объекта действия
+	Before     json.RawMessage `json:"before"`      // Значение до изменения (null - объекта не было)
+	After      json.RawMessage `json:"after"`       // Значение после изменения (null - объект удален)
+	RequestID  string          `json:"request_id"`  // ID запроса из заголовка X-Request-ID
+	IP         string          `json:"ip"`          // IP адрес клиента
+	CreatedAt  time.Time       `json:"created_at"`  // Время действия
+}
+
+// AuditFilter задает условия выборки журнала действий администраторов
+type AuditFilter struct {
+	ActorID    int        // Пользователь (0 - все)
+	Action     string     // Действие
+	TargetType string     // Тип объекта
+	TargetID   string     // Идентификатор объекта
+	RequestID  string     // ID запроса
+	From       *time.Time // Начало периода
+	To         *time.Time // Конец периода (не включительно)
+	Limit      int        // Количество записей
+	Offset     int        // Смещение
+}
@@ -0,0 +1,31 @@
+// Package models Обязательства тайного голосования commit-reveal
+package models
+
+import "time"
+
+// TransactionSourceCommitment источник голоса для обязательств, поданных через API
+const TransactionSourceCommitment = "commitment"
+
+// VoteCommitment представляет хэш-обязательство голоса и его раскрытие
+type VoteCommitment struct {
+	ID         int        `json:"id"`                    // Уникальный идентификатор обязательства
+	VoteID     int        `json:"vote_id"`               // ID голосования
+	Voter      string     `json:"voter"`                 // Адрес кошелька голосующего
+	Commitment string     `json:"commitment"`            // sha256(voter:choice:salt) в hex
+	VotePower  int        `json:"vote_power"`            // Сила голоса на момент подачи обязательства
+	Choice     string     `json:"choice,omitempty"`      // Раскрытый выбор
+	Salt       string     `json:"salt,omitempty"`        // Раскрытая соль
+	CreatedAt  time.Time  `json:"created_at"`            // Время подачи обязательства
+	RevealedAt *time.Time `json:"revealed_at,omitempty"` // Время раскрытия
+}
+
+// CommitRequest представляет запрос на подачу обязательства
+type CommitRequest struct {
+	Commitment string `json:"commitment" validate:"required,hexadecimal,len=64"` // sha256(voter:choice:salt) в hex
+}
+
+// RevealRequest представляет запрос на раскрытие голоса
+type RevealRequest struct {
+	Choice string `json:"choice" validate:"required"` // Выбранный вариант ("За" или "Против")
+	Salt   string `json:"salt" validate:"required"`   // Соль, использованная в обязательстве
+}
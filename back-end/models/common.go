@@ -4,5 +4,36 @@ package models
 // WithdrawRequest представляет структуру запроса для снятия средств
 type WithdrawRequest struct {
 	Amount  float64 `json:"amount" validate:"required"`
-	Address string  `json:"address" validate:"required"`
+	Address string  `json:"address" validate:"required,decimal_or_evm_address"`
+	Coin    string  `json:"coin,omitempty"` // Монета вывода; если не указана - монета по умолчанию
+}
+
+// WithdrawResponse представляет ответ внешнего API на запрос снятия средств
+type WithdrawResponse struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Data    struct {
+		TransactionID int `json:"transaction_id"`
+	} `json:"data"`
+}
+
+// TransactionHashResponse представляет ответ внешнего API с данными транзакции
+type TransactionHashResponse struct {
+	Data struct {
+		ID             int    `json:"id"`
+		UserID         int    `json:"user_id"`
+		WalletID       int    `json:"wallet_id"`
+		Amount         string `json:"amount"`
+		Payload        string `json:"payload"`
+		DecimalPayload string `json:"decimal_payload"`
+		Coin           string `json:"coin"`
+		Hash           string `json:"hash"`
+		Result         string `json:"result"`
+		Complete       bool   `json:"complete"`
+		Success        bool   `json:"success"`
+		CreatedAt      string `json:"created_at"`
+		UpdatedAt      string `json:"updated_at"`
+		Type           int    `json:"type"`
+		Calculated     int    `json:"calculated"`
+	} `json:"data"`
 }
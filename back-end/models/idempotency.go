@@ -0,0 +1,23 @@
+// Package models Ключи идемпотентности запросов
+package models
+
+import "time"
+
+// Статусы ключа идемпотентности
+const (
+	IdempotencyInFlight  = "in_flight" // Первый запрос с ключом еще выполняется
+	IdempotencyCompleted = "completed" // Ответ первого запроса сохранен
+)
+
+// IdempotencyRecord представляет сохраненный результат запроса с заголовком Idempotency-Key
+type IdempotencyRecord struct {
+	Key            string    // Ключ в пространстве пользователя, метода и пути запроса
+	RequestHash    string    // sha256 метода, пути и тела запроса
+	Status         string    // Статус ключа
+	ResponseStatus int       // HTTP статус сохраненного ответа
+	ResponseBody   []byte    // Тело сохраненного ответа
+	ContentType    string    // Content-Type сохраненного ответа
+	Location       string    // Заголовок Location сохраненного ответа
+	CreatedAt      time.Time // Время первого запроса
+	ExpiresAt      time.Time // Время окончания хранения ответа
+}
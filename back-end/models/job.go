@@ -0,0 +1,31 @@
+// Package models Структуры фоновых задач
+package models
+
+import "time"
+
+// Статусы фоновых задач
+const (
+	JobStatusPending   = "pending"   // Задача выполняется
+	JobStatusConfirmed = "confirmed" // Транзакция получила хэш
+	JobStatusFailed    = "failed"    // Задача завершилась ошибкой
+)
+
+// JobTypeVoteSubmission тип задачи отправки голоса в блокчейн
+const JobTypeVoteSubmission = "vote_submission"
+
+// Job представляет фоновую задачу отправки транзакции
+type Job struct {
+	ID              string    `json:"id"`                         // Уникальный идентификатор задачи
+	Type            string    `json:"type"`                       // Тип задачи
+	Status          string    `json:"status"`                     // Статус задачи
+	UserID          int       `json:"user_id"`                    // Пользователь, создавший задачу
+	VoteID          int       `json:"vote_id"`                    // ID голосования
+	UserVoteID      int       `json:"user_vote_id"`               // ID голоса пользователя
+	TransactionID   int       `json:"transaction_id,omitempty"`   // ID транзакции во внешнем API
+	TransactionHash string    `json:"transaction_hash,omitempty"` // Хэш транзакции в блокчейне
+	Error           string    `json:"error,omitempty"`            // Причина ошибки
+	Attempts        int       `json:"attempts"`                   // Количество попыток получения хэша
+	CallbackURL     string    `json:"callback_url,omitempty"`     // Адрес для уведомления о завершении
+	CreatedAt       time.Time `json:"created_at"`                 // Время создания
+	UpdatedAt       time.Time `json:"updated_at"`                 // Время последнего обновления
+}
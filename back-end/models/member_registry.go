@@ -0,0 +1,60 @@
+// Package models Реестр участников DAO (таблица vote_strength): импорт и экспорт
+package models
+
+import "time"
+
+// Member представляет запись реестра участников DAO: кошелек и сила голоса
+type Member struct {
+	WalletAddress string `json:"wallet_address"` // Адрес кошелька в форме d0…
+	VotePower     int    `json:"vote_power"`     // Сила голоса
+}
+
+// MemberPowerChange представляет изменение силы голоса участника
+type MemberPowerChange struct {
+	WalletAddress string `json:"wallet_address"` // Адрес кошелька
+	OldVotePower  int    `json:"old_vote_power"` // Текущая сила голоса
+	NewVotePower  int    `json:"new_vote_power"` // Сила голоса из импорта
+}
+
+// MemberImportDiff представляет разницу между текущим реестром и импортируемым списком участников
+type MemberImportDiff struct {
+	Added     []Member            `json:"added"`     // Новые участники
+	Removed   []Member            `json:"removed"`   // Участники, отсутствующие в импорте
+	Changed   []MemberPowerChange `json:"changed"`   // Участники с измененной силой голоса
+	Unchanged int                 `json:"unchanged"` // Количество участников без изменений
+	Applied   bool                `json:"applied"`   // Изменения применены (false - пробный запуск)
+}
+
+// VoteStrengthChangeMeta автор и причина изменения реестра участников
+type VoteStrengthChangeMeta struct {
+	ActorID int    // ID пользователя, изменившего реестр (0 - система)
+	Actor   string // Логин пользователя
+	Reason  string // Причина изменения
+}
+
+// VoteStrengthHistoryEntry представляет запись истории изменения силы голоса кошелька.
+// OldVotePower пуст при добавлении участника, NewVotePower - при удалении.
+type VoteStrengthHistoryEntry struct {
+	ID            int       `json:"id"`             // Уникальный идентификатор записи
+	WalletAddress string    `json:"wallet_address"` // Адрес кошелька
+	OldVotePower  *int      `json:"old_vote_power"` // Сила голоса до изменения
+	NewVotePower  *int      `json:"new_vote_power"` // Сила голоса после изменения
+	ActorID       int       `json:"actor_id"`       // ID пользователя, внесшего изменение
+	Actor         string    `json:"actor"`          // Логин пользователя
+	Reason        string    `json:"reason"`         // Причина изменения
+	ChangedAt     time.Time `json:"changed_at"`     // Время изменения
+}
+
+// VoteStrengthUpdateRequest представляет запрос на изменение силы голоса кошелька
+type VoteStrengthUpdateRequest struct {
+	VotePower int    `json:"vote_power" validate:"required,gt=0"` // Новая сила голоса
+	Reason    string `json:"reason" validate:"max=500"`           // Причина изменения
+}
+
+// VoteStrengthAsOf представляет силу голоса кошелька на момент времени
+type VoteStrengthAsOf struct {
+	WalletAddress string    `json:"wallet_address"` // Адрес кошелька
+	AsOf          time.Time `json:"as_of"`          // Момент времени
+	Member        bool      `json:"member"`         // Кошелек был в реестре участников
+	VotePower     int       `json:"vote_power"`     // Сила голоса (0, если кошелек не был участником)
+}
@@ -0,0 +1,40 @@
+// Package models Выгрузка голосований с итогами для отчетов
+package models
+
+import "time"
+
+// ProposalExportFilter задает условия выборки голосований для выгрузки
+type ProposalExportFilter struct {
+	From   *time.Time // Начало периода создания
+	To     *time.Time // Конец периода создания (не включительно)
+	Status string     // Статус голосования ("active" или "completed", пусто - все)
+}
+
+// ProposalVoter представляет учтенный голос участника в выгрузке
+type ProposalVoter struct {
+	Wallet          string `json:"wallet"`           // Адрес кошелька
+	VotePower       int    `json:"vote_power"`       // Сила голоса
+	Choice          string `json:"choice"`           // Вариант ("for" или "against")
+	TransactionHash string `json:"transaction_hash"` // Хэш транзакции голоса
+	Source          string `json:"source,omitempty"` // Источник голоса ("signed" для подписанных голосов)
+}
+
+// ProposalExport представляет голосование с итогами подсчета и голосами участников
+type ProposalExport struct {
+	ID                int             `json:"id"`                 // ID голосования
+	Title             string          `json:"title"`              // Заголовок
+	Subtitle          string          `json:"subtitle"`           // Подзаголовок
+	VoteMode          string          `json:"vote_mode"`          // Режим приема голосов
+	BallotType        string          `json:"ballot_type"`        // Тип бюллетеня
+	CreatedAt         *time.Time      `json:"created_at"`         // Время создания
+	Status            string          `json:"status"`             // Статус ("active" или "completed")
+	Resolution        string          `json:"resolution"`         // Резолюция
+	DAOMembers        int             `json:"dao_members"`        // Количество участников DAO
+	VotedMembers      int             `json:"voted_members"`      // Количество проголосовавших
+	Turnout           string          `json:"turnout"`            // Явка
+	VotesFor          string          `json:"votes_for"`          // Итог голосов "За"
+	VotesAgainst      string          `json:"votes_against"`      // Итог голосов "Против"
+	TotalTransactions int             `json:"total_transactions"` // Всего транзакций, включая отклоненные
+	Voters            []ProposalVoter `json:"voters"`             // Учтенные голоса участников
+	Error             string          `json:"error,omitempty"`    // Ошибка подсчета итогов (итоги не заполнены)
+}
@@ -0,0 +1,25 @@
+// Package models Структуры отчета сверки голосов
+package models
+
+// ChoiceMismatch представляет голос, выбор которого в базе отличается от сообщения транзакции
+type ChoiceMismatch struct {
+	UserVote      UserVote    `json:"user_vote"`       // Локальный голос пользователя
+	Transaction   Transaction `json:"transaction"`     // Транзакция в блокчейне
+	LocalChoice   string      `json:"local_choice"`    // Вариант, сохраненный в user_votes
+	OnChainChoice string      `json:"on_chain_choice"` // Вариант из сообщения транзакции
+}
+
+// ReconciliationReport представляет результат сверки user_votes с транзакциями кошелька голосования
+type ReconciliationReport struct {
+	VoteID           int              `json:"vote_id"`                      // ID голосования
+	WalletAddress    string           `json:"wallet_address"`               // Кошелек голосования
+	LocalVotes       int              `json:"local_votes"`                  // Количество голосов в user_votes
+	OnChainVotes     int              `json:"on_chain_votes"`               // Количество голосующих транзакций в блокчейне
+	Matched          int              `json:"matched"`                      // Количество совпавших голосов
+	MissingOnChain   []UserVote       `json:"missing_on_chain"`             // Локальные голоса без транзакции
+	MissingLocal     []Transaction    `json:"missing_local"`                // Транзакции без локального голоса
+	NullVotePowerTxs []Transaction    `json:"null_vote_power_transactions"` // Транзакции без локального голоса от кошельков без силы голоса, не восстанавливаются
+	ChoiceMismatches []ChoiceMismatch `json:"choice_mismatches"`            // Расхождения выбора
+	Repaired         bool             `json:"repaired"`                     // Выполнено ли восстановление
+	RepairedRecords  int              `json:"repaired_records"`             // Количество исправленных записей
+}
@@ -0,0 +1,30 @@
+// Package models Структуры подписанных голосов без транзакций
+package models
+
+import "time"
+
+// TransactionSourceSigned источник голоса, поданного подписанным сообщением
+const TransactionSourceSigned = "signed"
+
+// SignedVote представляет голос, подписанный ключом кошелька участника без отправки транзакции
+type SignedVote struct {
+	ID         int       `json:"id"`          // Уникальный идентификатор голоса
+	VoteID     int       `json:"vote_id"`     // ID голосования
+	Voter      string    `json:"voter"`       // Адрес кошелька голосующего
+	Choice     string    `json:"choice"`      // Выбранный вариант ("За" или "Против")
+	VotePower  int       `json:"vote_power"`  // Сила голоса на момент подачи
+	Timestamp  int64     `json:"timestamp"`   // Время подписи (Unix, секунды)
+	Signature  string    `json:"signature"`   // Подпись канонического сообщения (hex)
+	PublicKey  string    `json:"public_key"`  // Публичный ключ подписанта (hex)
+	BallotHash string    `json:"ballot_hash"` // Идентификатор бюллетеня (sha256 подписи)
+	CreatedAt  time.Time `json:"created_at"`  // Время сохранения
+}
+
+// SignedVoteRequest представляет тело запроса на подачу подписанного голоса
+type SignedVoteRequest struct {
+	Voter     string `json:"voter" validate:"required,decimal_address"` // Адрес кошелька голосующего
+	Choice    string `json:"choice" validate:"required"`                // Выбранный вариант ("За" или "Против")
+	Timestamp int64  `json:"timestamp" validate:"required"`             // Время подписи (Unix, секунды)
+	Signature string `json:"signature" validate:"required"`             // Подпись в hex или base64
+	PublicKey string `json:"public_key"`                                // Публичный ключ в hex или base64 (необязателен для 65-байтовой подписи)
+}
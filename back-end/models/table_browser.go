@@ -0,0 +1,54 @@
+// Package models Просмотр таблиц базы данных по списку разрешенных таблиц и колонок
+package models
+
+// BrowsableTable описывает таблицу, доступную для просмотра
+type BrowsableTable struct {
+	Name     string   `json:"name"`     // Название таблицы
+	Columns  []string `json:"columns"`  // Колонки в порядке вывода
+	Redacted []string `json:"redacted"` // Колонки, значения которых скрываются
+}
+
+// TableQuery представляет запрос страницы строк таблицы из параметров URL
+type TableQuery struct {
+	Filters map[string]string // Фильтры по равенству значения колонки
+	Sort    string            // Колонка сортировки; префикс "-" - по убыванию (пусто - порядок добавления)
+	Cursor  string            // Курсор следующей страницы из предыдущего ответа
+	Limit   int               // Количество строк
+	Offset  int               // Смещение (не используется вместе с курсором)
+}
+
+// TableFilter условие равенства значения колонки
+type TableFilter struct {
+	Column string
+	Value  string
+}
+
+// TableCursor позиция последней строки страницы: значение колонки сортировки и rowid
+type TableCursor struct {
+	Sort  interface{} `json:"s"`
+	RowID int64       `json:"r"`
+}
+
+// TableSelect параметры выборки строк. Названия таблицы и колонок должны быть проверены
+// по списку разрешенных таблиц до построения запроса.
+type TableSelect struct {
+	Table      string        // Таблица
+	Columns    []string      // Выбираемые колонки
+	Filters    []TableFilter // Условия равенства
+	SortColumn string        // Колонка сортировки (пусто - rowid)
+	Desc       bool          // Сортировка по убыванию
+	After      *TableCursor  // Строки после курсора
+	Limit      int           // Количество строк
+	Offset     int           // Смещение
+}
+
+// TablePage представляет страницу строк таблицы
+type TablePage struct {
+	Table      string                   `json:"table"`                 // Таблица
+	Columns    []string                 `json:"columns"`               // Колонки в порядке вывода
+	Redacted   []string                 `json:"redacted"`              // Скрытые колонки
+	Rows       []map[string]interface{} `json:"rows"`                  // Строки
+	Limit      int                      `json:"limit"`                 // Количество строк
+	Offset     int                      `json:"offset"`                // Смещение
+	NextCursor string                   `json:"next_cursor,omitempty"` // Курсор следующей страницы (пусто - страниц больше нет)
+}
@@ -1,26 +1,63 @@
 // Package models Структуры для голосования пользователей
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
+// Политики повторного голосования пользователя в рамках одного голосования
+const (
+	VoteChangeReject  = "reject"  // Повторный голос отклоняется
+	VoteChangeReplace = "replace" // Повторный голос заменяет предыдущий выбор
+)
+
+// Режимы приема голосов в голосовании
+const (
+	VoteModeOnChain  = "onchain"  // Только транзакции в блокчейне
+	VoteModeOffChain = "offchain" // Только подписанные голоса без транзакций
+	VoteModeHybrid   = "hybrid"   // Транзакции и подписанные голоса
+)
+
+// Типы бюллетеней голосования
+const (
+	BallotOpen         = "open"          // Открытый голос сообщением транзакции
+	BallotCommitReveal = "commit_reveal" // Тайный голос: хэш-обязательство, затем раскрытие
+)
+
 // VoteInfo представляет структуру для хранения пользовательского голосования.
 type VoteInfo struct {
-	ID             int    `json:"id"`                              // Уникальный идентификатор голосования
-	Title          string `json:"title" validate:"required"`       // Заголовок голосования
-	Subtitle       string `json:"subtitle" validate:"required"`    // Подзаголовок голосования
-	Description    string `json:"description" validate:"required"` // Описание предложения
-	Voter          string `json:"voter" validate:"required"`       // Адрес кошелька, с которого было отправлено голосование
-	Choice         string `json:"choice" validate:"required"`      // Выбранный вариант голосования ("За" или "Против")
-	VotePower      int    `json:"vote_power"`                      // Сила голоса
-	WalletAddress  string `json:"wallet_address"`                  // Адрес кошелька
-	MnemonicPhrase string `json:"-"`                               // Мнемоническая фраза, скрыта в JSON-ответах
+	ID               int        `json:"id"`                              // Уникальный идентификатор голосования
+	Title            string     `json:"title" validate:"required"`       // Заголовок голосования
+	Subtitle         string     `json:"subtitle" validate:"required"`    // Подзаголовок голосования
+	Description      string     `json:"description" validate:"required"` // Описание предложения
+	Voter            string     `json:"voter" validate:"required"`       // Адрес кошелька, с которого было отправлено голосование
+	Choice           string     `json:"choice" validate:"required"`      // Выбранный вариант голосования ("За" или "Против")
+	VotePower        int        `json:"vote_power"`                      // Сила голоса
+	WalletAddress    string     `json:"wallet_address"`                  // Адрес кошелька
+	VoteChangePolicy string     `json:"vote_change_policy"`              // Политика повторного голосования ("reject" или "replace")
+	VoteMode         string     `json:"vote_mode"`                       // Режим приема голосов ("onchain", "offchain" или "hybrid")
+	BallotType       string     `json:"ballot_type"`                     // Тип бюллетеня ("open" или "commit_reveal")
+	CommitEndsAt     *time.Time `json:"commit_ends_at,omitempty"`        // Окончание приема обязательств
+	RevealEndsAt     *time.Time `json:"reveal_ends_at,omitempty"`        // Окончание раскрытия голосов
+	VoteCoin         string     `json:"vote_coin,omitempty"`             // Монета депозита голоса (пусто - любая)
+	MinVoteAmount    float64    `json:"min_vote_amount,omitempty"`       // Минимальная сумма депозита голоса
+	CreatedAt        *time.Time `json:"created_at,omitempty"`            // Время создания (пусто у голосований, созданных до его учета)
+	MnemonicPhrase   string     `json:"-"`                               // Мнемоническая фраза, скрыта в JSON-ответах
 }
 
 // NewVote представляет структуру для пользовательского голосования без VoterID.
 type NewVote struct {
-	Title       string `json:"title" validate:"required"`       // Заголовок голосования
-	Subtitle    string `json:"subtitle" validate:"required"`    // Подзаголовок голосования
-	Description string `json:"description" validate:"required"` // Описание предложения
-	Voter       string `json:"voter" validate:"required"`       // Адрес кошелька, с которого было отправлено голосование
-	Choice      string `json:"choice" validate:"required"`      // Выбранный вариант голосования ("За" или "Против")
+	Title            string  `json:"title" validate:"required"`                                    // Заголовок голосования
+	Subtitle         string  `json:"subtitle" validate:"required"`                                 // Подзаголовок голосования
+	Description      string  `json:"description" validate:"required"`                              // Описание предложения
+	Voter            string  `json:"voter" validate:"required,decimal_address"`                    // Адрес кошелька, с которого было отправлено голосование
+	Choice           string  `json:"choice" validate:"required"`                                   // Выбранный вариант голосования ("За" или "Против")
+	VoteChangePolicy string  `json:"vote_change_policy" validate:"omitempty,oneof=reject replace"` // Политика повторного голосования
+	VoteMode         string  `json:"vote_mode" validate:"omitempty,oneof=onchain offchain hybrid"` // Режим приема голосов
+	BallotType       string  `json:"ballot_type" validate:"omitempty,oneof=open commit_reveal"`    // Тип бюллетеня
+	VoteCoin         string  `json:"vote_coin"`                                                    // Монета депозита голоса
+	MinVoteAmount    float64 `json:"min_vote_amount" validate:"gte=0"`                             // Минимальная сумма депозита голоса
 }
 
 // WithdrawOrderResponse представляет ответ от API результатов голосования команды DAO.
@@ -32,10 +69,14 @@ type WithdrawOrderResponse struct {
 
 // Transaction представляет одну транзакцию в результатах голосования команды DAO.
 type Transaction struct {
-	From      string `json:"from"`
-	Message   string `json:"message"`
-	VotePower int    `json:"vote_power"`
-	Hash      string `json:"hash"` // Добавлено поле для хэша транзакции
+	From      string      `json:"from"`
+	Message   string      `json:"message"`
+	VotePower int         `json:"vote_power"`
+	Hash      string      `json:"hash"`                // Добавлено поле для хэша транзакции
+	Source    string      `json:"source,omitempty"`    // Источник голоса ("signed" для подписанных голосов)
+	Timestamp string      `json:"timestamp,omitempty"` // Время транзакции в эксплорере (RFC3339)
+	Coin      string      `json:"coin,omitempty"`      // Монета перевода
+	Amount    json.Number `json:"amount,omitempty"`    // Сумма перевода
 }
 
 // VoteResults представляет обработанные результаты голосования команды DAO.
@@ -52,13 +93,36 @@ type VoteResults struct {
 	RejectedTxs       []Transaction `json:"rejected_transactions"`
 	NullVotePowerTxs  []Transaction `json:"null_vote_power_transactions"`
 	InvalidMessageTxs []Transaction `json:"invalid_message_transactions"`
+	UnrevealedTxs     []Transaction `json:"unrevealed_commitments,omitempty"`            // Обязательства без раскрытия
+	MismatchedReveals []Transaction `json:"mismatched_reveals,omitempty"`                // Раскрытия, не совпавшие с обязательством
+	InsufficientTxs   []Transaction `json:"insufficient_deposit_transactions,omitempty"` // Транзакции в другой монете или с суммой меньше минимальной
 }
 
+// Статусы квитанции голоса пользователя
+const (
+	ReceiptStatusPending   = "pending"   // Голос сохранен, транзакция еще не отправлена
+	ReceiptStatusSubmitted = "submitted" // Транзакция создана во внешнем API
+	ReceiptStatusSent      = "sent"      // Транзакция получила хэш
+	ReceiptStatusConfirmed = "confirmed" // Транзакция найдена среди транзакций кошелька голосования
+	ReceiptStatusFailed    = "failed"    // Отправка транзакции завершилась ошибкой
+)
+
 // UserVote представляет структуру для голосjdfybz пользователей.
 type UserVote struct {
-	VoterID   int    `json:"id"`         // Уникальный идентификатор голоса
-	VoteID    int    `json:"vote_id"`    // VoterID голосования
-	Voter     string `json:"voter"`      // Адрес кошелька голосующего
-	Choice    string `json:"choice"`     // Выбранный вариант ("За" или "Против")
-	VotePower int    `json:"vote_power"` // Сила голоса
+	VoterID         int       `json:"id"`                         // Уникальный идентификатор голоса
+	VoteID          int       `json:"vote_id"`                    // VoterID голосования
+	Voter           string    `json:"voter"`                      // Адрес кошелька голосующего
+	Choice          string    `json:"choice"`                     // Выбранный вариант ("За" или "Против")
+	VotePower       int       `json:"vote_power"`                 // Сила голоса
+	TransactionID   int       `json:"transaction_id,omitempty"`   // ID транзакции во внешнем API
+	TransactionHash string    `json:"transaction_hash,omitempty"` // Хэш транзакции в блокчейне
+	Status          string    `json:"status"`                     // Статус квитанции
+	CreatedAt       time.Time `json:"created_at"`                 // Время создания голоса
+	UpdatedAt       time.Time `json:"updated_at"`                 // Время последнего обновления
+}
+
+// UserVoteRequest представляет тело запроса на голосование пользователя.
+type UserVoteRequest struct {
+	Choice      string `json:"choice" validate:"required"`                 // Выбранный вариант ("За" или "Против")
+	CallbackURL string `json:"callback_url" validate:"omitempty,http_url"` // Адрес для уведомления о завершении отправки голоса
 }
@@ -0,0 +1,51 @@
+// Package models Синхронизация силы голоса с балансами монеты PRO
+package models
+
+import "time"
+
+// CoinHolder представляет баланс адреса в монете по данным эксплорера
+type CoinHolder struct {
+	Address string `json:"address"` // Адрес кошелька
+	Amount  string `json:"amount"`  // Баланс в минимальных единицах монеты
+}
+
+// VotePowerFormula правило расчета силы голоса по балансу монеты:
+// сила голоса = целая часть (баланс / coins_per_vote), не больше max_vote_power.
+// Кошелек с силой голоса меньше min_vote_power исключается из реестра участников.
+type VotePowerFormula struct {
+	Coin         string `json:"coin"`           // Монета, баланс которой определяет силу голоса
+	Decimals     int    `json:"decimals"`       // Количество знаков после запятой в балансе монеты
+	CoinsPerVote int64  `json:"coins_per_vote"` // Количество монет на один голос
+	MinVotePower int    `json:"min_vote_power"` // Минимальная сила голоса участника
+	MaxVotePower int    `json:"max_vote_power"` // Максимальная сила голоса (0 - без ограничения)
+	Discover     bool   `json:"discover"`       // Добавлять держателей монеты, отсутствующих в реестре
+}
+
+// VotePowerSyncError представляет кошелек, баланс которого не удалось получить или разобрать.
+// Запись такого кошелька в реестре не изменяется.
+type VotePowerSyncError struct {
+	WalletAddress string `json:"wallet_address"` // Адрес кошелька
+	Error         string `json:"error"`          // Описание ошибки
+}
+
+// VotePowerSyncRun представляет отчет запуска синхронизации силы голоса с балансами монеты.
+// Changes и Errors заполняются только при получении отдельного отчета.
+type VotePowerSyncRun struct {
+	ID         int                  `json:"id"`                // Уникальный идентификатор запуска
+	ActorID    int                  `json:"actor_id"`          // ID пользователя, запустившего синхронизацию (0 - расписание)
+	Actor      string               `json:"actor"`             // Логин пользователя или vote_power_sync
+	Applied    bool                 `json:"applied"`           // Изменения применены (false - пробный запуск)
+	Formula    VotePowerFormula     `json:"formula"`           // Правило расчета силы голоса
+	Checked    int                  `json:"checked"`           // Количество проверенных кошельков
+	Discovered int                  `json:"discovered"`        // Количество найденных держателей монеты
+	Added      int                  `json:"added"`             // Количество добавленных участников
+	Removed    int                  `json:"removed"`           // Количество удаленных участников
+	Changed    int                  `json:"changed"`           // Количество участников с измененной силой голоса
+	Unchanged  int                  `json:"unchanged"`         // Количество участников без изменений
+	Failed     int                  `json:"failed"`            // Количество кошельков с ошибкой получения баланса
+	Changes    *MemberImportDiff    `json:"changes,omitempty"` // Изменения реестра
+	Errors     []VotePowerSyncError `json:"errors,omitempty"`  // Ошибки по кошелькам
+	Error      string               `json:"error,omitempty"`   // Ошибка запуска
+	StartedAt  time.Time            `json:"started_at"`        // Время начала
+	FinishedAt time.Time            `json:"finished_at"`       // Время окончания
+}
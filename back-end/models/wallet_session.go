@@ -0,0 +1,44 @@
+// Package models Вход участников DAO подписью кошелька Decimal
+package models
+
+import "time"
+
+// WalletChallengeRequest представляет запрос на получение nonce для входа подписью кошелька
+type WalletChallengeRequest struct {
+	Wallet string `json:"wallet" validate:"required,decimal_or_evm_address"` // Адрес кошелька участника
+}
+
+// WalletChallenge представляет одноразовое сообщение, которое участник подписывает ключом кошелька
+type WalletChallenge struct {
+	Wallet    string    `json:"wallet"`     // Адрес кошелька в форме d0…
+	Nonce     string    `json:"nonce"`      // Одноразовое случайное значение
+	Message   string    `json:"message"`    // Сообщение для подписи
+	ExpiresAt time.Time `json:"expires_at"` // Срок действия nonce
+}
+
+// WalletSignInRequest представляет запрос на вход с подписанным сообщением
+type WalletSignInRequest struct {
+	Wallet    string `json:"wallet" validate:"required,decimal_or_evm_address"` // Адрес кошелька участника
+	Nonce     string `json:"nonce" validate:"required"`                         // Nonce из WalletChallenge
+	Signature string `json:"signature" validate:"required"`                     // Подпись сообщения в hex или base64
+	PublicKey string `json:"public_key"`                                        // Публичный ключ в hex или base64 (необязателен для 65-байтовой подписи)
+}
+
+// WalletSession представляет сессию участника, вошедшего подписью кошелька. Токен сессии не хранится, только его хэш.
+type WalletSession struct {
+	ID         int        `json:"id"`                     // Уникальный идентификатор сессии
+	MemberID   int        `json:"-"`                      // ID первой сессии кошелька, постоянный для участника
+	Wallet     string     `json:"wallet"`                 // Адрес кошелька участника
+	CreatedAt  time.Time  `json:"created_at"`             // Время входа
+	ExpiresAt  time.Time  `json:"expires_at"`             // Срок действия сессии
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"` // Время последнего использования
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`   // Время выхода
+}
+
+// WalletSignInResponse представляет результат входа: токен сессии возвращается только один раз
+type WalletSignInResponse struct {
+	Token     string    `json:"token"`      // Токен сессии для заголовка Authorization
+	Wallet    string    `json:"wallet"`     // Адрес кошелька участника
+	VotePower int       `json:"vote_power"` // Сила голоса по таблице vote_strength
+	ExpiresAt time.Time `json:"expires_at"` // Срок действия сессии
+}
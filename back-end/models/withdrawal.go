@@ -0,0 +1,81 @@
+// Package models Журнал выводов средств
+package models
+
+import "time"
+
+// Статусы вывода средств
+const (
+	WithdrawalStatusPendingApproval = "pending_approval" // Крупный вывод ожидает одобрения администраторов
+	WithdrawalStatusPending         = "pending"          // Запись создана, запрос во внешний API еще не выполнен
+	WithdrawalStatusSubmitted       = "submitted"        // Внешний API создал транзакцию
+	WithdrawalStatusSent            = "sent"             // Транзакция получила хэш в блокчейне
+	WithdrawalStatusCompleted       = "completed"        // Перевод успешно завершен
+	WithdrawalStatusFailed          = "failed"           // Перевод завершился ошибкой
+	WithdrawalStatusRejected        = "rejected"         // Вывод отклонен администратором
+	WithdrawalStatusExpired         = "expired"          // Срок одобрения вывода истек
+)
+
+// Решения администраторов по выводу средств
+const (
+	ApprovalDecisionApprove = "approve" // Одобрение
+	ApprovalDecisionReject  = "reject"  // Отклонение
+)
+
+// Withdrawal представляет запись журнала выводов средств
+type Withdrawal struct {
+	ID              int        `json:"id"`                         // Уникальный идентификатор вывода
+	UserID          int        `json:"user_id"`                    // Пользователь, запросивший вывод
+	Requester       string     `json:"requester"`                  // Кошелек пользователя, запросившего вывод
+	Amount          float64    `json:"amount"`                     // Сумма вывода
+	Coin            string     `json:"coin"`                       // Монета вывода
+	Address         string     `json:"address"`                    // Адрес получателя
+	TransactionID   int        `json:"transaction_id,omitempty"`   // ID транзакции во внешнем API
+	TransactionHash string     `json:"transaction_hash,omitempty"` // Хэш транзакции в блокчейне
+	Status          string     `json:"status"`                     // Статус вывода
+	Error           string     `json:"error,omitempty"`            // Причина ошибки
+	Attempts        int        `json:"attempts"`                   // Количество опросов статуса транзакции
+	CreatedAt       time.Time  `json:"created_at"`                 // Время создания
+	UpdatedAt       time.Time  `json:"updated_at"`                 // Время последнего обновления
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`     // Время получения итогового статуса
+
+	ApprovalsRequired int                  `json:"approvals_required,omitempty"` // Количество одобрений, необходимое для отправки
+	ExpiresAt         *time.Time           `json:"expires_at,omitempty"`         // Срок ожидания одобрения
+	Approvals         []WithdrawalApproval `json:"approvals,omitempty"`          // Решения администраторов
+}
+
+// IsFinal проверяет, что вывод получил итоговый статус
+func (w Withdrawal) IsFinal() bool {
+	switch w.Status {
+	case WithdrawalStatusCompleted, WithdrawalStatusFailed, WithdrawalStatusRejected, WithdrawalStatusExpired:
+		return true
+	}
+	return false
+}
+
+// WithdrawalApproval представляет решение администратора по выводу средств
+type WithdrawalApproval struct {
+	ID           int       `json:"id"`                // Уникальный идентификатор решения
+	WithdrawalID int       `json:"withdrawal_id"`     // Вывод средств
+	AdminID      int       `json:"admin_id"`          // Администратор, принявший решение
+	AdminWallet  string    `json:"admin_wallet"`      // Кошелек администратора
+	Decision     string    `json:"decision"`          // Решение: approve или reject
+	Comment      string    `json:"comment,omitempty"` // Комментарий администратора
+	CreatedAt    time.Time `json:"created_at"`        // Время решения
+}
+
+// ApprovalRequest представляет тело запроса на одобрение или отклонение вывода средств
+type ApprovalRequest struct {
+	Comment string `json:"comment" validate:"max=500"` // Комментарий администратора
+}
+
+// WithdrawalFilter задает условия выборки журнала выводов средств
+type WithdrawalFilter struct {
+	AllUsers bool       // Выводы всех пользователей; иначе только выводы UserID
+	UserID   int        // Пользователь, запросивший вывод
+	Status   string     // Статус вывода
+	Address  string     // Адрес получателя
+	From     *time.Time // Начало периода создания
+	To       *time.Time // Конец периода создания (не включительно)
+	Limit    int        // Количество записей
+	Offset   int        // Смещение
+}
@@ -0,0 +1,59 @@
+// Package models Политики вывода средств
+package models
+
+import "time"
+
+// Типы правил политики вывода средств
+const (
+	PolicyPerTransactionMax = "per_tx_max"       // Максимальная сумма одного вывода
+	PolicyUserDailyCap      = "user_daily_cap"   // Дневной лимит суммы выводов пользователя
+	PolicyGlobalDailyCap    = "global_daily_cap" // Дневной лимит суммы всех выводов
+	PolicyAllowlist         = "allowlist"        // Разрешенный адрес получателя
+	PolicyDenylist          = "denylist"         // Запрещенный адрес получателя
+)
+
+// Requester представляет пользователя, запросившего вывод средств
+type Requester struct {
+	UserID int      // ID пользователя
+	Wallet string   // Кошелек пользователя
+	Roles  []string // Названия ролей пользователя
+}
+
+// WithdrawalPolicy представляет правило политики вывода средств
+type WithdrawalPolicy struct {
+	ID          int       `json:"id"`                    // Уникальный идентификатор правила
+	Type        string    `json:"type"`                  // Тип правила
+	Amount      float64   `json:"amount,omitempty"`      // Сумма для лимитов
+	Address     string    `json:"address,omitempty"`     // Адрес для списков разрешенных и запрещенных адресов
+	Coin        string    `json:"coin,omitempty"`        // Монета, к выводам которой применяется правило (пусто - все монеты)
+	ExemptRoles []string  `json:"exempt_roles"`          // Роли, на которые правило не распространяется
+	Enabled     bool      `json:"enabled"`               // Признак действия правила
+	Description string    `json:"description,omitempty"` // Описание правила
+	CreatedAt   time.Time `json:"created_at"`            // Время создания
+	UpdatedAt   time.Time `json:"updated_at"`            // Время последнего изменения
+}
+
+// WithdrawalPolicyRequest представляет запрос на создание или изменение правила политики
+type WithdrawalPolicyRequest struct {
+	Type        string   `json:"type" validate:"required,oneof=per_tx_max user_daily_cap global_daily_cap allowlist denylist"`
+	Amount      float64  `json:"amount" validate:"gte=0"`
+	Address     string   `json:"address" validate:"omitempty,decimal_or_evm_address"`
+	Coin        string   `json:"coin"`
+	ExemptRoles []string `json:"exempt_roles"`
+	Enabled     *bool    `json:"enabled"`
+	Description string   `json:"description"`
+}
+
+// PolicyRejection представляет запись об отклоненном политикой выводе средств
+type PolicyRejection struct {
+	ID         int       `json:"id"`          // Уникальный идентификатор записи
+	UserID     int       `json:"user_id"`     // Пользователь, запросивший вывод
+	Requester  string    `json:"requester"`   // Кошелек пользователя
+	Amount     float64   `json:"amount"`      // Запрошенная сумма
+	Address    string    `json:"address"`     // Адрес получателя
+	Coin       string    `json:"coin"`        // Монета вывода
+	PolicyID   int       `json:"policy_id"`   // Правило, отклонившее вывод
+	PolicyType string    `json:"policy_type"` // Тип правила
+	Reason     string    `json:"reason"`      // Причина отказа
+	CreatedAt  time.Time `json:"created_at"`  // Время отказа
+}
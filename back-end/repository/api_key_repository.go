@@ -0,0 +1,110 @@
+// Package repository Хранилище API ключей сервисного доступа
+package repository
+
+import (
+	"dao_vote/back-end/models"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrAPIKeyNotFound возвращается, если API ключ не найден
+var ErrAPIKeyNotFound = errors.New("API ключ не найден")
+
+// apiKeyColumns перечень колонок таблицы api_keys в порядке сканирования scanAPIKey
+const apiKeyColumns = "id, name, prefix, scopes, allowed_ips, wallet, created_by, created_at, expires_at, last_used_at, last_used_ip, revoked_at"
+
+// scanAPIKey считывает API ключ из строки результата
+func scanAPIKey(row rowScanner) (models.APIKey, error) {
+	var key models.APIKey
+	var scopes, allowedIPs string
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &scopes, &allowedIPs, &key.Wallet, &key.CreatedBy, &key.CreatedAt,
+		&expiresAt, &lastUsedAt, &key.LastUsedIP, &revokedAt)
+	key.Scopes = splitRoles(scopes)
+	key.AllowedIPs = splitRoles(allowedIPs)
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, err
+}
+
+// CreateAPIKey сохраняет API ключ с хэшем его значения и возвращает ID
+func CreateAPIKey(key models.APIKey, keyHash string) (int, error) {
+	var expiresAt interface{}
+	if key.ExpiresAt != nil {
+		expiresAt = key.ExpiresAt.UTC()
+	}
+	result, err := db.Exec(`INSERT INTO api_keys (name, prefix, key_hash, scopes, allowed_ips, wallet, created_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		key.Name, key.Prefix, keyHash, strings.Join(key.Scopes, ","), strings.Join(key.AllowedIPs, ","), key.Wallet,
+		key.CreatedBy, key.CreatedAt.UTC(), expiresAt)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// GetAPIKeyByID возвращает API ключ по ID
+func GetAPIKeyByID(id int) (models.APIKey, error) {
+	key, err := scanAPIKey(db.QueryRow("SELECT "+apiKeyColumns+" FROM api_keys WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return key, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+// GetAPIKeyByHash возвращает API ключ по хэшу его значения
+func GetAPIKeyByHash(keyHash string) (models.APIKey, error) {
+	key, err := scanAPIKey(db.QueryRow("SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = ?", keyHash))
+	if err == sql.ErrNoRows {
+		return key, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+// ListAPIKeys возвращает все API ключи, включая отозванные
+func ListAPIKeys() ([]models.APIKey, error) {
+	rows, err := db.Query("SELECT " + apiKeyColumns + " FROM api_keys ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// TouchAPIKey записывает время и IP адрес последнего использования API ключа
+func TouchAPIKey(id int, ip string, usedAt time.Time) error {
+	_, err := db.Exec("UPDATE api_keys SET last_used_at = ?, last_used_ip = ? WHERE id = ?", usedAt.UTC(), ip, id)
+	return err
+}
+
+// RevokeAPIKey отзывает API ключ; повторный отзыв не меняет время отзыва
+func RevokeAPIKey(id int, revokedAt time.Time) error {
+	result, err := db.Exec("UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ?", revokedAt.UTC(), id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, ErrAPIKeyNotFound)
+}
@@ -0,0 +1,110 @@
+// Package repository Хранилище журнала действий администраторов
+package repository
+
+import (
+	"dao_vote/back-end/models"
+	"strings"
+	"time"
+)
+
+// auditColumns перечень колонок таблицы audit_log в порядке сканирования scanAuditEntry
+const auditColumns = "id, actor_id, actor, action, target_type, target_id, before_value, after_value, request_id, ip, created_at"
+
+// scanAuditEntry считывает запись журнала действий из строки результата
+func scanAuditEntry(row rowScanner) (models.AuditEntry, error) {
+	var entry models.AuditEntry
+	var before, after []byte
+	err := row.Scan(&entry.ID, &entry.ActorID, &entry.Actor, &entry.Action, &entry.TargetType, &entry.TargetID,
+		&before, &after, &entry.RequestID, &entry.IP, &entry.CreatedAt)
+	entry.Before, entry.After = nullableJSON(before), nullableJSON(after)
+	return entry, err
+}
+
+// nullableJSON возвращает JSON значение колонки; пустая колонка - null
+func nullableJSON(value []byte) []byte {
+	if len(value) == 0 {
+		return []byte("null")
+	}
+	return value
+}
+
+// AddAuditEntry добавляет запись в журнал действий. Записи журнала не изменяются и не удаляются.
+func AddAuditEntry(entry models.AuditEntry) (int, error) {
+	var before, after interface{}
+	if len(entry.Before) > 0 && string(entry.Before) != "null" {
+		before = string(entry.Before)
+	}
+	if len(entry.After) > 0 && string(entry.After) != "null" {
+		after = string(entry.After)
+	}
+	createdAt := entry.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	result, err := db.Exec(`INSERT INTO audit_log (actor_id, actor, action, target_type, target_id, before_value, after_value, request_id, ip, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.ActorID, entry.Actor, entry.Action, entry.TargetType, entry.TargetID, before, after, entry.RequestID, entry.IP, createdAt.UTC())
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// ListAuditEntries возвращает записи журнала действий по фильтру, начиная с последних
+func ListAuditEntries(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.ActorID != 0 {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, filter.ActorID)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.TargetType != "" {
+		conditions = append(conditions, "target_type = ?")
+		args = append(args, filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		conditions = append(conditions, "target_id = ?")
+		args = append(args, filter.TargetID)
+	}
+	if filter.RequestID != "" {
+		conditions = append(conditions, "request_id = ?")
+		args = append(args, filter.RequestID)
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From.UTC())
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.To.UTC())
+	}
+
+	query := "SELECT " + auditColumns + " FROM audit_log"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ? OFFSET ?"
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
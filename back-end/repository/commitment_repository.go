@@ -0,0 +1,90 @@
+// Package repository Хранилище обязательств тайного голосования
+package repository
+
+import (
+	"dao_vote/back-end/models"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrCommitmentNotFound возвращается, если обязательство не найдено
+var ErrCommitmentNotFound = errors.New("обязательство не найдено")
+
+// commitmentColumns перечень колонок таблицы vote_commitments в порядке сканирования scanCommitment
+const commitmentColumns = "id, vote_id, voter, commitment, vote_power, choice, salt, created_at, revealed_at"
+
+// scanCommitment считывает обязательство из строки результата
+func scanCommitment(row rowScanner) (models.VoteCommitment, error) {
+	var commitment models.VoteCommitment
+	var revealedAt sql.NullTime
+	err := row.Scan(&commitment.ID, &commitment.VoteID, &commitment.Voter, &commitment.Commitment, &commitment.VotePower,
+		&commitment.Choice, &commitment.Salt, &commitment.CreatedAt, &revealedAt)
+	if revealedAt.Valid {
+		commitment.RevealedAt = &revealedAt.Time
+	}
+	return commitment, err
+}
+
+// AddCommitment сохраняет обязательство голоса
+func AddCommitment(commitment models.VoteCommitment) (int, error) {
+	result, err := db.Exec("INSERT INTO vote_commitments (vote_id, voter, commitment, vote_power, created_at) VALUES (?, ?, ?, ?, ?)",
+		commitment.VoteID, commitment.Voter, commitment.Commitment, commitment.VotePower, time.Now().UTC())
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, ErrUserVoteExists
+		}
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// ReplaceCommitment заменяет обязательство кошелька, сбрасывая раскрытие
+func ReplaceCommitment(commitment models.VoteCommitment) error {
+	_, err := db.Exec("UPDATE vote_commitments SET commitment = ?, vote_power = ?, choice = '', salt = '', created_at = ?, revealed_at = NULL WHERE vote_id = ? AND voter = ?",
+		commitment.Commitment, commitment.VotePower, time.Now().UTC(), commitment.VoteID, commitment.Voter)
+	return err
+}
+
+// RevealCommitment сохраняет раскрытые выбор и соль обязательства
+func RevealCommitment(id int, choice, salt string) error {
+	_, err := db.Exec("UPDATE vote_commitments SET choice = ?, salt = ?, revealed_at = ? WHERE id = ?",
+		choice, salt, time.Now().UTC(), id)
+	return err
+}
+
+// GetCommitmentByVoter возвращает обязательство кошелька в голосовании
+func GetCommitmentByVoter(voteID int, voter string) (models.VoteCommitment, error) {
+	commitment, err := scanCommitment(db.QueryRow("SELECT "+commitmentColumns+" FROM vote_commitments WHERE vote_id = ? AND voter = ?", voteID, voter))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return commitment, ErrCommitmentNotFound
+		}
+		return commitment, err
+	}
+	return commitment, nil
+}
+
+// GetCommitments возвращает все обязательства голосования в порядке подачи
+func GetCommitments(voteID int) ([]models.VoteCommitment, error) {
+	rows, err := db.Query("SELECT "+commitmentColumns+" FROM vote_commitments WHERE vote_id = ? ORDER BY id", voteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var commitments []models.VoteCommitment
+	for rows.Next() {
+		commitment, err := scanCommitment(rows)
+		if err != nil {
+			return nil, err
+		}
+		commitments = append(commitments, commitment)
+	}
+	return commitments, rows.Err()
+}
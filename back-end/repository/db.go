@@ -4,7 +4,6 @@ package repository
 
 import (
 	"database/sql"
-	"fmt"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -26,12 +25,27 @@ func InitDB(dataSourceName string) error {
         voter TEXT,
         choice TEXT,
         vote_power INTEGER,
-        wallet_address TEXT
+        wallet_address TEXT,
+        vote_change_policy TEXT NOT NULL DEFAULT 'reject',
+        vote_mode TEXT NOT NULL DEFAULT 'onchain',
+        ballot_type TEXT NOT NULL DEFAULT 'open',
+        commit_ends_at DATETIME,
+        reveal_ends_at DATETIME,
+        vote_coin TEXT NOT NULL DEFAULT '',
+        min_vote_amount REAL NOT NULL DEFAULT 0,
+        created_at DATETIME
     );`
 	if _, err := db.Exec(createVotesTable); err != nil {
 		return err
 	}
 
+	// Индекс выборки голосований по времени создания
+	createVotesCreatedAtIndex := `
+    CREATE INDEX IF NOT EXISTS idx_votes_created_at ON votes (created_at);`
+	if _, err := db.Exec(createVotesCreatedAtIndex); err != nil {
+		return err
+	}
+
 	// Создаем таблицу для голосов пользователей, если она не существует
 	createUserVotesTable := `
     CREATE TABLE IF NOT EXISTS user_votes (
@@ -39,12 +53,24 @@ func InitDB(dataSourceName string) error {
         vote_id INTEGER,
         voter TEXT,
         choice TEXT,
-        vote_power INTEGER
+        vote_power INTEGER,
+        transaction_id INTEGER NOT NULL DEFAULT 0,
+        transaction_hash TEXT NOT NULL DEFAULT '',
+        status TEXT NOT NULL DEFAULT 'pending',
+        created_at DATETIME,
+        updated_at DATETIME
     );`
 	if _, err := db.Exec(createUserVotesTable); err != nil {
 		return err
 	}
 
+	// Один голос на кошелек в рамках голосования
+	createUserVotesVoterIndex := `
+    CREATE UNIQUE INDEX IF NOT EXISTS idx_user_votes_vote_voter ON user_votes (vote_id, voter);`
+	if _, err := db.Exec(createUserVotesVoterIndex); err != nil {
+		return err
+	}
+
 	// Создаем таблицу для силы голосов кошельков, если она не существует
 	createVoteStrengthTable := `
     CREATE TABLE IF NOT EXISTS vote_strength (
@@ -56,6 +82,317 @@ func InitDB(dataSourceName string) error {
 		return err
 	}
 
+	// Создаем таблицу для фоновых задач, если она не существует
+	createJobsTable := `
+    CREATE TABLE IF NOT EXISTS jobs (
+        id TEXT PRIMARY KEY,
+        type TEXT NOT NULL,
+        status TEXT NOT NULL,
+        user_id INTEGER,
+        vote_id INTEGER,
+        user_vote_id INTEGER,
+        transaction_id INTEGER NOT NULL DEFAULT 0,
+        transaction_hash TEXT NOT NULL DEFAULT '',
+        error TEXT NOT NULL DEFAULT '',
+        attempts INTEGER NOT NULL DEFAULT 0,
+        callback_url TEXT NOT NULL DEFAULT '',
+        created_at DATETIME NOT NULL,
+        updated_at DATETIME NOT NULL
+    );`
+	if _, err := db.Exec(createJobsTable); err != nil {
+		return err
+	}
+
+	// Создаем таблицу для подписанных голосов, если она не существует
+	createSignedVotesTable := `
+    CREATE TABLE IF NOT EXISTS signed_votes (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        vote_id INTEGER NOT NULL,
+        voter TEXT NOT NULL,
+        choice TEXT NOT NULL,
+        vote_power INTEGER NOT NULL,
+        timestamp INTEGER NOT NULL,
+        signature TEXT NOT NULL,
+        public_key TEXT NOT NULL DEFAULT '',
+        ballot_hash TEXT NOT NULL,
+        created_at DATETIME NOT NULL,
+        UNIQUE (vote_id, voter)
+    );`
+	if _, err := db.Exec(createSignedVotesTable); err != nil {
+		return err
+	}
+
+	// Создаем таблицу для обязательств тайного голосования, если она не существует
+	createVoteCommitmentsTable := `
+    CREATE TABLE IF NOT EXISTS vote_commitments (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        vote_id INTEGER NOT NULL,
+        voter TEXT NOT NULL,
+        commitment TEXT NOT NULL,
+        vote_power INTEGER NOT NULL,
+        choice TEXT NOT NULL DEFAULT '',
+        salt TEXT NOT NULL DEFAULT '',
+        created_at DATETIME NOT NULL,
+        revealed_at DATETIME,
+        UNIQUE (vote_id, voter)
+    );`
+	if _, err := db.Exec(createVoteCommitmentsTable); err != nil {
+		return err
+	}
+
+	// Создаем таблицу журнала выводов средств, если она не существует
+	createWithdrawalsTable := `
+    CREATE TABLE IF NOT EXISTS withdrawals (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        user_id INTEGER NOT NULL,
+        requester TEXT NOT NULL DEFAULT '',
+        amount REAL NOT NULL,
+        address TEXT NOT NULL,
+        transaction_id INTEGER NOT NULL DEFAULT 0,
+        transaction_hash TEXT NOT NULL DEFAULT '',
+        status TEXT NOT NULL,
+        error TEXT NOT NULL DEFAULT '',
+        attempts INTEGER NOT NULL DEFAULT 0,
+        created_at DATETIME NOT NULL,
+        updated_at DATETIME NOT NULL,
+        completed_at DATETIME,
+        approvals_required INTEGER NOT NULL DEFAULT 0,
+        expires_at DATETIME,
+        coin TEXT NOT NULL DEFAULT 'del'
+    );
+    CREATE INDEX IF NOT EXISTS idx_withdrawals_user_id ON withdrawals (user_id);
+    CREATE INDEX IF NOT EXISTS idx_withdrawals_status ON withdrawals (status);
+    CREATE INDEX IF NOT EXISTS idx_withdrawals_created_at ON withdrawals (created_at);`
+	if _, err := db.Exec(createWithdrawalsTable); err != nil {
+		return err
+	}
+
+	// Создаем таблицу ключей идемпотентности, если она не существует
+	createIdempotencyKeysTable := `
+    CREATE TABLE IF NOT EXISTS idempotency_keys (
+        key TEXT PRIMARY KEY,
+        request_hash TEXT NOT NULL,
+        status TEXT NOT NULL,
+        response_status INTEGER NOT NULL DEFAULT 0,
+        response_body BLOB,
+        content_type TEXT NOT NULL DEFAULT '',
+        location TEXT NOT NULL DEFAULT '',
+        created_at DATETIME NOT NULL,
+        expires_at DATETIME NOT NULL
+    );`
+	if _, err := db.Exec(createIdempotencyKeysTable); err != nil {
+		return err
+	}
+
+	// Создаем таблицы политик вывода средств и журнала отказов, если они не существуют
+	createWithdrawalPoliciesTables := `
+    CREATE TABLE IF NOT EXISTS withdrawal_policies (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        type TEXT NOT NULL,
+        amount REAL NOT NULL DEFAULT 0,
+        address TEXT NOT NULL DEFAULT '',
+        exempt_roles TEXT NOT NULL DEFAULT '',
+        enabled BOOLEAN NOT NULL DEFAULT 1,
+        description TEXT NOT NULL DEFAULT '',
+        created_at DATETIME NOT NULL,
+        updated_at DATETIME NOT NULL,
+        coin TEXT NOT NULL DEFAULT ''
+    );
+    CREATE TABLE IF NOT EXISTS withdrawal_policy_rejections (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        user_id INTEGER NOT NULL,
+        requester TEXT NOT NULL DEFAULT '',
+        amount REAL NOT NULL,
+        address TEXT NOT NULL,
+        policy_id INTEGER NOT NULL,
+        policy_type TEXT NOT NULL,
+        reason TEXT NOT NULL,
+        created_at DATETIME NOT NULL,
+        coin TEXT NOT NULL DEFAULT 'del'
+    );`
+	if _, err := db.Exec(createWithdrawalPoliciesTables); err != nil {
+		return err
+	}
+
+	// Создаем таблицу решений администраторов по крупным выводам средств, если она не существует
+	createWithdrawalApprovalsTable := `
+    CREATE TABLE IF NOT EXISTS withdrawal_approvals (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        withdrawal_id INTEGER NOT NULL,
+        admin_id INTEGER NOT NULL,
+        admin_wallet TEXT NOT NULL DEFAULT '',
+        decision TEXT NOT NULL,
+        comment TEXT NOT NULL DEFAULT '',
+        created_at DATETIME NOT NULL,
+        UNIQUE (withdrawal_id, admin_id)
+    );`
+	if _, err := db.Exec(createWithdrawalApprovalsTable); err != nil {
+		return err
+	}
+
+	// Создаем таблицу API ключей сервисного доступа, если она не существует
+	createAPIKeysTable := `
+    CREATE TABLE IF NOT EXISTS api_keys (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        name TEXT NOT NULL,
+        prefix TEXT NOT NULL,
+        key_hash TEXT NOT NULL UNIQUE,
+        scopes TEXT NOT NULL DEFAULT '',
+        allowed_ips TEXT NOT NULL DEFAULT '',
+        wallet TEXT NOT NULL DEFAULT '',
+        created_by INTEGER NOT NULL,
+        created_at DATETIME NOT NULL,
+        expires_at DATETIME,
+        last_used_at DATETIME,
+        last_used_ip TEXT NOT NULL DEFAULT '',
+        revoked_at DATETIME
+    );`
+	if _, err := db.Exec(createAPIKeysTable); err != nil {
+		return err
+	}
+
+	// Создаем таблицы пользователей, их ролей и подписок, если они не существуют
+	createUsersTables := `
+    CREATE TABLE IF NOT EXISTS users (
+        id INTEGER PRIMARY KEY,
+        login TEXT NOT NULL DEFAULT '',
+        email TEXT NOT NULL DEFAULT '',
+        phone TEXT NOT NULL DEFAULT '',
+        nick TEXT NOT NULL DEFAULT '',
+        locale TEXT NOT NULL DEFAULT '',
+        avatar TEXT NOT NULL DEFAULT '',
+        wallet TEXT NOT NULL DEFAULT '',
+        created_at DATETIME NOT NULL,
+        updated_at DATETIME NOT NULL
+    );
+    CREATE INDEX IF NOT EXISTS idx_users_wallet ON users (wallet);
+    CREATE TABLE IF NOT EXISTS user_roles (
+        user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        name TEXT NOT NULL,
+        permissions TEXT NOT NULL DEFAULT '',
+        PRIMARY KEY (user_id, name)
+    );
+    CREATE TABLE IF NOT EXISTS user_subscriptions (
+        user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        id INTEGER NOT NULL,
+        tag TEXT NOT NULL DEFAULT '',
+        plan_id INTEGER NOT NULL DEFAULT 0,
+        name TEXT NOT NULL DEFAULT '',
+        description TEXT NOT NULL DEFAULT '',
+        price REAL NOT NULL DEFAULT 0,
+        currency TEXT NOT NULL DEFAULT '',
+        trial_period INTEGER NOT NULL DEFAULT 0,
+        trial_interval TEXT NOT NULL DEFAULT '',
+        grace_period INTEGER NOT NULL DEFAULT 0,
+        grace_interval TEXT NOT NULL DEFAULT '',
+        invoice_period INTEGER NOT NULL DEFAULT 0,
+        invoice_interval TEXT NOT NULL DEFAULT '',
+        tier INTEGER NOT NULL DEFAULT 0,
+        starts_at TEXT NOT NULL DEFAULT '',
+        ends_at TEXT NOT NULL DEFAULT '',
+        created_at TEXT NOT NULL DEFAULT '',
+        updated_at TEXT NOT NULL DEFAULT '',
+        PRIMARY KEY (user_id, id)
+    );`
+	if _, err := db.Exec(createUsersTables); err != nil {
+		return err
+	}
+
+	// Создаем таблицы одноразовых nonce и сессий входа подписью кошелька, если они не существуют
+	createWalletSessionsTables := `
+    CREATE TABLE IF NOT EXISTS wallet_nonces (
+        nonce TEXT PRIMARY KEY,
+        wallet TEXT NOT NULL,
+        message TEXT NOT NULL,
+        created_at DATETIME NOT NULL,
+        expires_at DATETIME NOT NULL,
+        used_at DATETIME
+    );
+    CREATE TABLE IF NOT EXISTS wallet_sessions (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        wallet TEXT NOT NULL,
+        token_hash TEXT NOT NULL UNIQUE,
+        created_at DATETIME NOT NULL,
+        expires_at DATETIME NOT NULL,
+        last_used_at DATETIME,
+        revoked_at DATETIME
+    );
+    CREATE INDEX IF NOT EXISTS idx_wallet_sessions_wallet ON wallet_sessions (wallet);`
+	if _, err := db.Exec(createWalletSessionsTables); err != nil {
+		return err
+	}
+
+	// Создаем таблицу истории изменений силы голоса, если она не существует
+	createVoteStrengthHistoryTable := `
+    CREATE TABLE IF NOT EXISTS vote_strength_history (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        wallet_address TEXT NOT NULL,
+        old_vote_power INTEGER,
+        new_vote_power INTEGER,
+        actor_id INTEGER NOT NULL DEFAULT 0,
+        actor TEXT NOT NULL DEFAULT '',
+        reason TEXT NOT NULL DEFAULT '',
+        changed_at DATETIME NOT NULL
+    );
+    CREATE INDEX IF NOT EXISTS idx_vote_strength_history_wallet ON vote_strength_history (wallet_address, changed_at);`
+	if _, err := db.Exec(createVoteStrengthHistoryTable); err != nil {
+		return err
+	}
+
+	// Создаем таблицу отчетов синхронизации силы голоса с балансами PRO, если она не существует
+	createVotePowerSyncRunsTable := `
+    CREATE TABLE IF NOT EXISTS vote_power_sync_runs (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        actor_id INTEGER NOT NULL DEFAULT 0,
+        actor TEXT NOT NULL DEFAULT '',
+        applied BOOLEAN NOT NULL DEFAULT FALSE,
+        formula TEXT NOT NULL,
+        checked INTEGER NOT NULL DEFAULT 0,
+        discovered INTEGER NOT NULL DEFAULT 0,
+        added INTEGER NOT NULL DEFAULT 0,
+        removed INTEGER NOT NULL DEFAULT 0,
+        changed INTEGER NOT NULL DEFAULT 0,
+        unchanged INTEGER NOT NULL DEFAULT 0,
+        failed INTEGER NOT NULL DEFAULT 0,
+        changes TEXT NOT NULL DEFAULT '{}',
+        errors TEXT NOT NULL DEFAULT '[]',
+        error TEXT NOT NULL DEFAULT '',
+        started_at DATETIME NOT NULL,
+        finished_at DATETIME NOT NULL
+    );`
+	if _, err := db.Exec(createVotePowerSyncRunsTable); err != nil {
+		return err
+	}
+
+	// Создаем журнал действий администраторов, если он не существует. Триггеры запрещают изменение и удаление записей.
+	createAuditLogTable := `
+    CREATE TABLE IF NOT EXISTS audit_log (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        actor_id INTEGER NOT NULL DEFAULT 0,
+        actor TEXT NOT NULL DEFAULT '',
+        action TEXT NOT NULL,
+        target_type TEXT NOT NULL DEFAULT '',
+        target_id TEXT NOT NULL DEFAULT '',
+        before_value TEXT,
+        after_value TEXT,
+        request_id TEXT NOT NULL DEFAULT '',
+        ip TEXT NOT NULL DEFAULT '',
+        created_at DATETIME NOT NULL
+    );
+    CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log (action, created_at);
+    CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log (target_type, target_id);
+    CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+    BEGIN
+        SELECT RAISE(ABORT, 'audit_log is append-only');
+    END;
+    CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+    BEGIN
+        SELECT RAISE(ABORT, 'audit_log is append-only');
+    END;`
+	if _, err := db.Exec(createAuditLogTable); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -83,41 +420,3 @@ func GetTableNames() ([]string, error) {
 
 	return tableNames, nil
 }
-
-// Функция для получения всех элементов из таблицы по её названию
-func GetTableElements(tableName string) ([]map[string]interface{}, error) {
-	query := fmt.Sprintf("SELECT * FROM %s", tableName)
-	rows, err := db.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	columns, err := rows.Columns()
-	if err != nil {
-		return nil, err
-	}
-
-	var elements []map[string]interface{}
-	for rows.Next() {
-		columnValues := make([]interface{}, len(columns))
-		columnPointers := make([]interface{}, len(columns))
-		for i := range columnValues {
-			columnPointers[i] = &columnValues[i]
-		}
-
-		if err := rows.Scan(columnPointers...); err != nil {
-			return nil, err
-		}
-
-		element := make(map[string]interface{})
-		for i, colName := range columns {
-			val := columnPointers[i].(*interface{})
-			element[colName] = *val
-		}
-
-		elements = append(elements, element)
-	}
-
-	return elements, nil
-}
@@ -0,0 +1,68 @@
+// Package repository Хранилище ключей идемпотентности
+package repository
+
+import (
+	"dao_vote/back-end/models"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrIdempotencyKeyExists возвращается, если ключ идемпотентности уже зарезервирован
+	ErrIdempotencyKeyExists = errors.New("ключ идемпотентности уже существует")
+	// ErrIdempotencyKeyNotFound возвращается, если ключ идемпотентности не найден
+	ErrIdempotencyKeyNotFound = errors.New("ключ идемпотентности не найден")
+)
+
+// ReserveIdempotencyKey резервирует ключ для первого запроса; просроченные ключи удаляются
+func ReserveIdempotencyKey(record models.IdempotencyRecord) error {
+	if _, err := db.Exec("DELETE FROM idempotency_keys WHERE expires_at <= ?", time.Now().UTC()); err != nil {
+		return err
+	}
+
+	_, err := db.Exec("INSERT INTO idempotency_keys (key, request_hash, status, created_at, expires_at) VALUES (?, ?, ?, ?, ?)",
+		record.Key, record.RequestHash, models.IdempotencyInFlight, record.CreatedAt, record.ExpiresAt)
+	if isUniqueViolation(err) {
+		return ErrIdempotencyKeyExists
+	}
+	return err
+}
+
+// GetIdempotencyRecord возвращает запись ключа идемпотентности
+func GetIdempotencyRecord(key string) (models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	err := db.QueryRow(`SELECT key, request_hash, status, response_status, response_body, content_type, location, created_at, expires_at
+		FROM idempotency_keys WHERE key = ?`, key).Scan(
+		&record.Key, &record.RequestHash, &record.Status, &record.ResponseStatus, &record.ResponseBody,
+		&record.ContentType, &record.Location, &record.CreatedAt, &record.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return record, ErrIdempotencyKeyNotFound
+	}
+	return record, err
+}
+
+// CompleteIdempotencyKey сохраняет ответ первого запроса
+func CompleteIdempotencyKey(record models.IdempotencyRecord) error {
+	_, err := db.Exec("UPDATE idempotency_keys SET status = ?, response_status = ?, response_body = ?, content_type = ?, location = ? WHERE key = ?",
+		models.IdempotencyCompleted, record.ResponseStatus, record.ResponseBody, record.ContentType, record.Location, record.Key)
+	return err
+}
+
+// TakeOverIdempotencyKey занимает незавершенный ключ прерванного запроса, начатого в startedAt.
+// Возвращает false, если ключ уже занят другим запросом или завершен.
+func TakeOverIdempotencyKey(key string, startedAt, now, expiresAt time.Time) (bool, error) {
+	res, err := db.Exec("UPDATE idempotency_keys SET created_at = ?, expires_at = ? WHERE key = ? AND status = ? AND created_at = ?",
+		now, expiresAt, key, models.IdempotencyInFlight, startedAt)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected == 1, err
+}
+
+// ReleaseIdempotencyKey удаляет резерв ключа, чтобы запрос можно было повторить
+func ReleaseIdempotencyKey(key string) error {
+	_, err := db.Exec("DELETE FROM idempotency_keys WHERE key = ?", key)
+	return err
+}
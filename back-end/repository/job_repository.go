@@ -0,0 +1,54 @@
+// Package repository Хранилище фоновых задач
+package repository
+
+import (
+	"dao_vote/back-end/models"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrJobNotFound возвращается, если задача не найдена
+var ErrJobNotFound = errors.New("задача не найдена")
+
+// CreateJob сохраняет новую фоновую задачу
+func CreateJob(job models.Job) error {
+	_, err := db.Exec(`INSERT INTO jobs (id, type, status, user_id, vote_id, user_vote_id, transaction_id, transaction_hash, error, attempts, callback_url, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.ID, job.Type, job.Status, job.UserID, job.VoteID, job.UserVoteID, job.TransactionID, job.TransactionHash,
+		job.Error, job.Attempts, job.CallbackURL, job.CreatedAt, job.UpdatedAt)
+	return err
+}
+
+// UpdateJob обновляет состояние фоновой задачи
+func UpdateJob(job models.Job) error {
+	_, err := db.Exec(`UPDATE jobs SET status = ?, transaction_id = ?, transaction_hash = ?, error = ?, attempts = ?, updated_at = ? WHERE id = ?`,
+		job.Status, job.TransactionID, job.TransactionHash, job.Error, job.Attempts, job.UpdatedAt, job.ID)
+	return err
+}
+
+// GetJobByID возвращает фоновую задачу по её ID
+func GetJobByID(id string) (models.Job, error) {
+	var job models.Job
+	err := db.QueryRow(`SELECT id, type, status, user_id, vote_id, user_vote_id, transaction_id, transaction_hash, error, attempts, callback_url, created_at, updated_at
+		FROM jobs WHERE id = ?`, id).Scan(
+		&job.ID, &job.Type, &job.Status, &job.UserID, &job.VoteID, &job.UserVoteID, &job.TransactionID, &job.TransactionHash,
+		&job.Error, &job.Attempts, &job.CallbackURL, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return job, ErrJobNotFound
+		}
+		return job, err
+	}
+	return job, nil
+}
+
+// FailPendingJobs помечает все незавершенные задачи как неуспешные
+func FailPendingJobs(reason string) (int64, error) {
+	result, err := db.Exec("UPDATE jobs SET status = ?, error = ?, updated_at = ? WHERE status = ?",
+		models.JobStatusFailed, reason, time.Now().UTC(), models.JobStatusPending)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
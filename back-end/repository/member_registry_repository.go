@@ -0,0 +1,253 @@
+// Package repository Хранилище реестра участников DAO: выгрузка и атомарное применение импорта
+package repository
+
+import (
+	"dao_vote/back-end/models"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrMemberRegistryChanged возвращается, если реестр изменился между расчетом и применением импорта
+	ErrMemberRegistryChanged = errors.New("реестр участников изменился после расчета разницы")
+	// ErrMemberNotFound возвращается, если кошелек отсутствует в vote_strength
+	ErrMemberNotFound = errors.New("кошелек не найден в реестре участников")
+)
+
+// voteStrengthHistoryColumns перечень колонок таблицы vote_strength_history в порядке сканирования scanVoteStrengthHistory
+const voteStrengthHistoryColumns = "id, wallet_address, old_vote_power, new_vote_power, actor_id, actor, reason, changed_at"
+
+// scanVoteStrengthHistory считывает запись истории силы голоса из строки результата
+func scanVoteStrengthHistory(row rowScanner) (models.VoteStrengthHistoryEntry, error) {
+	var entry models.VoteStrengthHistoryEntry
+	var oldPower, newPower sql.NullInt64
+	err := row.Scan(&entry.ID, &entry.WalletAddress, &oldPower, &newPower, &entry.ActorID, &entry.Actor, &entry.Reason, &entry.ChangedAt)
+	if oldPower.Valid {
+		value := int(oldPower.Int64)
+		entry.OldVotePower = &value
+	}
+	if newPower.Valid {
+		value := int(newPower.Int64)
+		entry.NewVotePower = &value
+	}
+	return entry, err
+}
+
+// recordVoteStrengthChange записывает изменение силы голоса в историю; nil означает отсутствие кошелька в реестре
+func recordVoteStrengthChange(tx *sql.Tx, wallet string, oldPower, newPower *int, meta models.VoteStrengthChangeMeta, changedAt time.Time) error {
+	_, err := tx.Exec(`INSERT INTO vote_strength_history (wallet_address, old_vote_power, new_vote_power, actor_id, actor, reason, changed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, wallet, oldPower, newPower, meta.ActorID, meta.Actor, meta.Reason, changedAt.UTC())
+	return err
+}
+
+// AddMember добавляет кошелек в реестр участников и записывает изменение в историю
+func AddMember(member models.Member, meta models.VoteStrengthChangeMeta) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT INTO vote_strength (wallet_address, vote_power) VALUES (?, ?)", member.WalletAddress, member.VotePower); err != nil {
+		return err
+	}
+	if err := recordVoteStrengthChange(tx, member.WalletAddress, nil, &member.VotePower, meta, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveMember удаляет кошелек из реестра участников и записывает изменение в историю.
+// Для отсутствующего кошелька возвращается ErrMemberNotFound.
+func RemoveMember(wallet string, meta models.VoteStrengthChangeMeta) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldPower int
+	err = tx.QueryRow("SELECT vote_power FROM vote_strength WHERE wallet_address = ?", wallet).Scan(&oldPower)
+	if err == sql.ErrNoRows {
+		return ErrMemberNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM vote_strength WHERE wallet_address = ?", wallet); err != nil {
+		return err
+	}
+	if err := recordVoteStrengthChange(tx, wallet, &oldPower, nil, meta, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateMemberPower изменяет силу голоса кошелька и возвращает запись истории.
+// Если сила голоса не изменилась, история не пополняется и возвращается пустая запись.
+func UpdateMemberPower(wallet string, votePower int, meta models.VoteStrengthChangeMeta) (models.VoteStrengthHistoryEntry, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return models.VoteStrengthHistoryEntry{}, err
+	}
+	defer tx.Rollback()
+
+	var oldPower int
+	err = tx.QueryRow("SELECT vote_power FROM vote_strength WHERE wallet_address = ?", wallet).Scan(&oldPower)
+	if err == sql.ErrNoRows {
+		return models.VoteStrengthHistoryEntry{}, ErrMemberNotFound
+	}
+	if err != nil {
+		return models.VoteStrengthHistoryEntry{}, err
+	}
+	if oldPower == votePower {
+		return models.VoteStrengthHistoryEntry{}, nil
+	}
+
+	if _, err := tx.Exec("UPDATE vote_strength SET vote_power = ? WHERE wallet_address = ?", votePower, wallet); err != nil {
+		return models.VoteStrengthHistoryEntry{}, err
+	}
+	changedAt := time.Now().UTC()
+	if err := recordVoteStrengthChange(tx, wallet, &oldPower, &votePower, meta, changedAt); err != nil {
+		return models.VoteStrengthHistoryEntry{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.VoteStrengthHistoryEntry{}, err
+	}
+	return models.VoteStrengthHistoryEntry{
+		WalletAddress: wallet,
+		OldVotePower:  &oldPower,
+		NewVotePower:  &votePower,
+		ActorID:       meta.ActorID,
+		Actor:         meta.Actor,
+		Reason:        meta.Reason,
+		ChangedAt:     changedAt,
+	}, nil
+}
+
+// GetVoteStrengthHistory возвращает историю изменений силы голоса кошелька от новых к старым
+func GetVoteStrengthHistory(wallet string) ([]models.VoteStrengthHistoryEntry, error) {
+	rows, err := db.Query("SELECT "+voteStrengthHistoryColumns+" FROM vote_strength_history WHERE wallet_address = ? ORDER BY changed_at DESC, id DESC", wallet)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.VoteStrengthHistoryEntry{}
+	for rows.Next() {
+		entry, err := scanVoteStrengthHistory(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// GetVoteStrengthAsOf возвращает силу голоса кошелька на момент asOf по истории изменений.
+// Для кошелька, добавленного до ведения истории, используется значение до первого изменения
+// после asOf, а без изменений - текущее значение vote_strength.
+func GetVoteStrengthAsOf(wallet string, asOf time.Time) (int, bool, error) {
+	var power sql.NullInt64
+	err := db.QueryRow(`SELECT new_vote_power FROM vote_strength_history WHERE wallet_address = ? AND changed_at <= ?
+		ORDER BY changed_at DESC, id DESC LIMIT 1`, wallet, asOf.UTC()).Scan(&power)
+	if err == nil {
+		return int(power.Int64), power.Valid, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, false, err
+	}
+
+	err = db.QueryRow(`SELECT old_vote_power FROM vote_strength_history WHERE wallet_address = ? AND changed_at > ?
+		ORDER BY changed_at, id LIMIT 1`, wallet, asOf.UTC()).Scan(&power)
+	if err == nil {
+		return int(power.Int64), power.Valid, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, false, err
+	}
+
+	err = db.QueryRow("SELECT vote_power FROM vote_strength WHERE wallet_address = ?", wallet).Scan(&power)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	return int(power.Int64), err == nil, err
+}
+
+// ListMembers возвращает все записи vote_strength, упорядоченные по адресу кошелька
+func ListMembers() ([]models.Member, error) {
+	rows, err := db.Query("SELECT wallet_address, vote_power FROM vote_strength ORDER BY wallet_address")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []models.Member{}
+	for rows.Next() {
+		var member models.Member
+		var wallet sql.NullString
+		var votePower sql.NullInt64
+		if err := rows.Scan(&wallet, &votePower); err != nil {
+			return nil, err
+		}
+		member.WalletAddress, member.VotePower = wallet.String, int(votePower.Int64)
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+// ApplyMemberDiff применяет разницу импорта и записывает изменения в историю в одной транзакции.
+// Изменение и удаление выполняются только при совпадении текущей силы голоса; если реестр уже
+// изменился, транзакция откатывается с ErrMemberRegistryChanged.
+func ApplyMemberDiff(diff models.MemberImportDiff, meta models.VoteStrengthChangeMeta) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	changedAt := time.Now()
+
+	for _, member := range diff.Removed {
+		result, err := tx.Exec("DELETE FROM vote_strength WHERE wallet_address = ? AND vote_power = ?", member.WalletAddress, member.VotePower)
+		if err != nil {
+			return err
+		}
+		if err := requireAffected(result, ErrMemberRegistryChanged); err != nil {
+			return err
+		}
+		oldPower := member.VotePower
+		if err := recordVoteStrengthChange(tx, member.WalletAddress, &oldPower, nil, meta, changedAt); err != nil {
+			return err
+		}
+	}
+	for _, change := range diff.Changed {
+		result, err := tx.Exec("UPDATE vote_strength SET vote_power = ? WHERE wallet_address = ? AND vote_power = ?",
+			change.NewVotePower, change.WalletAddress, change.OldVotePower)
+		if err != nil {
+			return err
+		}
+		if err := requireAffected(result, ErrMemberRegistryChanged); err != nil {
+			return err
+		}
+		oldPower, newPower := change.OldVotePower, change.NewVotePower
+		if err := recordVoteStrengthChange(tx, change.WalletAddress, &oldPower, &newPower, meta, changedAt); err != nil {
+			return err
+		}
+	}
+	for _, member := range diff.Added {
+		if _, err := tx.Exec("INSERT INTO vote_strength (wallet_address, vote_power) VALUES (?, ?)", member.WalletAddress, member.VotePower); err != nil {
+			if isUniqueViolation(err) {
+				return ErrMemberRegistryChanged
+			}
+			return err
+		}
+		newPower := member.VotePower
+		if err := recordVoteStrengthChange(tx, member.WalletAddress, nil, &newPower, meta, changedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
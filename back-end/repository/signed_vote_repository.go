@@ -0,0 +1,79 @@
+// Package repository Хранилище подписанных голосов
+package repository
+
+import (
+	"dao_vote/back-end/models"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrSignedVoteNotFound возвращается, если подписанный голос не найден
+var ErrSignedVoteNotFound = errors.New("подписанный голос не найден")
+
+// signedVoteColumns перечень колонок таблицы signed_votes в порядке сканирования scanSignedVote
+const signedVoteColumns = "id, vote_id, voter, choice, vote_power, timestamp, signature, public_key, ballot_hash, created_at"
+
+// scanSignedVote считывает подписанный голос из строки результата
+func scanSignedVote(row rowScanner) (models.SignedVote, error) {
+	var vote models.SignedVote
+	err := row.Scan(&vote.ID, &vote.VoteID, &vote.Voter, &vote.Choice, &vote.VotePower, &vote.Timestamp,
+		&vote.Signature, &vote.PublicKey, &vote.BallotHash, &vote.CreatedAt)
+	return vote, err
+}
+
+// AddSignedVote сохраняет подписанный голос
+func AddSignedVote(vote models.SignedVote) (int, error) {
+	result, err := db.Exec("INSERT INTO signed_votes (vote_id, voter, choice, vote_power, timestamp, signature, public_key, ballot_hash, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		vote.VoteID, vote.Voter, vote.Choice, vote.VotePower, vote.Timestamp, vote.Signature, vote.PublicKey, vote.BallotHash, time.Now().UTC())
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, ErrUserVoteExists
+		}
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// ReplaceSignedVote заменяет ранее поданный подписанный голос кошелька
+func ReplaceSignedVote(vote models.SignedVote) error {
+	_, err := db.Exec("UPDATE signed_votes SET choice = ?, vote_power = ?, timestamp = ?, signature = ?, public_key = ?, ballot_hash = ?, created_at = ? WHERE vote_id = ? AND voter = ?",
+		vote.Choice, vote.VotePower, vote.Timestamp, vote.Signature, vote.PublicKey, vote.BallotHash, time.Now().UTC(), vote.VoteID, vote.Voter)
+	return err
+}
+
+// GetSignedVoteByVoter возвращает подписанный голос кошелька в голосовании
+func GetSignedVoteByVoter(voteID int, voter string) (models.SignedVote, error) {
+	vote, err := scanSignedVote(db.QueryRow("SELECT "+signedVoteColumns+" FROM signed_votes WHERE vote_id = ? AND voter = ?", voteID, voter))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return vote, ErrSignedVoteNotFound
+		}
+		return vote, err
+	}
+	return vote, nil
+}
+
+// GetSignedVotes возвращает все подписанные голоса голосования в порядке подачи
+func GetSignedVotes(voteID int) ([]models.SignedVote, error) {
+	rows, err := db.Query("SELECT "+signedVoteColumns+" FROM signed_votes WHERE vote_id = ? ORDER BY id", voteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var votes []models.SignedVote
+	for rows.Next() {
+		vote, err := scanSignedVote(rows)
+		if err != nil {
+			return nil, err
+		}
+		votes = append(votes, vote)
+	}
+	return votes, rows.Err()
+}
@@ -0,0 +1,135 @@
+// Package repository Выборка строк таблиц для просмотра данных параметризованными запросами
+package repository
+
+import (
+	"dao_vote/back-end/models"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// identifierPattern допустимое название таблицы или колонки
+var identifierPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// quoteIdentifier возвращает название в кавычках или ошибку, если название недопустимо
+func quoteIdentifier(name string) (string, error) {
+	if !identifierPattern.MatchString(name) {
+		return "", fmt.Errorf("недопустимое название %q", name)
+	}
+	return `"` + name + `"`, nil
+}
+
+// SelectTableRows возвращает страницу строк таблицы и курсор последней строки, если есть следующая страница.
+// Значения фильтров и курсора передаются параметрами запроса; названия дополнительно проверяются по шаблону.
+func SelectTableRows(sel models.TableSelect) ([]map[string]interface{}, *models.TableCursor, error) {
+	table, err := quoteIdentifier(sel.Table)
+	if err != nil {
+		return nil, nil, err
+	}
+	sortExpr := "rowid"
+	if sel.SortColumn != "" {
+		if sortExpr, err = quoteIdentifier(sel.SortColumn); err != nil {
+			return nil, nil, err
+		}
+	}
+	// Унарный плюс убирает тип колонки, чтобы значение курсора считывалось без преобразования
+	selected := []string{"rowid", "+" + sortExpr}
+	for _, column := range sel.Columns {
+		quoted, err := quoteIdentifier(column)
+		if err != nil {
+			return nil, nil, err
+		}
+		selected = append(selected, quoted)
+	}
+
+	var conditions []string
+	var args []interface{}
+	for _, filter := range sel.Filters {
+		quoted, err := quoteIdentifier(filter.Column)
+		if err != nil {
+			return nil, nil, err
+		}
+		conditions = append(conditions, quoted+" = ?")
+		args = append(args, filter.Value)
+	}
+	if sel.After != nil {
+		condition, cursorArgs := cursorCondition(sortExpr, sel.SortColumn != "", sel.Desc, sel.After)
+		conditions = append(conditions, condition)
+		args = append(args, cursorArgs...)
+	}
+
+	direction := "ASC"
+	if sel.Desc {
+		direction = "DESC"
+	}
+	query := "SELECT " + strings.Join(selected, ", ") + " FROM " + table
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, rowid %s LIMIT ? OFFSET ?", sortExpr, direction, direction)
+	args = append(args, sel.Limit+1, sel.Offset)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	elements := []map[string]interface{}{}
+	var cursors []models.TableCursor
+	for rows.Next() {
+		var cursor models.TableCursor
+		values := make([]interface{}, len(sel.Columns))
+		pointers := []interface{}{&cursor.RowID, &cursor.Sort}
+		for i := range values {
+			pointers = append(pointers, &values[i])
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, nil, err
+		}
+
+		element := make(map[string]interface{}, len(sel.Columns))
+		for i, column := range sel.Columns {
+			if value, ok := values[i].([]byte); ok {
+				values[i] = string(value)
+			}
+			element[column] = values[i]
+		}
+		elements = append(elements, element)
+		cursors = append(cursors, cursor)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(elements) <= sel.Limit {
+		return elements, nil, nil
+	}
+	next := cursors[sel.Limit-1]
+	if value, ok := next.Sort.([]byte); ok {
+		next.Sort = string(value)
+	}
+	return elements[:sel.Limit], &next, nil
+}
+
+// cursorCondition возвращает условие строк после курсора с учетом порядка NULL в SQLite:
+// при сортировке по возрастанию NULL идут первыми, по убыванию - последними
+func cursorCondition(sortExpr string, sorted, desc bool, after *models.TableCursor) (string, []interface{}) {
+	if !sorted {
+		if desc {
+			return "rowid < ?", []interface{}{after.RowID}
+		}
+		return "rowid > ?", []interface{}{after.RowID}
+	}
+	switch {
+	case after.Sort == nil && !desc:
+		return fmt.Sprintf("((%s IS NULL AND rowid > ?) OR %s IS NOT NULL)", sortExpr, sortExpr), []interface{}{after.RowID}
+	case after.Sort == nil && desc:
+		return fmt.Sprintf("(%s IS NULL AND rowid < ?)", sortExpr), []interface{}{after.RowID}
+	case !desc:
+		return fmt.Sprintf("(%s > ? OR (%s = ? AND rowid > ?))", sortExpr, sortExpr), []interface{}{after.Sort, after.Sort, after.RowID}
+	default:
+		return fmt.Sprintf("(%s < ? OR (%s = ? AND rowid < ?) OR %s IS NULL)", sortExpr, sortExpr, sortExpr),
+			[]interface{}{after.Sort, after.Sort, after.RowID}
+	}
+}
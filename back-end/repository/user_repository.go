@@ -2,64 +2,208 @@
 package repository
 
 import (
+	"database/sql"
 	"errors"
-	"sync"
+	"strings"
+	"time"
 )
 
+// ErrUserNotFound возвращается, если пользователь не найден
+var ErrUserNotFound = errors.New("пользователь не найден")
+
+// User представляет сохраненного пользователя поставщика удостоверений
 type User struct {
-	ID            int
-	Login         string
-	Email         string
-	Phone         string
-	Nick          string
-	Locale        string
-	Avatar        string
-	Wallet        string
-	Roles         []string
-	Permissions   []string
-	Subscriptions []Subscription
+	ID            int            `json:"id"`
+	Login         string         `json:"login"`
+	Email         string         `json:"email"`
+	Phone         string         `json:"phone"`
+	Nick          string         `json:"nick"`
+	Locale        string         `json:"locale"`
+	Avatar        string         `json:"avatar"`
+	Wallet        string         `json:"wallet"`
+	Roles         []UserRole     `json:"roles"`
+	Subscriptions []Subscription `json:"subscriptions"`
+	CreatedAt     time.Time      `json:"created_at"` // Время первого сохранения
+	UpdatedAt     time.Time      `json:"updated_at"` // Время последнего обновления от поставщика удостоверений
+	VoteStrength  *VoteStrength  `json:"vote_strength"`
+}
+
+// UserRole представляет роль пользователя с разрешениями от поставщика удостоверений
+type UserRole struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+// VoteStrength представляет участие кошелька пользователя в DAO по таблице vote_strength
+type VoteStrength struct {
+	ID            int    `json:"id"`
+	WalletAddress string `json:"wallet_address"`
+	VotePower     int    `json:"vote_power"`
 }
 
 type Subscription struct {
-	ID              int
-	Tag             string
-	PlanID          int
-	Name            string
-	Description     string
-	Price           float64
-	Currency        string
-	TrialPeriod     int
-	TrialInterval   string
-	GracePeriod     int
-	GraceInterval   string
-	InvoicePeriod   int
-	InvoiceInterval string
-	Tier            int
-	StartsAt        string
-	EndsAt          string
-	CreatedAt       string
-	UpdatedAt       string
+	ID              int     `json:"id"`
+	Tag             string  `json:"tag"`
+	PlanID          int     `json:"plan_id"`
+	Name            string  `json:"name"`
+	Description     string  `json:"description"`
+	Price           float64 `json:"price"`
+	Currency        string  `json:"currency"`
+	TrialPeriod     int     `json:"trial_period"`
+	TrialInterval   string  `json:"trial_interval"`
+	GracePeriod     int     `json:"grace_period"`
+	GraceInterval   string  `json:"grace_interval"`
+	InvoicePeriod   int     `json:"invoice_period"`
+	InvoiceInterval string  `json:"invoice_interval"`
+	Tier            int     `json:"tier"`
+	StartsAt        string  `json:"starts_at"`
+	EndsAt          string  `json:"ends_at"`
+	CreatedAt       string  `json:"created_at"`
+	UpdatedAt       string  `json:"updated_at"`
 }
 
-var (
-	userStore = make(map[int]User)
-	userMutex = sync.Mutex{}
-)
+// userColumns перечень колонок пользователя и его записи vote_strength в порядке сканирования scanUser
+const userColumns = `u.id, u.login, u.email, u.phone, u.nick, u.locale, u.avatar, u.wallet, u.created_at, u.updated_at,
+	vs.id, vs.wallet_address, vs.vote_power`
+
+// userFrom источник выборки пользователей с привязкой к vote_strength по кошельку
+const userFrom = " FROM users u LEFT JOIN vote_strength vs ON vs.wallet_address = u.wallet AND u.wallet != ''"
+
+// SaveUser сохраняет пользователя вместе с ролями и подписками; роли и подписки заменяются полностью
+func SaveUser(user User) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-// SaveUser сохраняет информацию о пользователе в хранилище
-func SaveUser(user User) {
-	userMutex.Lock()
-	defer userMutex.Unlock()
-	userStore[user.ID] = user
+	now := time.Now().UTC()
+	if _, err := tx.Exec(`INSERT INTO users (id, login, email, phone, nick, locale, avatar, wallet, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET login = excluded.login, email = excluded.email, phone = excluded.phone,
+			nick = excluded.nick, locale = excluded.locale, avatar = excluded.avatar, wallet = excluded.wallet,
+			updated_at = excluded.updated_at`,
+		user.ID, user.Login, user.Email, user.Phone, user.Nick, user.Locale, user.Avatar, user.Wallet, now, now); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM user_roles WHERE user_id = ?", user.ID); err != nil {
+		return err
+	}
+	for _, role := range user.Roles {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO user_roles (user_id, name, permissions) VALUES (?, ?, ?)",
+			user.ID, role.Name, strings.Join(role.Permissions, ",")); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM user_subscriptions WHERE user_id = ?", user.ID); err != nil {
+		return err
+	}
+	for _, sub := range user.Subscriptions {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO user_subscriptions (user_id, id, tag, plan_id, name, description, price, currency,
+			trial_period, trial_interval, grace_period, grace_interval, invoice_period, invoice_interval, tier, starts_at, ends_at,
+			created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			user.ID, sub.ID, sub.Tag, sub.PlanID, sub.Name, sub.Description, sub.Price, sub.Currency,
+			sub.TrialPeriod, sub.TrialInterval, sub.GracePeriod, sub.GraceInterval, sub.InvoicePeriod, sub.InvoiceInterval,
+			sub.Tier, sub.StartsAt, sub.EndsAt, sub.CreatedAt, sub.UpdatedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
-// GetUserByID возвращает информацию о пользователе по его ID
+// GetUserByID возвращает пользователя по ID вместе с ролями, подписками и записью vote_strength
 func GetUserByID(id int) (User, error) {
-	userMutex.Lock()
-	defer userMutex.Unlock()
-	user, exists := userStore[id]
-	if !exists {
-		return User{}, errors.New("user not found")
+	user, err := scanUser(db.QueryRow("SELECT "+userColumns+userFrom+" WHERE u.id = ?", id))
+	if err == sql.ErrNoRows {
+		return user, ErrUserNotFound
+	}
+	if err != nil {
+		return user, err
+	}
+	return user, loadUserDetails(&user)
+}
+
+// GetUsersByWallet возвращает пользователей с указанным кошельком
+func GetUsersByWallet(wallet string) ([]User, error) {
+	rows, err := db.Query("SELECT "+userColumns+userFrom+" WHERE u.wallet = ? ORDER BY u.id", wallet)
+	if err != nil {
+		return nil, err
+	}
+	users := []User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range users {
+		if err := loadUserDetails(&users[i]); err != nil {
+			return nil, err
+		}
+	}
+	return users, nil
+}
+
+// scanUser считывает пользователя и его запись vote_strength из строки результата
+func scanUser(row rowScanner) (User, error) {
+	var user User
+	var strengthID, votePower sql.NullInt64
+	var strengthWallet sql.NullString
+	err := row.Scan(&user.ID, &user.Login, &user.Email, &user.Phone, &user.Nick, &user.Locale, &user.Avatar, &user.Wallet,
+		&user.CreatedAt, &user.UpdatedAt, &strengthID, &strengthWallet, &votePower)
+	if strengthID.Valid {
+		user.VoteStrength = &VoteStrength{ID: int(strengthID.Int64), WalletAddress: strengthWallet.String, VotePower: int(votePower.Int64)}
+	}
+	return user, err
+}
+
+// loadUserDetails загружает роли и подписки пользователя
+func loadUserDetails(user *User) error {
+	user.Roles = []UserRole{}
+	rows, err := db.Query("SELECT name, permissions FROM user_roles WHERE user_id = ? ORDER BY name", user.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var role UserRole
+		var permissions string
+		if err := rows.Scan(&role.Name, &permissions); err != nil {
+			return err
+		}
+		role.Permissions = splitRoles(permissions)
+		user.Roles = append(user.Roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	user.Subscriptions = []Subscription{}
+	subRows, err := db.Query(`SELECT id, tag, plan_id, name, description, price, currency, trial_period, trial_interval,
+		grace_period, grace_interval, invoice_period, invoice_interval, tier, starts_at, ends_at, created_at, updated_at
+		FROM user_subscriptions WHERE user_id = ? ORDER BY id`, user.ID)
+	if err != nil {
+		return err
+	}
+	defer subRows.Close()
+	for subRows.Next() {
+		var sub Subscription
+		if err := subRows.Scan(&sub.ID, &sub.Tag, &sub.PlanID, &sub.Name, &sub.Description, &sub.Price, &sub.Currency,
+			&sub.TrialPeriod, &sub.TrialInterval, &sub.GracePeriod, &sub.GraceInterval, &sub.InvoicePeriod, &sub.InvoiceInterval,
+			&sub.Tier, &sub.StartsAt, &sub.EndsAt, &sub.CreatedAt, &sub.UpdatedAt); err != nil {
+			return err
+		}
+		user.Subscriptions = append(user.Subscriptions, sub)
 	}
-	return user, nil
+	return subRows.Err()
 }
@@ -0,0 +1,96 @@
+// Package repository Хранилище отчетов синхронизации силы голоса с балансами PRO
+package repository
+
+import (
+	"dao_vote/back-end/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+)
+
+// ErrVotePowerSyncRunNotFound возвращается, если отчет синхронизации не найден
+var ErrVotePowerSyncRunNotFound = errors.New("отчет синхронизации силы голоса не найден")
+
+// votePowerSyncRunColumns перечень колонок сводки запуска в порядке сканирования scanVotePowerSyncRun
+const votePowerSyncRunColumns = "id, actor_id, actor, applied, formula, checked, discovered, added, removed, changed, unchanged, failed, error, started_at, finished_at"
+
+// scanVotePowerSyncRun считывает сводку запуска синхронизации из строки результата
+func scanVotePowerSyncRun(row rowScanner) (models.VotePowerSyncRun, error) {
+	var run models.VotePowerSyncRun
+	var formula string
+	err := row.Scan(&run.ID, &run.ActorID, &run.Actor, &run.Applied, &formula, &run.Checked, &run.Discovered,
+		&run.Added, &run.Removed, &run.Changed, &run.Unchanged, &run.Failed, &run.Error, &run.StartedAt, &run.FinishedAt)
+	if err != nil {
+		return run, err
+	}
+	return run, json.Unmarshal([]byte(formula), &run.Formula)
+}
+
+// SaveVotePowerSyncRun сохраняет отчет запуска синхронизации и возвращает его ID
+func SaveVotePowerSyncRun(run models.VotePowerSyncRun) (int, error) {
+	formula, err := json.Marshal(run.Formula)
+	if err != nil {
+		return 0, err
+	}
+	changes, err := json.Marshal(run.Changes)
+	if err != nil {
+		return 0, err
+	}
+	syncErrors, err := json.Marshal(run.Errors)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := db.Exec(`INSERT INTO vote_power_sync_runs (actor_id, actor, applied, formula, checked, discovered, added, removed,
+		changed, unchanged, failed, changes, errors, error, started_at, finished_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.ActorID, run.Actor, run.Applied, string(formula), run.Checked, run.Discovered, run.Added, run.Removed,
+		run.Changed, run.Unchanged, run.Failed, string(changes), string(syncErrors), run.Error, run.StartedAt.UTC(), run.FinishedAt.UTC())
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// ListVotePowerSyncRuns возвращает сводки запусков синхронизации, начиная с последних
+func ListVotePowerSyncRuns(limit, offset int) ([]models.VotePowerSyncRun, error) {
+	rows, err := db.Query("SELECT "+votePowerSyncRunColumns+" FROM vote_power_sync_runs ORDER BY id DESC LIMIT ? OFFSET ?", limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []models.VotePowerSyncRun{}
+	for rows.Next() {
+		run, err := scanVotePowerSyncRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// GetVotePowerSyncRun возвращает отчет запуска синхронизации с изменениями и ошибками по кошелькам
+func GetVotePowerSyncRun(id int) (models.VotePowerSyncRun, error) {
+	var changes, syncErrors string
+	run, err := scanVotePowerSyncRun(db.QueryRow("SELECT "+votePowerSyncRunColumns+" FROM vote_power_sync_runs WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return run, ErrVotePowerSyncRunNotFound
+	}
+	if err != nil {
+		return run, err
+	}
+
+	if err := db.QueryRow("SELECT changes, errors FROM vote_power_sync_runs WHERE id = ?", id).Scan(&changes, &syncErrors); err != nil {
+		return run, err
+	}
+	if err := json.Unmarshal([]byte(changes), &run.Changes); err != nil {
+		return run, err
+	}
+	if err := json.Unmarshal([]byte(syncErrors), &run.Errors); err != nil {
+		return run, err
+	}
+	return run, nil
+}
@@ -5,12 +5,21 @@ import (
 	"dao_vote/back-end/models"
 	"database/sql"
 	"errors"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
+	"strings"
+	"time"
 )
 
 // Глобальная переменная для базы данных
 var db *sql.DB
 
+var (
+	// ErrUserVoteNotFound возвращается, если голос пользователя не найден
+	ErrUserVoteNotFound = errors.New("голос пользователя не найден")
+	// ErrUserVoteExists возвращается при попытке повторно сохранить голос того же кошелька
+	ErrUserVoteExists = errors.New("кошелек уже проголосовал")
+)
+
 // Карта силы голосов для различных кошельков
 var voteMap = map[string]int{}
 
@@ -27,16 +36,14 @@ func GetVoteStrength(walletAddress string) (int, error) {
 	return votePower, nil
 }
 
-// AddWalletStrength добавляет новый адрес кошелька и силу голоса в базу данных
+// AddWalletStrength добавляет новый адрес кошелька и силу голоса в базу данных от имени системы
 func AddWalletStrength(walletAddress string, votePower int) error {
-	_, err := db.Exec("INSERT INTO vote_strength (wallet_address, vote_power) VALUES (?, ?)", walletAddress, votePower)
-	return err
+	return AddMember(models.Member{WalletAddress: walletAddress, VotePower: votePower}, models.VoteStrengthChangeMeta{})
 }
 
-// DeleteWalletStrength удаляет адрес кошелька и силу голоса из базы данных
+// DeleteWalletStrength удаляет адрес кошелька и силу голоса из базы данных от имени системы
 func DeleteWalletStrength(walletAddress string) error {
-	_, err := db.Exec("DELETE FROM vote_strength WHERE wallet_address = ?", walletAddress)
-	return err
+	return RemoveMember(walletAddress, models.VoteStrengthChangeMeta{})
 }
 
 // GetVoteMap возвращает карту всех голосов
@@ -55,8 +62,18 @@ func GetTotalVoices() int {
 
 // SaveVote сохраняет новое пользовательское голосование
 func SaveVote(vote models.VoteInfo) (int, error) {
-	result, err := db.Exec("INSERT INTO votes (title, subtitle, description, voter, choice, vote_power, wallet_address) VALUES (?, ?, ?, ?, ?, ?, ?)",
-		vote.Title, vote.Subtitle, vote.Description, vote.Voter, vote.Choice, vote.VotePower, vote.WalletAddress)
+	if vote.VoteChangePolicy == "" {
+		vote.VoteChangePolicy = models.VoteChangeReject
+	}
+	if vote.VoteMode == "" {
+		vote.VoteMode = models.VoteModeOnChain
+	}
+	if vote.BallotType == "" {
+		vote.BallotType = models.BallotOpen
+	}
+	result, err := db.Exec("INSERT INTO votes (title, subtitle, description, voter, choice, vote_power, wallet_address, vote_change_policy, vote_mode, ballot_type, commit_ends_at, reveal_ends_at, vote_coin, min_vote_amount, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		vote.Title, vote.Subtitle, vote.Description, vote.Voter, vote.Choice, vote.VotePower, vote.WalletAddress, vote.VoteChangePolicy, vote.VoteMode,
+		vote.BallotType, vote.CommitEndsAt, vote.RevealEndsAt, vote.VoteCoin, vote.MinVoteAmount, time.Now().UTC())
 	if err != nil {
 		return 0, err
 	}
@@ -69,11 +86,33 @@ func SaveVote(vote models.VoteInfo) (int, error) {
 	return int(id), nil
 }
 
+// voteColumns перечень колонок таблицы votes в порядке сканирования scanVote
+const voteColumns = "id, title, subtitle, description, voter, choice, vote_power, wallet_address, vote_change_policy, vote_mode, ballot_type, commit_ends_at, reveal_ends_at, vote_coin, min_vote_amount, created_at"
+
+// scanVote считывает голосование из строки результата
+func scanVote(row rowScanner) (models.VoteInfo, error) {
+	var vote models.VoteInfo
+	var commitEndsAt, revealEndsAt, createdAt sql.NullTime
+	err := row.Scan(&vote.ID, &vote.Title, &vote.Subtitle, &vote.Description, &vote.Voter, &vote.Choice, &vote.VotePower, &vote.WalletAddress,
+		&vote.VoteChangePolicy, &vote.VoteMode, &vote.BallotType, &commitEndsAt, &revealEndsAt, &vote.VoteCoin, &vote.MinVoteAmount, &createdAt)
+	if err != nil {
+		return vote, err
+	}
+	if commitEndsAt.Valid {
+		vote.CommitEndsAt = &commitEndsAt.Time
+	}
+	if revealEndsAt.Valid {
+		vote.RevealEndsAt = &revealEndsAt.Time
+	}
+	if createdAt.Valid {
+		vote.CreatedAt = &createdAt.Time
+	}
+	return vote, nil
+}
+
 // GetVoteByID возвращает пользовательское голосование по его ID
 func GetVoteByID(id int) (models.VoteInfo, error) {
-	var vote models.VoteInfo
-	err := db.QueryRow("SELECT id, title, subtitle, description, voter, choice, vote_power, wallet_address FROM votes WHERE id = ?", id).Scan(
-		&vote.ID, &vote.Title, &vote.Subtitle, &vote.Description, &vote.Voter, &vote.Choice, &vote.VotePower, &vote.WalletAddress)
+	vote, err := scanVote(db.QueryRow("SELECT "+voteColumns+" FROM votes WHERE id = ?", id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return vote, errors.New("голосование не найдено")
@@ -83,6 +122,38 @@ func GetVoteByID(id int) (models.VoteInfo, error) {
 	return vote, nil
 }
 
+// ListVotes возвращает до limit голосований с ID больше afterID, созданных в периоде [from, to).
+// Голосования с неизвестным временем создания (созданные до миграции 0020) в период не попадают.
+func ListVotes(from, to *time.Time, afterID, limit int) ([]models.VoteInfo, error) {
+	conditions := []string{"id > ?"}
+	args := []interface{}{afterID}
+	if from != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, from.UTC())
+	}
+	if to != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, to.UTC())
+	}
+	args = append(args, limit)
+
+	rows, err := db.Query("SELECT "+voteColumns+" FROM votes WHERE "+strings.Join(conditions, " AND ")+" ORDER BY id LIMIT ?", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	votes := []models.VoteInfo{}
+	for rows.Next() {
+		vote, err := scanVote(rows)
+		if err != nil {
+			return nil, err
+		}
+		votes = append(votes, vote)
+	}
+	return votes, rows.Err()
+}
+
 // DeleteVote удаляет пользовательское голосование по его ID
 func DeleteVote(id int) error {
 	_, err := db.Exec("DELETE FROM votes WHERE id = ?", id)
@@ -92,11 +163,31 @@ func DeleteVote(id int) error {
 	return nil
 }
 
+// userVoteColumns перечень колонок таблицы user_votes в порядке сканирования scanUserVote
+const userVoteColumns = "id, vote_id, voter, choice, vote_power, transaction_id, transaction_hash, status, created_at, updated_at"
+
+// rowScanner общий интерфейс для *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanUserVote считывает голос пользователя из строки результата
+func scanUserVote(row rowScanner) (models.UserVote, error) {
+	var vote models.UserVote
+	err := row.Scan(&vote.VoterID, &vote.VoteID, &vote.Voter, &vote.Choice, &vote.VotePower,
+		&vote.TransactionID, &vote.TransactionHash, &vote.Status, &vote.CreatedAt, &vote.UpdatedAt)
+	return vote, err
+}
+
 // AddUserVote сохраняет новый голос пользователя
 func AddUserVote(vote models.UserVote) (int, error) {
-	result, err := db.Exec("INSERT INTO user_votes (vote_id, voter, choice, vote_power) VALUES (?, ?, ?, ?)",
-		vote.VoteID, vote.Voter, vote.Choice, vote.VotePower)
+	now := time.Now().UTC()
+	result, err := db.Exec("INSERT INTO user_votes (vote_id, voter, choice, vote_power, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		vote.VoteID, vote.Voter, vote.Choice, vote.VotePower, models.ReceiptStatusPending, now, now)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, ErrUserVoteExists
+		}
 		return 0, err
 	}
 
@@ -108,9 +199,49 @@ func AddUserVote(vote models.UserVote) (int, error) {
 	return int(id), nil
 }
 
+// GetUserVoteByVoter возвращает голос указанного кошелька в голосовании
+func GetUserVoteByVoter(voteID int, voter string) (models.UserVote, error) {
+	vote, err := scanUserVote(db.QueryRow("SELECT "+userVoteColumns+" FROM user_votes WHERE vote_id = ? AND voter = ?", voteID, voter))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return vote, ErrUserVoteNotFound
+		}
+		return vote, err
+	}
+	return vote, nil
+}
+
+// UpdateUserVote обновляет выбор и силу ранее сохраненного голоса пользователя.
+// Квитанция сбрасывается, так как для нового выбора отправляется новая транзакция.
+func UpdateUserVote(vote models.UserVote) error {
+	_, err := db.Exec("UPDATE user_votes SET choice = ?, vote_power = ?, transaction_id = 0, transaction_hash = '', status = ?, updated_at = ? WHERE id = ?",
+		vote.Choice, vote.VotePower, models.ReceiptStatusPending, time.Now().UTC(), vote.VoterID)
+	return err
+}
+
+// UpdateUserVoteReceipt сохраняет данные транзакции и статус квитанции голоса пользователя
+func UpdateUserVoteReceipt(id int, transactionID int, transactionHash, status string) error {
+	_, err := db.Exec("UPDATE user_votes SET transaction_id = ?, transaction_hash = ?, status = ?, updated_at = ? WHERE id = ?",
+		transactionID, transactionHash, status, time.Now().UTC(), id)
+	return err
+}
+
+// UpdateUserVoteStatus обновляет только статус квитанции голоса пользователя
+func UpdateUserVoteStatus(id int, status string) error {
+	_, err := db.Exec("UPDATE user_votes SET status = ?, updated_at = ? WHERE id = ?", status, time.Now().UTC(), id)
+	return err
+}
+
+// isUniqueViolation проверяет, что ошибка вызвана нарушением ограничения уникальности
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) &&
+		(sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+}
+
 // GetUserVotes возвращает все голоса пользователей для указанного голосования
 func GetUserVotes(voteID int) ([]models.UserVote, error) {
-	rows, err := db.Query("SELECT id, vote_id, voter, choice, vote_power FROM user_votes WHERE vote_id = ?", voteID)
+	rows, err := db.Query("SELECT "+userVoteColumns+" FROM user_votes WHERE vote_id = ?", voteID)
 	if err != nil {
 		return nil, err
 	}
@@ -118,11 +249,37 @@ func GetUserVotes(voteID int) ([]models.UserVote, error) {
 
 	var votes []models.UserVote
 	for rows.Next() {
-		var vote models.UserVote
-		if err := rows.Scan(&vote.VoterID, &vote.VoteID, &vote.Voter, &vote.Choice, &vote.VotePower); err != nil {
+		vote, err := scanUserVote(rows)
+		if err != nil {
 			return nil, err
 		}
 		votes = append(votes, vote)
 	}
-	return votes, nil
+	return votes, rows.Err()
+}
+
+// RepairUserVotes добавляет и исправляет голоса пользователей в одной транзакции базы данных
+func RepairUserVotes(inserts []models.UserVote, updates []models.UserVote) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	for _, vote := range inserts {
+		if _, err := tx.Exec("INSERT INTO user_votes (vote_id, voter, choice, vote_power, transaction_id, transaction_hash, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			vote.VoteID, vote.Voter, vote.Choice, vote.VotePower, vote.TransactionID, vote.TransactionHash, vote.Status, now, now); err != nil {
+			return err
+		}
+	}
+
+	for _, vote := range updates {
+		if _, err := tx.Exec("UPDATE user_votes SET choice = ?, transaction_hash = ?, status = ?, updated_at = ? WHERE id = ?",
+			vote.Choice, vote.TransactionHash, vote.Status, now, vote.VoterID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
@@ -0,0 +1,127 @@
+// Package repository Хранилище nonce и сессий входа подписью кошелька
+package repository
+
+import (
+	"dao_vote/back-end/models"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrWalletNonceNotFound возвращается, если nonce не найден или уже использован
+	ErrWalletNonceNotFound = errors.New("nonce не найден или уже использован")
+	// ErrWalletSessionNotFound возвращается, если сессия не найдена
+	ErrWalletSessionNotFound = errors.New("сессия не найдена")
+)
+
+// walletSessionColumns перечень колонок таблицы wallet_sessions в порядке сканирования scanWalletSession
+const walletSessionColumns = "id, wallet, created_at, expires_at, last_used_at, revoked_at"
+
+// scanWalletSession считывает сессию из строки результата
+func scanWalletSession(row rowScanner) (models.WalletSession, error) {
+	var session models.WalletSession
+	var lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&session.ID, &session.Wallet, &session.CreatedAt, &session.ExpiresAt, &lastUsedAt, &revokedAt)
+	if lastUsedAt.Valid {
+		session.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	return session, err
+}
+
+// CreateWalletNonce сохраняет nonce для входа подписью кошелька
+func CreateWalletNonce(challenge models.WalletChallenge, createdAt time.Time) error {
+	_, err := db.Exec("INSERT INTO wallet_nonces (nonce, wallet, message, created_at, expires_at) VALUES (?, ?, ?, ?, ?)",
+		challenge.Nonce, challenge.Wallet, challenge.Message, createdAt.UTC(), challenge.ExpiresAt.UTC())
+	return err
+}
+
+// UseWalletNonce помечает nonce кошелька использованным и возвращает подписываемое сообщение.
+// Просроченный, чужой или уже использованный nonce не находится.
+func UseWalletNonce(nonce, wallet string, usedAt time.Time) (string, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var message string
+	err = tx.QueryRow("SELECT message FROM wallet_nonces WHERE nonce = ? AND wallet = ? AND used_at IS NULL AND expires_at > ?",
+		nonce, wallet, usedAt.UTC()).Scan(&message)
+	if err == sql.ErrNoRows {
+		return "", ErrWalletNonceNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	result, err := tx.Exec("UPDATE wallet_nonces SET used_at = ? WHERE nonce = ? AND used_at IS NULL", usedAt.UTC(), nonce)
+	if err != nil {
+		return "", err
+	}
+	if err := requireAffected(result, ErrWalletNonceNotFound); err != nil {
+		return "", err
+	}
+	return message, tx.Commit()
+}
+
+// DeleteExpiredWalletNonces удаляет просроченные nonce
+func DeleteExpiredWalletNonces(now time.Time) error {
+	_, err := db.Exec("DELETE FROM wallet_nonces WHERE expires_at <= ?", now.UTC())
+	return err
+}
+
+// CreateWalletSession сохраняет сессию с хэшем ее токена и возвращает ID
+func CreateWalletSession(session models.WalletSession, tokenHash string) (int, error) {
+	result, err := db.Exec("INSERT INTO wallet_sessions (wallet, token_hash, created_at, expires_at) VALUES (?, ?, ?, ?)",
+		session.Wallet, tokenHash, session.CreatedAt.UTC(), session.ExpiresAt.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// GetWalletSessionByHash возвращает сессию по хэшу ее токена
+func GetWalletSessionByHash(tokenHash string) (models.WalletSession, error) {
+	session, err := scanWalletSession(db.QueryRow("SELECT "+walletSessionColumns+" FROM wallet_sessions WHERE token_hash = ?", tokenHash))
+	if err == sql.ErrNoRows {
+		return session, ErrWalletSessionNotFound
+	}
+	return session, err
+}
+
+// FirstWalletSessionID возвращает ID первой сессии кошелька. Сессии не удаляются,
+// поэтому значение не меняется между входами участника.
+func FirstWalletSessionID(wallet string) (int, error) {
+	var id sql.NullInt64
+	if err := db.QueryRow("SELECT MIN(id) FROM wallet_sessions WHERE wallet = ?", wallet).Scan(&id); err != nil {
+		return 0, err
+	}
+	if !id.Valid {
+		return 0, ErrWalletSessionNotFound
+	}
+	return int(id.Int64), nil
+}
+
+// TouchWalletSession записывает время последнего использования сессии
+func TouchWalletSession(id int, usedAt time.Time) error {
+	_, err := db.Exec("UPDATE wallet_sessions SET last_used_at = ? WHERE id = ?", usedAt.UTC(), id)
+	return err
+}
+
+// RevokeWalletSession завершает сессию; повторный выход не меняет время отзыва
+func RevokeWalletSession(id int, revokedAt time.Time) error {
+	result, err := db.Exec("UPDATE wallet_sessions SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ?", revokedAt.UTC(), id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, ErrWalletSessionNotFound)
+}
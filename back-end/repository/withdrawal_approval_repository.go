@@ -0,0 +1,51 @@
+// Package repository Хранилище решений администраторов по выводам средств
+package repository
+
+import (
+	"dao_vote/back-end/models"
+	"errors"
+	"time"
+)
+
+// ErrApprovalAlreadyRecorded возвращается, если администратор уже принял решение по выводу
+var ErrApprovalAlreadyRecorded = errors.New("решение администратора по выводу уже записано")
+
+// AddWithdrawalApproval сохраняет решение администратора по выводу средств
+func AddWithdrawalApproval(approval models.WithdrawalApproval) error {
+	_, err := db.Exec(`INSERT INTO withdrawal_approvals (withdrawal_id, admin_id, admin_wallet, decision, comment, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		approval.WithdrawalID, approval.AdminID, approval.AdminWallet, approval.Decision, approval.Comment, time.Now().UTC())
+	if isUniqueViolation(err) {
+		return ErrApprovalAlreadyRecorded
+	}
+	return err
+}
+
+// GetWithdrawalApprovals возвращает решения администраторов по выводу средств в порядке их принятия
+func GetWithdrawalApprovals(withdrawalID int) ([]models.WithdrawalApproval, error) {
+	rows, err := db.Query(`SELECT id, withdrawal_id, admin_id, admin_wallet, decision, comment, created_at
+		FROM withdrawal_approvals WHERE withdrawal_id = ? ORDER BY id`, withdrawalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	approvals := []models.WithdrawalApproval{}
+	for rows.Next() {
+		var approval models.WithdrawalApproval
+		if err := rows.Scan(&approval.ID, &approval.WithdrawalID, &approval.AdminID, &approval.AdminWallet,
+			&approval.Decision, &approval.Comment, &approval.CreatedAt); err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, approval)
+	}
+	return approvals, rows.Err()
+}
+
+// CountWithdrawalApprovals возвращает количество одобрений вывода средств
+func CountWithdrawalApprovals(withdrawalID int) (int, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM withdrawal_approvals WHERE withdrawal_id = ? AND decision = ?",
+		withdrawalID, models.ApprovalDecisionApprove).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,168 @@
+// Package repository Хранилище политик вывода средств
+package repository
+
+import (
+	"dao_vote/back-end/models"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrWithdrawalPolicyNotFound возвращается, если правило политики не найдено
+var ErrWithdrawalPolicyNotFound = errors.New("правило политики вывода не найдено")
+
+// withdrawalPolicyColumns перечень колонок таблицы withdrawal_policies в порядке сканирования scanWithdrawalPolicy
+const withdrawalPolicyColumns = "id, type, amount, address, coin, exempt_roles, enabled, description, created_at, updated_at"
+
+// scanWithdrawalPolicy считывает правило политики из строки результата
+func scanWithdrawalPolicy(row rowScanner) (models.WithdrawalPolicy, error) {
+	var policy models.WithdrawalPolicy
+	var exemptRoles string
+	err := row.Scan(&policy.ID, &policy.Type, &policy.Amount, &policy.Address, &policy.Coin, &exemptRoles, &policy.Enabled,
+		&policy.Description, &policy.CreatedAt, &policy.UpdatedAt)
+	policy.ExemptRoles = splitRoles(exemptRoles)
+	return policy, err
+}
+
+// splitRoles разбирает список ролей, сохраненный через запятую
+func splitRoles(value string) []string {
+	roles := []string{}
+	for _, role := range strings.Split(value, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// CreateWithdrawalPolicy сохраняет правило политики и возвращает его ID
+func CreateWithdrawalPolicy(policy models.WithdrawalPolicy) (int, error) {
+	now := time.Now().UTC()
+	result, err := db.Exec(`INSERT INTO withdrawal_policies (type, amount, address, coin, exempt_roles, enabled, description, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		policy.Type, policy.Amount, policy.Address, policy.Coin, strings.Join(policy.ExemptRoles, ","), policy.Enabled, policy.Description, now, now)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// UpdateWithdrawalPolicy изменяет правило политики
+func UpdateWithdrawalPolicy(policy models.WithdrawalPolicy) error {
+	result, err := db.Exec(`UPDATE withdrawal_policies SET type = ?, amount = ?, address = ?, coin = ?, exempt_roles = ?, enabled = ?, description = ?, updated_at = ?
+		WHERE id = ?`,
+		policy.Type, policy.Amount, policy.Address, policy.Coin, strings.Join(policy.ExemptRoles, ","), policy.Enabled, policy.Description,
+		time.Now().UTC(), policy.ID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, ErrWithdrawalPolicyNotFound)
+}
+
+// DeleteWithdrawalPolicy удаляет правило политики
+func DeleteWithdrawalPolicy(id int) error {
+	result, err := db.Exec("DELETE FROM withdrawal_policies WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, ErrWithdrawalPolicyNotFound)
+}
+
+// GetWithdrawalPolicyByID возвращает правило политики по ID
+func GetWithdrawalPolicyByID(id int) (models.WithdrawalPolicy, error) {
+	policy, err := scanWithdrawalPolicy(db.QueryRow("SELECT "+withdrawalPolicyColumns+" FROM withdrawal_policies WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return policy, ErrWithdrawalPolicyNotFound
+	}
+	return policy, err
+}
+
+// ListWithdrawalPolicies возвращает правила политики; enabledOnly ограничивает выборку действующими правилами
+func ListWithdrawalPolicies(enabledOnly bool) ([]models.WithdrawalPolicy, error) {
+	query := "SELECT " + withdrawalPolicyColumns + " FROM withdrawal_policies"
+	if enabledOnly {
+		query += " WHERE enabled = 1"
+	}
+	rows, err := db.Query(query + " ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []models.WithdrawalPolicy{}
+	for rows.Next() {
+		policy, err := scanWithdrawalPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, rows.Err()
+}
+
+// SumWithdrawals возвращает сумму неотклоненных выводов с указанного момента; userID 0 - по всем пользователям,
+// пустая монета - по всем монетам. Выводы, ожидающие одобрения, учитываются, отклоненные и просроченные - нет.
+func SumWithdrawals(userID int, coin string, since time.Time) (float64, error) {
+	query := "SELECT COALESCE(SUM(amount), 0) FROM withdrawals WHERE status NOT IN (?, ?, ?) AND created_at >= ?"
+	args := []interface{}{models.WithdrawalStatusFailed, models.WithdrawalStatusRejected, models.WithdrawalStatusExpired, since.UTC()}
+	if userID != 0 {
+		query += " AND user_id = ?"
+		args = append(args, userID)
+	}
+	if coin != "" {
+		query += " AND coin = ?"
+		args = append(args, coin)
+	}
+
+	var total float64
+	err := db.QueryRow(query, args...).Scan(&total)
+	return total, err
+}
+
+// AddPolicyRejection сохраняет запись об отклоненном выводе средств
+func AddPolicyRejection(rejection models.PolicyRejection) error {
+	_, err := db.Exec(`INSERT INTO withdrawal_policy_rejections (user_id, requester, amount, coin, address, policy_id, policy_type, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rejection.UserID, rejection.Requester, rejection.Amount, rejection.Coin, rejection.Address, rejection.PolicyID, rejection.PolicyType,
+		rejection.Reason, time.Now().UTC())
+	return err
+}
+
+// ListPolicyRejections возвращает журнал отказов, начиная с последних
+func ListPolicyRejections(limit, offset int) ([]models.PolicyRejection, error) {
+	rows, err := db.Query(`SELECT id, user_id, requester, amount, coin, address, policy_id, policy_type, reason, created_at
+		FROM withdrawal_policy_rejections ORDER BY id DESC LIMIT ? OFFSET ?`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rejections := []models.PolicyRejection{}
+	for rows.Next() {
+		var rejection models.PolicyRejection
+		if err := rows.Scan(&rejection.ID, &rejection.UserID, &rejection.Requester, &rejection.Amount, &rejection.Coin, &rejection.Address,
+			&rejection.PolicyID, &rejection.PolicyType, &rejection.Reason, &rejection.CreatedAt); err != nil {
+			return nil, err
+		}
+		rejections = append(rejections, rejection)
+	}
+	return rejections, rows.Err()
+}
+
+// requireAffected возвращает notFound, если запрос не изменил ни одной строки
+func requireAffected(result sql.Result, notFound error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
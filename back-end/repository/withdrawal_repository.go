@@ -0,0 +1,174 @@
+// Package repository Хранилище журнала выводов средств
+package repository
+
+import (
+	"dao_vote/back-end/models"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrWithdrawalNotFound возвращается, если вывод средств не найден
+var ErrWithdrawalNotFound = errors.New("вывод средств не найден")
+
+// withdrawalColumns перечень колонок таблицы withdrawals в порядке сканирования scanWithdrawal
+const withdrawalColumns = "id, user_id, requester, amount, coin, address, transaction_id, transaction_hash, status, error, attempts, created_at, updated_at, completed_at, approvals_required, expires_at"
+
+// scanWithdrawal считывает вывод средств из строки результата
+func scanWithdrawal(row rowScanner) (models.Withdrawal, error) {
+	var withdrawal models.Withdrawal
+	var completedAt, expiresAt sql.NullTime
+	err := row.Scan(&withdrawal.ID, &withdrawal.UserID, &withdrawal.Requester, &withdrawal.Amount, &withdrawal.Coin, &withdrawal.Address,
+		&withdrawal.TransactionID, &withdrawal.TransactionHash, &withdrawal.Status, &withdrawal.Error, &withdrawal.Attempts,
+		&withdrawal.CreatedAt, &withdrawal.UpdatedAt, &completedAt, &withdrawal.ApprovalsRequired, &expiresAt)
+	if completedAt.Valid {
+		withdrawal.CompletedAt = &completedAt.Time
+	}
+	if expiresAt.Valid {
+		withdrawal.ExpiresAt = &expiresAt.Time
+	}
+	return withdrawal, err
+}
+
+// CreateWithdrawal сохраняет новую запись журнала выводов средств и возвращает её ID
+func CreateWithdrawal(withdrawal models.Withdrawal) (int, error) {
+	now := time.Now().UTC()
+	var expiresAt interface{}
+	if withdrawal.ExpiresAt != nil {
+		expiresAt = withdrawal.ExpiresAt.UTC()
+	}
+	result, err := db.Exec(`INSERT INTO withdrawals (user_id, requester, amount, coin, address, transaction_id, transaction_hash, status, error, attempts, created_at, updated_at, approvals_required, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		withdrawal.UserID, withdrawal.Requester, withdrawal.Amount, withdrawal.Coin, withdrawal.Address, withdrawal.TransactionID,
+		withdrawal.TransactionHash, withdrawal.Status, withdrawal.Error, withdrawal.Attempts, now, now,
+		withdrawal.ApprovalsRequired, expiresAt)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// UpdateWithdrawal обновляет состояние вывода средств; итоговый статус фиксирует время завершения
+func UpdateWithdrawal(withdrawal models.Withdrawal) error {
+	now := time.Now().UTC()
+	var completedAt interface{}
+	if withdrawal.IsFinal() {
+		completedAt = now
+	}
+	_, err := db.Exec(`UPDATE withdrawals SET transaction_id = ?, transaction_hash = ?, status = ?, error = ?, attempts = ?, updated_at = ?,
+		completed_at = COALESCE(completed_at, ?) WHERE id = ?`,
+		withdrawal.TransactionID, withdrawal.TransactionHash, withdrawal.Status, withdrawal.Error, withdrawal.Attempts, now,
+		completedAt, withdrawal.ID)
+	return err
+}
+
+// GetWithdrawalByID возвращает вывод средств по его ID
+func GetWithdrawalByID(id int) (models.Withdrawal, error) {
+	withdrawal, err := scanWithdrawal(db.QueryRow("SELECT "+withdrawalColumns+" FROM withdrawals WHERE id = ?", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return withdrawal, ErrWithdrawalNotFound
+		}
+		return withdrawal, err
+	}
+	return withdrawal, nil
+}
+
+// ListWithdrawals возвращает выводы средств по фильтру, начиная с последних
+func ListWithdrawals(filter models.WithdrawalFilter) ([]models.Withdrawal, error) {
+	var conditions []string
+	var args []interface{}
+	if !filter.AllUsers {
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, filter.UserID)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.Address != "" {
+		conditions = append(conditions, "address = ?")
+		args = append(args, filter.Address)
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From.UTC())
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.To.UTC())
+	}
+
+	query := "SELECT " + withdrawalColumns + " FROM withdrawals"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ? OFFSET ?"
+	args = append(args, filter.Limit, filter.Offset)
+
+	return queryWithdrawals(query, args...)
+}
+
+// ListUnfinishedWithdrawals возвращает выводы средств, переданные во внешний API, но без итогового статуса
+func ListUnfinishedWithdrawals() ([]models.Withdrawal, error) {
+	return queryWithdrawals("SELECT "+withdrawalColumns+" FROM withdrawals WHERE status IN (?, ?) ORDER BY id",
+		models.WithdrawalStatusSubmitted, models.WithdrawalStatusSent)
+}
+
+// FailPendingWithdrawals помечает неуспешными выводы, не переданные во внешний API до перезапуска
+func FailPendingWithdrawals(reason string) (int64, error) {
+	now := time.Now().UTC()
+	result, err := db.Exec("UPDATE withdrawals SET status = ?, error = ?, updated_at = ?, completed_at = ? WHERE status = ?",
+		models.WithdrawalStatusFailed, reason, now, now, models.WithdrawalStatusPending)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ExpireWithdrawals переводит в статус expired выводы, не получившие одобрения до истечения срока, и возвращает их ID
+func ExpireWithdrawals(now time.Time) ([]int, error) {
+	expired, err := queryWithdrawals("SELECT "+withdrawalColumns+" FROM withdrawals WHERE status = ? AND expires_at <= ?",
+		models.WithdrawalStatusPendingApproval, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	for _, withdrawal := range expired {
+		result, err := db.Exec("UPDATE withdrawals SET status = ?, error = ?, updated_at = ?, completed_at = ? WHERE id = ? AND status = ?",
+			models.WithdrawalStatusExpired, "approval window expired", now.UTC(), now.UTC(), withdrawal.ID, models.WithdrawalStatusPendingApproval)
+		if err != nil {
+			return ids, err
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected > 0 {
+			ids = append(ids, withdrawal.ID)
+		}
+	}
+	return ids, nil
+}
+
+// queryWithdrawals выполняет запрос и считывает список выводов средств
+func queryWithdrawals(query string, args ...interface{}) ([]models.Withdrawal, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	withdrawals := []models.Withdrawal{}
+	for rows.Next() {
+		withdrawal, err := scanWithdrawal(rows)
+		if err != nil {
+			return nil, err
+		}
+		withdrawals = append(withdrawals, withdrawal)
+	}
+	return withdrawals, rows.Err()
+}
@@ -0,0 +1,147 @@
+// Package services API ключи сервисного доступа: выдача, проверка и отзыв
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// APIKeyPrefix начало значения всех API ключей
+const APIKeyPrefix = "dao_"
+
+// apiKeyDisplayLength длина начала ключа, сохраняемого для опознания
+const apiKeyDisplayLength = len(APIKeyPrefix) + 8
+
+// scopePattern формат разрешения API ключа: *, группа.* или группа.действие
+var scopePattern = regexp.MustCompile(`^(\*|[a-z_]+\.(\*|[a-z_]+))$`)
+
+var (
+	ErrInvalidAPIKeyRequest = errors.New("invalid API key request")
+	ErrInvalidAPIKey        = errors.New("invalid API key")
+	ErrAPIKeyRevoked        = errors.New("API key has been revoked")
+	ErrAPIKeyExpired        = errors.New("API key has expired")
+	ErrAPIKeyIPNotAllowed   = errors.New("API key is not allowed from this IP address")
+)
+
+// CreateAPIKey выдает новый API ключ. Значение ключа возвращается только здесь, в базе хранится его sha256.
+func CreateAPIKey(req models.APIKeyRequest, createdBy int) (models.CreatedAPIKey, error) {
+	key := models.APIKey{
+		Name:       strings.TrimSpace(req.Name),
+		Scopes:     []string{},
+		AllowedIPs: []string{},
+		Wallet:     req.Wallet,
+		CreatedBy:  createdBy,
+		CreatedAt:  time.Now().UTC(),
+		ExpiresAt:  req.ExpiresAt,
+	}
+	for _, scope := range req.Scopes {
+		scope = strings.TrimSpace(scope)
+		if !scopePattern.MatchString(scope) {
+			return models.CreatedAPIKey{}, fmt.Errorf("%w: invalid scope %q", ErrInvalidAPIKeyRequest, scope)
+		}
+		key.Scopes = append(key.Scopes, scope)
+	}
+	for _, entry := range req.AllowedIPs {
+		entry = strings.TrimSpace(entry)
+		if _, _, err := net.ParseCIDR(entry); err != nil && net.ParseIP(entry) == nil {
+			return models.CreatedAPIKey{}, fmt.Errorf("%w: invalid IP address or subnet %q", ErrInvalidAPIKeyRequest, entry)
+		}
+		key.AllowedIPs = append(key.AllowedIPs, entry)
+	}
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(key.CreatedAt) {
+		return models.CreatedAPIKey{}, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidAPIKeyRequest)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return models.CreatedAPIKey{}, err
+	}
+	value := APIKeyPrefix + hex.EncodeToString(secret)
+	key.Prefix = value[:apiKeyDisplayLength]
+
+	id, err := repository.CreateAPIKey(key, hashAPIKey(value))
+	if err != nil {
+		return models.CreatedAPIKey{}, err
+	}
+	created, err := repository.GetAPIKeyByID(id)
+	if err != nil {
+		return models.CreatedAPIKey{}, err
+	}
+	return models.CreatedAPIKey{APIKey: created, Key: value}, nil
+}
+
+// AuthenticateAPIKey проверяет API ключ, его срок действия и IP адрес клиента и записывает последнее использование
+func AuthenticateAPIKey(value, clientIP string) (models.APIKey, error) {
+	key, err := repository.GetAPIKeyByHash(hashAPIKey(value))
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return key, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return key, err
+	}
+
+	now := time.Now().UTC()
+	switch {
+	case key.RevokedAt != nil:
+		return key, ErrAPIKeyRevoked
+	case key.ExpiresAt != nil && !now.Before(*key.ExpiresAt):
+		return key, ErrAPIKeyExpired
+	case !ipAllowed(key.AllowedIPs, clientIP):
+		return key, ErrAPIKeyIPNotAllowed
+	}
+
+	if err := repository.TouchAPIKey(key.ID, clientIP, now); err != nil {
+		logrus.Errorf("API key %d: failed to record last use: %v", key.ID, err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys возвращает все API ключи без их значений
+func ListAPIKeys() ([]models.APIKey, error) {
+	return repository.ListAPIKeys()
+}
+
+// RevokeAPIKey отзывает API ключ и возвращает его
+func RevokeAPIKey(id int) (models.APIKey, error) {
+	if err := repository.RevokeAPIKey(id, time.Now()); err != nil {
+		return models.APIKey{}, err
+	}
+	return repository.GetAPIKeyByID(id)
+}
+
+// hashAPIKey возвращает sha256 значения API ключа
+func hashAPIKey(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// ipAllowed проверяет IP адрес клиента по списку адресов и подсетей; пустой список разрешает любой адрес
+func ipAllowed(allowed []string, clientIP string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+	for _, entry := range allowed {
+		if _, subnet, err := net.ParseCIDR(entry); err == nil {
+			if subnet.Contains(ip) {
+				return true
+			}
+		} else if allowedIP := net.ParseIP(entry); allowedIP != nil && allowedIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+// Package services Журнал действий администраторов с удалением секретов
+package services
+
+import (
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// RedactedValue заменяет значения секретных полей в журнале действий
+const RedactedValue = "[REDACTED]"
+
+// secretFieldMarkers части названий полей, значения которых не попадают в журнал действий
+var secretFieldMarkers = []string{"token", "secret", "password", "authorization", "signature", "mnemonic", "private_key", "cookie"}
+
+// secretFieldNames названия полей, значения которых не попадают в журнал действий целиком
+var secretFieldNames = map[string]bool{"key": true, "api_key": true, "x-api-key": true, "key_hash": true}
+
+// RecordAudit сохраняет запись журнала действий. Значения before и after сериализуются в JSON,
+// значения секретных полей заменяются на RedactedValue.
+func RecordAudit(entry models.AuditEntry, before, after interface{}) (int, error) {
+	var err error
+	if entry.Before, err = RedactSecrets(before); err != nil {
+		return 0, err
+	}
+	if entry.After, err = RedactSecrets(after); err != nil {
+		return 0, err
+	}
+	entry.CreatedAt = time.Now().UTC()
+	return repository.AddAuditEntry(entry)
+}
+
+// RedactSecrets сериализует значение в JSON и заменяет значения секретных полей на любой глубине
+func RedactSecrets(value interface{}) (json.RawMessage, error) {
+	if value == nil {
+		return json.RawMessage("null"), nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return json.Marshal(redactValue(decoded))
+}
+
+// redactValue заменяет значения секретных полей в разобранном JSON
+func redactValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for name, field := range typed {
+			if IsSecretField(name) {
+				typed[name] = RedactedValue
+				continue
+			}
+			typed[name] = redactValue(field)
+		}
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// IsSecretField проверяет, что поле с таким названием содержит секрет
+func IsSecretField(name string) bool {
+	name = strings.ToLower(name)
+	if secretFieldNames[name] {
+		return true
+	}
+	for _, marker := range secretFieldMarkers {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListAuditLog возвращает записи журнала действий по фильтру
+func ListAuditLog(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	return repository.ListAuditEntries(filter)
+}
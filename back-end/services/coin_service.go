@@ -0,0 +1,105 @@
+// Package services Монеты, разрешенные для выводов средств и депозитов голосов
+package services
+
+import (
+	"dao_vote/back-end/models"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// AllowedCoins монеты, разрешенные для выводов средств и депозитов голосов; первая - монета по умолчанию
+var AllowedCoins = []string{"del"}
+
+// ErrCoinNotAllowed возвращается, если монета не входит в список разрешенных
+var ErrCoinNotAllowed = errors.New("coin is not allowed")
+
+// LoadCoinConfig считывает список разрешенных монет из переменной окружения ALLOWED_COINS (через запятую)
+func LoadCoinConfig() error {
+	value := os.Getenv("ALLOWED_COINS")
+	if value == "" {
+		return nil
+	}
+
+	var coins []string
+	for _, coin := range strings.Split(value, ",") {
+		if coin = NormalizeCoin(coin); coin != "" {
+			coins = append(coins, coin)
+		}
+	}
+	if len(coins) == 0 {
+		return fmt.Errorf("invalid ALLOWED_COINS %q", value)
+	}
+	AllowedCoins = coins
+	return nil
+}
+
+// NormalizeCoin приводит обозначение монеты к нижнему регистру без пробелов
+func NormalizeCoin(coin string) string {
+	return strings.ToLower(strings.TrimSpace(coin))
+}
+
+// DefaultCoin возвращает монету по умолчанию
+func DefaultCoin() string {
+	return AllowedCoins[0]
+}
+
+// IsAllowedCoin проверяет, что монета входит в список разрешенных
+func IsAllowedCoin(coin string) bool {
+	coin = NormalizeCoin(coin)
+	for _, allowed := range AllowedCoins {
+		if coin == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveCoin возвращает монету запроса или монету по умолчанию, если монета не указана
+func resolveCoin(coin string) (string, error) {
+	coin = NormalizeCoin(coin)
+	if coin == "" {
+		return DefaultCoin(), nil
+	}
+	if !IsAllowedCoin(coin) {
+		return coin, fmt.Errorf("%w: %s", ErrCoinNotAllowed, coin)
+	}
+	return coin, nil
+}
+
+// VoteDeposit формирует перевод депозита голоса на кошелек голосования:
+// монета голосования (или монета по умолчанию) и сумма не меньше минимальной, но не меньше 1
+func VoteDeposit(vote models.VoteInfo, walletAddress string) models.WithdrawRequest {
+	coin := vote.VoteCoin
+	if coin == "" {
+		coin = DefaultCoin()
+	}
+	return models.WithdrawRequest{
+		Amount:  math.Max(1, vote.MinVoteAmount),
+		Address: walletAddress,
+		Coin:    coin,
+	}
+}
+
+// filterVoteDeposits отделяет транзакции, не удовлетворяющие правилу депозита голосования:
+// перевод в другой монете или на сумму меньше минимальной. Если монета голосования не задана, правило не действует.
+func filterVoteDeposits(vote models.VoteInfo, txs []models.Transaction) ([]models.Transaction, []models.Transaction) {
+	if vote.VoteCoin == "" {
+		return txs, nil
+	}
+
+	accepted := []models.Transaction{}
+	var insufficient []models.Transaction
+	for _, tx := range txs {
+		amount, err := strconv.ParseFloat(tx.Amount.String(), 64)
+		if NormalizeCoin(tx.Coin) != vote.VoteCoin || err != nil || amount < vote.MinVoteAmount {
+			insufficient = append(insufficient, tx)
+			continue
+		}
+		accepted = append(accepted, tx)
+	}
+	return accepted, insufficient
+}
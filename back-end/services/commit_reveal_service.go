@@ -0,0 +1,263 @@
+// Package services Тайное голосование commit-reveal
+package services
+
+import (
+	"crypto/sha256"
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"encoding/hex"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+// Префиксы сообщений транзакций тайного голосования
+const (
+	commitMemoPrefix = "commit:" // commit:<sha256(voter:choice:salt)>
+	revealMemoPrefix = "reveal:" // reveal:<choice>:<salt>
+)
+
+var (
+	// ErrNotCommitReveal возвращается, если голосование не использует тайные бюллетени
+	ErrNotCommitReveal = errors.New("proposal does not use commit-reveal ballots")
+	// ErrCommitPhaseClosed возвращается при подаче обязательства после окончания приема
+	ErrCommitPhaseClosed = errors.New("commit phase is closed")
+	// ErrRevealPhaseClosed возвращается при раскрытии вне окна раскрытия
+	ErrRevealPhaseClosed = errors.New("reveal phase is not open")
+	// ErrCommitmentMismatch возвращается, если выбор и соль не совпадают с обязательством
+	ErrCommitmentMismatch = errors.New("choice and salt do not match the commitment")
+)
+
+// BallotCommitment возвращает хэш-обязательство голоса: sha256("<voter>:<choice>:<salt>") в hex.
+// Адрес кошелька входит в хэш, чтобы чужое обязательство нельзя было скопировать.
+func BallotCommitment(voter, choice, salt string) string {
+	sum := sha256.Sum256([]byte(voter + ":" + strings.TrimSpace(choice) + ":" + salt))
+	return hex.EncodeToString(sum[:])
+}
+
+// SubmitCommitment сохраняет обязательство голоса кошелька в окне приема обязательств
+// с учетом политики повторного голосования.
+func SubmitCommitment(vote models.VoteInfo, voter, commitment string) (models.VoteCommitment, error) {
+	if vote.BallotType != models.BallotCommitReveal {
+		return models.VoteCommitment{}, ErrNotCommitReveal
+	}
+	if !inBallotWindow(time.Now(), nil, vote.CommitEndsAt) {
+		return models.VoteCommitment{}, ErrCommitPhaseClosed
+	}
+
+	votePower, err := repository.GetVoteStrength(voter)
+	if err != nil {
+		return models.VoteCommitment{}, ErrNotDAOMember
+	}
+
+	record := models.VoteCommitment{
+		VoteID:     vote.ID,
+		Voter:      voter,
+		Commitment: strings.ToLower(commitment),
+		VotePower:  votePower,
+	}
+
+	_, err = repository.GetCommitmentByVoter(vote.ID, voter)
+	if err == nil {
+		if vote.VoteChangePolicy != models.VoteChangeReplace {
+			return models.VoteCommitment{}, ErrAlreadyVoted
+		}
+		if err := repository.ReplaceCommitment(record); err != nil {
+			return models.VoteCommitment{}, err
+		}
+		return repository.GetCommitmentByVoter(vote.ID, voter)
+	}
+	if !errors.Is(err, repository.ErrCommitmentNotFound) {
+		return models.VoteCommitment{}, err
+	}
+
+	if _, err := repository.AddCommitment(record); err != nil {
+		if errors.Is(err, repository.ErrUserVoteExists) {
+			return models.VoteCommitment{}, ErrAlreadyVoted
+		}
+		return models.VoteCommitment{}, err
+	}
+	return repository.GetCommitmentByVoter(vote.ID, voter)
+}
+
+// RevealVote раскрывает обязательство, поданное через API, проверяя совпадение выбора и соли с хэшем
+func RevealVote(vote models.VoteInfo, voter string, req models.RevealRequest) (models.VoteCommitment, error) {
+	if vote.BallotType != models.BallotCommitReveal {
+		return models.VoteCommitment{}, ErrNotCommitReveal
+	}
+	if vote.CommitEndsAt == nil || !inBallotWindow(time.Now(), vote.CommitEndsAt, vote.RevealEndsAt) {
+		return models.VoteCommitment{}, ErrRevealPhaseClosed
+	}
+	if ClassifyChoice(req.Choice) == "" {
+		return models.VoteCommitment{}, ErrInvalidChoice
+	}
+
+	commitment, err := repository.GetCommitmentByVoter(vote.ID, voter)
+	if err != nil {
+		return models.VoteCommitment{}, err
+	}
+	if BallotCommitment(voter, req.Choice, req.Salt) != commitment.Commitment {
+		return models.VoteCommitment{}, ErrCommitmentMismatch
+	}
+
+	choice := strings.TrimSpace(req.Choice)
+	if err := repository.RevealCommitment(commitment.ID, choice, req.Salt); err != nil {
+		return models.VoteCommitment{}, err
+	}
+	return repository.GetCommitmentByVoter(vote.ID, voter)
+}
+
+// inBallotWindow проверяет, что момент времени попадает в полуинтервал [from, to)
+func inBallotWindow(at time.Time, from, to *time.Time) bool {
+	if from != nil && at.Before(*from) {
+		return false
+	}
+	if to != nil && !at.Before(*to) {
+		return false
+	}
+	return true
+}
+
+// txInBallotWindow проверяет время транзакции. Если окно ограничено, транзакция без распознаваемого времени
+// не учитывается, так как нельзя проверить, что она подана вовремя.
+func txInBallotWindow(tx models.Transaction, from, to *time.Time) bool {
+	if from == nil && to == nil {
+		return true
+	}
+	at, err := time.Parse(time.RFC3339, tx.Timestamp)
+	if err != nil {
+		logrus.Warnf("Transaction %s from %s excluded from ballot: invalid timestamp %q", tx.Hash, tx.From, tx.Timestamp)
+		return false
+	}
+	return inBallotWindow(at, from, to)
+}
+
+// parseBallotMemo разбирает сообщение тайного голосования.
+// Возвращает префикс сообщения, хэш обязательства либо выбор и соль раскрытия.
+func parseBallotMemo(message string) (kind, commitment, choice, salt string) {
+	raw := strings.Trim(strings.TrimSpace(message), `"`)
+	lower := strings.ToLower(raw)
+	switch {
+	case strings.HasPrefix(lower, commitMemoPrefix):
+		return commitMemoPrefix, strings.ToLower(strings.TrimSpace(raw[len(commitMemoPrefix):])), "", ""
+	case strings.HasPrefix(lower, revealMemoPrefix):
+		parts := strings.SplitN(raw[len(revealMemoPrefix):], ":", 2)
+		if len(parts) != 2 {
+			return "", "", "", ""
+		}
+		return revealMemoPrefix, "", strings.TrimSpace(parts[0]), parts[1]
+	default:
+		return "", "", "", ""
+	}
+}
+
+// commitmentTransactions представляет обязательства и раскрытия, поданные через API, в виде транзакций
+func commitmentTransactions(commitments []models.VoteCommitment) []models.Transaction {
+	var txs []models.Transaction
+	for _, commitment := range commitments {
+		txs = append(txs, models.Transaction{
+			From:      commitment.Voter,
+			Message:   commitMemoPrefix + commitment.Commitment,
+			VotePower: commitment.VotePower,
+			Hash:      commitment.Commitment,
+			Source:    models.TransactionSourceCommitment,
+			Timestamp: commitment.CreatedAt.UTC().Format(time.RFC3339),
+		})
+		if commitment.RevealedAt != nil {
+			txs = append(txs, models.Transaction{
+				From:      commitment.Voter,
+				Message:   revealMemoPrefix + commitment.Choice + ":" + commitment.Salt,
+				VotePower: commitment.VotePower,
+				Hash:      commitment.Commitment,
+				Source:    models.TransactionSourceCommitment,
+				Timestamp: commitment.RevealedAt.UTC().Format(time.RFC3339),
+			})
+		}
+	}
+	return txs
+}
+
+// revealEntry раскрытие голоса, ожидающее сопоставления с обязательством
+type revealEntry struct {
+	tx     models.Transaction
+	choice string
+	salt   string
+	used   bool
+}
+
+// resolveCommitReveal сопоставляет раскрытия с обязательствами.
+// Возвращает раскрытые голоса (транзакция обязательства с выбором в сообщении), нераскрытые обязательства,
+// раскрытия, не совпавшие с обязательством, и прочие транзакции, не участвующие в подсчете.
+func resolveCommitReveal(txs []models.Transaction, commitEndsAt, revealEndsAt *time.Time) (revealed, unrevealed, mismatched, invalid []models.Transaction) {
+	var commits []models.Transaction
+	var reveals []*revealEntry
+	commitments := make(map[string]string) // Обязательство каждого кошелька
+	for _, tx := range txs {
+		kind, commitment, choice, salt := parseBallotMemo(tx.Message)
+		switch kind {
+		case commitMemoPrefix:
+			// Учитывается первое обязательство кошелька, поданное в окне приема обязательств
+			if _, exists := commitments[tx.From]; exists || commitment == "" || !txInBallotWindow(tx, nil, commitEndsAt) {
+				invalid = append(invalid, tx)
+				continue
+			}
+			commitments[tx.From] = commitment
+			commits = append(commits, tx)
+		case revealMemoPrefix:
+			if !txInBallotWindow(tx, commitEndsAt, revealEndsAt) {
+				invalid = append(invalid, tx)
+				continue
+			}
+			reveals = append(reveals, &revealEntry{tx: tx, choice: choice, salt: salt})
+		default:
+			invalid = append(invalid, tx)
+		}
+	}
+
+	for _, commit := range commits {
+		commitment := commitments[commit.From]
+		matched, attempted := false, false
+		for _, reveal := range reveals {
+			if reveal.used || reveal.tx.From != commit.From {
+				continue
+			}
+			reveal.used, attempted = true, true
+			switch {
+			case matched:
+				// Повторные раскрытия после совпавшего не учитываются
+				invalid = append(invalid, reveal.tx)
+			case BallotCommitment(commit.From, reveal.choice, reveal.salt) == commitment && ClassifyChoice(reveal.choice) != "":
+				ballot := commit
+				ballot.Message = reveal.choice
+				revealed = append(revealed, ballot)
+				matched = true
+			default:
+				mismatched = append(mismatched, reveal.tx)
+			}
+		}
+		if !attempted {
+			unrevealed = append(unrevealed, commit)
+		}
+	}
+
+	// Раскрытия без обязательства не учитываются
+	for _, reveal := range reveals {
+		if !reveal.used {
+			invalid = append(invalid, reveal.tx)
+		}
+	}
+	return revealed, unrevealed, mismatched, invalid
+}
+
+// prepareCommitRevealResults подсчитывает только раскрытые голоса, совпавшие со своими обязательствами
+func prepareCommitRevealResults(txs []models.Transaction, commitEndsAt, revealEndsAt *time.Time) models.VoteResults {
+	revealed, unrevealed, mismatched, invalid := resolveCommitReveal(txs, commitEndsAt, revealEndsAt)
+
+	results := tallyVoteResults(revealed)
+	results.TotalTransactions = len(txs)
+	results.InvalidMessageTxs = append(results.InvalidMessageTxs, invalid...)
+	results.UnrevealedTxs = unrevealed
+	results.MismatchedReveals = mismatched
+	return results
+}
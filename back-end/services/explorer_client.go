@@ -0,0 +1,108 @@
+// Package services Клиент API эксплорера Decimal: балансы адресов и держатели монет
+package services
+
+import (
+	"dao_vote/back-end/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ExplorerClient источник балансов монет. Заменяется в тестах или указывает на локальный
+// сервер с подготовленными ответами через EXPLORER_API_URL.
+type ExplorerClient interface {
+	// CoinBalance возвращает баланс адреса в монете в минимальных единицах ("0", если монеты нет)
+	CoinBalance(address, coin string) (string, error)
+	// CoinHolders возвращает страницу держателей монеты и общее количество держателей
+	CoinHolders(coin string, limit, offset int) ([]models.CoinHolder, int, error)
+}
+
+// Explorer клиент эксплорера, используемый синхронизацией силы голоса
+var Explorer ExplorerClient = HTTPExplorerClient{}
+
+// ErrExplorerRequest возвращается при ошибке запроса к эксплореру
+var ErrExplorerRequest = errors.New("explorer request failed")
+
+// explorerClient HTTP клиент для запросов к API эксплорера
+var explorerClient = &http.Client{Timeout: 10 * time.Second}
+
+// LoadExplorerConfig переопределяет адрес API эксплорера из переменной окружения EXPLORER_API_URL
+func LoadExplorerConfig() error {
+	value := strings.TrimRight(os.Getenv("EXPLORER_API_URL"), "/")
+	if value == "" {
+		return nil
+	}
+	if parsed, err := url.Parse(value); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return fmt.Errorf("invalid EXPLORER_API_URL %q", value)
+	}
+	ExplorerAPIURL = value
+	return nil
+}
+
+// HTTPExplorerClient клиент API эксплорера по адресу ExplorerAPIURL
+type HTTPExplorerClient struct{}
+
+// CoinBalance запрашивает GET /address/:address/balances
+func (HTTPExplorerClient) CoinBalance(address, coin string) (string, error) {
+	var response struct {
+		Result struct {
+			Balances []struct {
+				Coin   string      `json:"coin"`
+				Amount json.Number `json:"amount"`
+			} `json:"balances"`
+		} `json:"result"`
+	}
+	if err := getExplorerJSON(fmt.Sprintf("%s/address/%s/balances", ExplorerAPIURL, url.PathEscape(address)), &response); err != nil {
+		return "", err
+	}
+	for _, balance := range response.Result.Balances {
+		if NormalizeCoin(balance.Coin) == NormalizeCoin(coin) {
+			return balance.Amount.String(), nil
+		}
+	}
+	return "0", nil
+}
+
+// CoinHolders запрашивает GET /coin/:coin/holders?limit=&offset=
+func (HTTPExplorerClient) CoinHolders(coin string, limit, offset int) ([]models.CoinHolder, int, error) {
+	var response struct {
+		Result struct {
+			Count   int `json:"count"`
+			Holders []struct {
+				Address string      `json:"address"`
+				Amount  json.Number `json:"amount"`
+			} `json:"holders"`
+		} `json:"result"`
+	}
+	apiURL := fmt.Sprintf("%s/coin/%s/holders?limit=%d&offset=%d", ExplorerAPIURL, url.PathEscape(NormalizeCoin(coin)), limit, offset)
+	if err := getExplorerJSON(apiURL, &response); err != nil {
+		return nil, 0, err
+	}
+	holders := make([]models.CoinHolder, 0, len(response.Result.Holders))
+	for _, holder := range response.Result.Holders {
+		holders = append(holders, models.CoinHolder{Address: holder.Address, Amount: holder.Amount.String()})
+	}
+	return holders, response.Result.Count, nil
+}
+
+// getExplorerJSON выполняет GET запрос к эксплореру и разбирает JSON ответ
+func getExplorerJSON(apiURL string, target interface{}) error {
+	resp, err := explorerClient.Get(apiURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrExplorerRequest, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: received non-200 response code: %d", ErrExplorerRequest, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("%w: error unmarshalling response body: %v", ErrExplorerRequest, err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Package services Идемпотентность запросов с заголовком Idempotency-Key
+package services
+
+import (
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// IdempotencyRetention время хранения ответа на запрос с ключом идемпотентности
+var IdempotencyRetention = 24 * time.Hour
+
+// IdempotencyInFlightLease время, после которого незавершенный запрос считается прерванным
+// (например, при падении сервера), и ключ может занять повторный запрос.
+// Должно превышать время выполнения обработчика вместе с таймаутами внешних API.
+var IdempotencyInFlightLease = 2 * time.Minute
+
+var (
+	// ErrIdempotencyInFlight возвращается, если первый запрос с тем же ключом еще выполняется
+	ErrIdempotencyInFlight = errors.New("a request with this Idempotency-Key is still in progress")
+	// ErrIdempotencyKeyReused возвращается, если ключ повторно использован для другого запроса
+	ErrIdempotencyKeyReused = errors.New("Idempotency-Key was already used for a different request")
+)
+
+// BeginIdempotentRequest резервирует ключ для первого запроса.
+// Для повторного запроса возвращает сохраненный ответ первого.
+func BeginIdempotentRequest(key, requestHash string) (*models.IdempotencyRecord, error) {
+	now := time.Now().UTC()
+	err := repository.ReserveIdempotencyKey(models.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(IdempotencyRetention),
+	})
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, repository.ErrIdempotencyKeyExists) {
+		return nil, err
+	}
+
+	record, err := repository.GetIdempotencyRecord(key)
+	if errors.Is(err, repository.ErrIdempotencyKeyNotFound) {
+		// Первый запрос освободил ключ между резервированием и чтением
+		return BeginIdempotentRequest(key, requestHash)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if record.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if record.Status == models.IdempotencyInFlight {
+		if now.Sub(record.CreatedAt) < IdempotencyInFlightLease {
+			return nil, ErrIdempotencyInFlight
+		}
+		taken, err := repository.TakeOverIdempotencyKey(key, record.CreatedAt, now, now.Add(IdempotencyRetention))
+		if err != nil {
+			return nil, err
+		}
+		if !taken {
+			// Ключ уже занял другой повторный запрос
+			return nil, ErrIdempotencyInFlight
+		}
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// CompleteIdempotentRequest сохраняет ответ первого запроса на время хранения.
+// Ответ с ошибкой сервера не сохраняется, чтобы запрос можно было повторить, если обработчик
+// не успел выполнить внешнее действие (sideEffect); иначе повтор мог бы выполнить его дважды.
+func CompleteIdempotentRequest(key string, status int, sideEffect bool, body []byte, contentType, location string) error {
+	if status >= http.StatusInternalServerError && !sideEffect {
+		return repository.ReleaseIdempotencyKey(key)
+	}
+	return repository.CompleteIdempotencyKey(models.IdempotencyRecord{
+		Key:            key,
+		ResponseStatus: status,
+		ResponseBody:   body,
+		ContentType:    contentType,
+		Location:       location,
+	})
+}
@@ -0,0 +1,298 @@
+// Package services Фоновые задачи отправки голосов в блокчейн
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Backoff описывает параметры экспоненциальной задержки между попытками
+type Backoff struct {
+	Initial     time.Duration // Задержка перед первой попыткой
+	Max         time.Duration // Максимальная задержка между попытками
+	Factor      float64       // Множитель задержки
+	MaxAttempts int           // Максимальное количество попыток
+}
+
+// Delay возвращает задержку перед попыткой с указанным номером (начиная с нуля)
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := float64(b.Initial)
+	for i := 0; i < attempt; i++ {
+		delay *= b.Factor
+		if time.Duration(delay) >= b.Max {
+			return b.Max
+		}
+	}
+	return time.Duration(delay)
+}
+
+// VoteJobBackoff задает расписание опроса хэша транзакции голоса
+var VoteJobBackoff = Backoff{
+	Initial:     2 * time.Second,
+	Max:         30 * time.Second,
+	Factor:      2,
+	MaxAttempts: 8,
+}
+
+// ErrInvalidCallbackURL возвращается, если адрес уведомления не разрешен
+var ErrInvalidCallbackURL = errors.New("callback_url is not allowed")
+
+// JobCallbackHosts разрешенные хосты адресов уведомлений (пусто - любой хост с публичным IP адресом)
+var JobCallbackHosts []string
+
+// carrierGradeNAT диапазон 100.64.0.0/10, не относящийся к публичным адресам
+var carrierGradeNAT = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// callbackClient HTTP клиент для уведомлений о завершении задач. Соединяется только с публичными
+// IP адресами (проверяется адрес, полученный при разрешении имени) и не следует перенаправлениям.
+var callbackClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		Proxy:       nil,
+		DialContext: (&net.Dialer{Timeout: 5 * time.Second, Control: rejectNonPublicAddress}).DialContext,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// LoadJobCallbackConfig загружает разрешенные хосты уведомлений из JOB_CALLBACK_HOSTS (через запятую)
+func LoadJobCallbackConfig() error {
+	JobCallbackHosts = nil
+	for _, host := range strings.Split(os.Getenv("JOB_CALLBACK_HOSTS"), ",") {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host == "" {
+			continue
+		}
+		if strings.ContainsAny(host, "/:@") {
+			return fmt.Errorf("invalid JOB_CALLBACK_HOSTS entry %q", host)
+		}
+		JobCallbackHosts = append(JobCallbackHosts, host)
+	}
+	return nil
+}
+
+// ValidateCallbackURL проверяет адрес уведомления: схема http или https, хост из JobCallbackHosts,
+// если список задан, и IP адрес в адресе не из частных, локальных и служебных диапазонов
+func ValidateCallbackURL(rawURL string) error {
+	if rawURL == "" {
+		return nil
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" || parsed.User != nil {
+		return ErrInvalidCallbackURL
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+		return ErrInvalidCallbackURL
+	}
+	if len(JobCallbackHosts) == 0 {
+		return nil
+	}
+	for _, allowed := range JobCallbackHosts {
+		if host == allowed {
+			return nil
+		}
+	}
+	return ErrInvalidCallbackURL
+}
+
+// isPublicIP сообщает, что адрес не относится к локальным, частным, служебным и групповым диапазонам
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || carrierGradeNAT.Contains(ip))
+}
+
+// rejectNonPublicAddress запрещает соединение с непубличным адресом после разрешения имени хоста
+func rejectNonPublicAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("%w: address %s is not public", ErrInvalidCallbackURL, host)
+	}
+	return nil
+}
+
+// SubmitVoteJob создает задачу отправки голоса и запускает её выполнение в фоне.
+// Токен пользователя хранится только в памяти фонового обработчика.
+func SubmitVoteJob(userID int, userVote models.UserVote, withdrawReq models.WithdrawRequest, token, callbackURL string) (models.Job, error) {
+	if err := ValidateCallbackURL(callbackURL); err != nil {
+		return models.Job{}, err
+	}
+	id, err := newJobID()
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	now := time.Now().UTC()
+	job := models.Job{
+		ID:          id,
+		Type:        models.JobTypeVoteSubmission,
+		Status:      models.JobStatusPending,
+		UserID:      userID,
+		VoteID:      userVote.VoteID,
+		UserVoteID:  userVote.VoterID,
+		CallbackURL: callbackURL,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := repository.CreateJob(job); err != nil {
+		return models.Job{}, fmt.Errorf("failed to create job: %v", err)
+	}
+
+	go runVoteJob(job, withdrawReq, token)
+	return job, nil
+}
+
+// GetJob возвращает фоновую задачу по ID
+func GetJob(id string) (models.Job, error) {
+	return repository.GetJobByID(id)
+}
+
+// FailInterruptedJobs завершает задачи, прерванные перезапуском сервиса.
+// Токены пользователей не сохраняются, поэтому продолжить такие задачи невозможно.
+func FailInterruptedJobs() error {
+	count, err := repository.FailPendingJobs("interrupted by service restart")
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		logrus.Warnf("Marked %d interrupted jobs as failed", count)
+	}
+	return nil
+}
+
+// runVoteJob отправляет транзакцию голоса и опрашивает её хэш с экспоненциальной задержкой
+func runVoteJob(job models.Job, withdrawReq models.WithdrawRequest, token string) {
+	logrus.Infof("Job %s: initiating withdrawal %+v", job.ID, withdrawReq)
+
+	response, err := InitiateWithdrawal(withdrawReq, token)
+	if err != nil {
+		finishJob(job, models.JobStatusFailed, fmt.Sprintf("failed to initiate withdrawal: %v", err))
+		return
+	}
+	if response.Data.TransactionID == 0 {
+		finishJob(job, models.JobStatusFailed, "invalid transaction ID in response")
+		return
+	}
+
+	job.TransactionID = response.Data.TransactionID
+	saveJob(&job)
+	updateVoteReceipt(job, models.ReceiptStatusSubmitted)
+
+	for attempt := 0; attempt < VoteJobBackoff.MaxAttempts; attempt++ {
+		time.Sleep(VoteJobBackoff.Delay(attempt))
+		job.Attempts = attempt + 1
+
+		hashResponse, err := GetTransactionHash(job.TransactionID, token)
+		if err != nil {
+			logrus.Warnf("Job %s: attempt %d to retrieve transaction hash failed: %v", job.ID, job.Attempts, err)
+			saveJob(&job)
+			continue
+		}
+
+		if hashResponse.Data.Complete && !hashResponse.Data.Success {
+			finishJob(job, models.JobStatusFailed, fmt.Sprintf("transaction failed: %s", hashResponse.Data.Result))
+			return
+		}
+
+		if hashResponse.Data.Hash != "" {
+			job.TransactionHash = hashResponse.Data.Hash
+			finishJob(job, models.JobStatusConfirmed, "")
+			return
+		}
+
+		logrus.Infof("Job %s: transaction %d has no hash yet (attempt %d)", job.ID, job.TransactionID, job.Attempts)
+		saveJob(&job)
+	}
+
+	finishJob(job, models.JobStatusFailed, fmt.Sprintf("transaction hash is not available after %d attempts", job.Attempts))
+}
+
+// saveJob сохраняет промежуточное состояние задачи
+func saveJob(job *models.Job) {
+	job.UpdatedAt = time.Now().UTC()
+	if err := repository.UpdateJob(*job); err != nil {
+		logrus.Errorf("Job %s: failed to update job: %v", job.ID, err)
+	}
+}
+
+// finishJob сохраняет итоговый статус задачи и уведомляет клиента
+func finishJob(job models.Job, status, reason string) {
+	job.Status = status
+	job.Error = reason
+	saveJob(&job)
+
+	if status == models.JobStatusFailed {
+		logrus.Errorf("Job %s failed: %s", job.ID, reason)
+	} else {
+		logrus.Infof("Job %s confirmed with transaction hash %s", job.ID, job.TransactionHash)
+	}
+
+	if status == models.JobStatusFailed {
+		updateVoteReceipt(job, models.ReceiptStatusFailed)
+	} else {
+		updateVoteReceipt(job, models.ReceiptStatusSent)
+	}
+
+	if job.CallbackURL != "" {
+		notifyJobCallback(job)
+	}
+}
+
+// updateVoteReceipt переносит данные транзакции задачи в квитанцию голоса пользователя
+func updateVoteReceipt(job models.Job, status string) {
+	if job.UserVoteID == 0 {
+		return
+	}
+	if err := repository.UpdateUserVoteReceipt(job.UserVoteID, job.TransactionID, job.TransactionHash, status); err != nil {
+		logrus.Errorf("Job %s: failed to update vote receipt %d: %v", job.ID, job.UserVoteID, err)
+	}
+}
+
+// notifyJobCallback отправляет состояние задачи на адрес обратного вызова
+func notifyJobCallback(job models.Job) {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		logrus.Errorf("Job %s: failed to marshal callback payload: %v", job.ID, err)
+		return
+	}
+
+	if err := ValidateCallbackURL(job.CallbackURL); err != nil {
+		logrus.Errorf("Job %s: callback not sent: %v", job.ID, err)
+		return
+	}
+	resp, err := callbackClient.Post(job.CallbackURL, "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		logrus.Errorf("Job %s: failed to send callback: %v", job.ID, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		logrus.Errorf("Job %s: callback responded with status %d", job.ID, resp.StatusCode)
+	}
+}
+
+// newJobID генерирует случайный идентификатор задачи
+func newJobID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,187 @@
+// Package services Реестр участников DAO: разбор списков CSV и JSON, расчет разницы и импорт
+package services
+
+import (
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/utils"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Форматы списков участников
+const (
+	MemberFormatCSV  = "csv"
+	MemberFormatJSON = "json"
+)
+
+// memberCSVHeader заголовок CSV файла реестра участников
+var memberCSVHeader = []string{"wallet_address", "vote_power"}
+
+// ErrInvalidMemberList возвращается при ошибке в импортируемом списке участников
+var ErrInvalidMemberList = errors.New("invalid member list")
+
+// ParseMembers разбирает список участников в формате csv или json, проверяет и нормализует адреса.
+// CSV содержит колонки wallet_address и vote_power; строка заголовка необязательна.
+// JSON - массив объектов {"wallet_address", "vote_power"}.
+func ParseMembers(format string, r io.Reader) ([]models.Member, error) {
+	var members []models.Member
+	switch format {
+	case MemberFormatCSV:
+		reader := csv.NewReader(r)
+		reader.TrimLeadingSpace = true
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMemberList, err)
+		}
+		walletColumn, powerColumn, start := 0, 1, 0
+		if len(records) > 0 {
+			header := map[string]int{}
+			for i, name := range records[0] {
+				header[strings.ToLower(strings.TrimSpace(name))] = i
+			}
+			wallet, hasWallet := header[memberCSVHeader[0]]
+			power, hasPower := header[memberCSVHeader[1]]
+			if hasWallet != hasPower {
+				return nil, fmt.Errorf("%w: header must contain wallet_address and vote_power", ErrInvalidMemberList)
+			}
+			if hasWallet {
+				walletColumn, powerColumn, start = wallet, power, 1
+			}
+		}
+		for i, record := range records[start:] {
+			if len(record) <= walletColumn || len(record) <= powerColumn {
+				return nil, fmt.Errorf("%w: line %d: expected wallet_address and vote_power", ErrInvalidMemberList, start+i+1)
+			}
+			votePower, err := strconv.Atoi(strings.TrimSpace(record[powerColumn]))
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: invalid vote_power %q", ErrInvalidMemberList, start+i+1, record[powerColumn])
+			}
+			members = append(members, models.Member{WalletAddress: strings.TrimSpace(record[walletColumn]), VotePower: votePower})
+		}
+	case MemberFormatJSON:
+		if err := json.NewDecoder(r).Decode(&members); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMemberList, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidMemberList, format)
+	}
+
+	seen := make(map[string]int, len(members))
+	for i := range members {
+		address, err := utils.NormalizeAddress(members[i].WalletAddress)
+		if err != nil {
+			return nil, fmt.Errorf("%w: entry %d: invalid wallet address %q", ErrInvalidMemberList, i+1, members[i].WalletAddress)
+		}
+		if members[i].VotePower <= 0 {
+			return nil, fmt.Errorf("%w: entry %d: vote_power must be positive", ErrInvalidMemberList, i+1)
+		}
+		if first, exists := seen[address]; exists {
+			return nil, fmt.Errorf("%w: entry %d: wallet %s duplicates entry %d", ErrInvalidMemberList, i+1, address, first)
+		}
+		seen[address] = i + 1
+		members[i].WalletAddress = address
+	}
+	return members, nil
+}
+
+// DiffMembers сравнивает текущий реестр с импортируемым списком. Импортируемый список считается
+// полным: участники, которых в нем нет, удаляются.
+func DiffMembers(current, incoming []models.Member) models.MemberImportDiff {
+	diff := models.MemberImportDiff{Added: []models.Member{}, Removed: []models.Member{}, Changed: []models.MemberPowerChange{}}
+	existing := make(map[string]int, len(current))
+	for _, member := range current {
+		existing[member.WalletAddress] = member.VotePower
+	}
+	imported := make(map[string]bool, len(incoming))
+	for _, member := range incoming {
+		imported[member.WalletAddress] = true
+		oldPower, exists := existing[member.WalletAddress]
+		switch {
+		case !exists:
+			diff.Added = append(diff.Added, member)
+		case oldPower != member.VotePower:
+			diff.Changed = append(diff.Changed, models.MemberPowerChange{
+				WalletAddress: member.WalletAddress,
+				OldVotePower:  oldPower,
+				NewVotePower:  member.VotePower,
+			})
+		default:
+			diff.Unchanged++
+		}
+	}
+	for _, member := range current {
+		if !imported[member.WalletAddress] {
+			diff.Removed = append(diff.Removed, member)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].WalletAddress < diff.Added[j].WalletAddress })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].WalletAddress < diff.Changed[j].WalletAddress })
+	return diff
+}
+
+// ImportMembers рассчитывает разницу с текущим реестром и при apply применяет ее в одной транзакции
+// с записью изменений в историю силы голоса
+func ImportMembers(members []models.Member, apply bool, meta models.VoteStrengthChangeMeta) (models.MemberImportDiff, error) {
+	current, err := repository.ListMembers()
+	if err != nil {
+		return models.MemberImportDiff{}, err
+	}
+	diff := DiffMembers(current, members)
+	if !apply {
+		return diff, nil
+	}
+	if err := repository.ApplyMemberDiff(diff, meta); err != nil {
+		return diff, err
+	}
+	diff.Applied = true
+	return diff, nil
+}
+
+// ListMembers возвращает текущий реестр участников
+func ListMembers() ([]models.Member, error) {
+	return repository.ListMembers()
+}
+
+// UpdateMemberPower изменяет силу голоса участника с записью в историю
+func UpdateMemberPower(wallet string, req models.VoteStrengthUpdateRequest, meta models.VoteStrengthChangeMeta) (models.VoteStrengthHistoryEntry, error) {
+	meta.Reason = strings.TrimSpace(req.Reason)
+	return repository.UpdateMemberPower(wallet, req.VotePower, meta)
+}
+
+// GetVoteStrengthHistory возвращает историю изменений силы голоса кошелька
+func GetVoteStrengthHistory(wallet string) ([]models.VoteStrengthHistoryEntry, error) {
+	return repository.GetVoteStrengthHistory(wallet)
+}
+
+// GetVoteStrengthAsOf возвращает силу голоса кошелька на момент asOf
+func GetVoteStrengthAsOf(wallet string, asOf time.Time) (models.VoteStrengthAsOf, error) {
+	votePower, member, err := repository.GetVoteStrengthAsOf(wallet, asOf)
+	if err != nil {
+		return models.VoteStrengthAsOf{}, err
+	}
+	return models.VoteStrengthAsOf{WalletAddress: wallet, AsOf: asOf.UTC(), Member: member, VotePower: votePower}, nil
+}
+
+// WriteMembersCSV записывает реестр участников в CSV с заголовком
+func WriteMembersCSV(w io.Writer, members []models.Member) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(memberCSVHeader); err != nil {
+		return err
+	}
+	for _, member := range members {
+		if err := writer.Write([]string{member.WalletAddress, strconv.Itoa(member.VotePower)}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,148 @@
+// Package services Выгрузка голосований с итогами подсчета для отчетов
+package services
+
+import (
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"sync"
+)
+
+// Статусы голосования в выгрузке
+const (
+	ProposalStatusActive    = "active"    // Требуемое большинство не набрано
+	ProposalStatusCompleted = "completed" // Решение принято или отклонено большинством
+)
+
+// proposalExportBatch количество голосований, выбираемых из базы за один запрос
+const proposalExportBatch = 100
+
+// ProposalExportWorkers количество голосований, итоги которых подсчитываются одновременно при выгрузке
+var ProposalExportWorkers = 4
+
+// ErrInvalidExportFilter возвращается при некорректных условиях выгрузки
+var ErrInvalidExportFilter = errors.New("invalid export filter")
+
+// proposalStatuses соответствие статусов результатов подсчета статусам выгрузки
+var proposalStatuses = map[string]string{
+	VotingStatusActive:    ProposalStatusActive,
+	VotingStatusCompleted: ProposalStatusCompleted,
+}
+
+// ValidateProposalExportFilter проверяет условия выгрузки до начала записи ответа
+func ValidateProposalExportFilter(filter models.ProposalExportFilter) error {
+	switch filter.Status {
+	case "", ProposalStatusActive, ProposalStatusCompleted:
+	default:
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidExportFilter, filter.Status)
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidExportFilter)
+	}
+	return nil
+}
+
+// ExportProposals подсчитывает итоги голосований, созданных в периоде фильтра, и передает их в emit по одному
+// в порядке ID, не загружая все голосования в память. Итоги пачки голосований подсчитываются не более чем
+// в ProposalExportWorkers потоков; квитанции голосов при выгрузке не подтверждаются. Голосование, итоги которого
+// не удалось получить, передается с заполненным Error, только если фильтр статуса не задан.
+// Возвращает количество переданных голосований.
+func ExportProposals(filter models.ProposalExportFilter, emit func(models.ProposalExport) error) (int, error) {
+	if err := ValidateProposalExportFilter(filter); err != nil {
+		return 0, err
+	}
+
+	exported, afterID := 0, 0
+	for {
+		votes, err := repository.ListVotes(filter.From, filter.To, afterID, proposalExportBatch)
+		if err != nil {
+			return exported, err
+		}
+		counted := countProposals(votes)
+		for i, vote := range votes {
+			afterID = vote.ID
+			proposal := proposalExport(vote)
+			if counted[i].err != nil {
+				// Статус голосования без итогов неизвестен, поэтому оно не попадает в выгрузку по статусу
+				if filter.Status != "" {
+					logrus.Warnf("Skipping vote ID %d in export with status %s: %v", vote.ID, filter.Status, counted[i].err)
+					continue
+				}
+				proposal.Error = counted[i].err.Error()
+			} else {
+				withProposalResults(&proposal, counted[i].results)
+				if filter.Status != "" && proposal.Status != filter.Status {
+					continue
+				}
+			}
+			if err := emit(proposal); err != nil {
+				return exported, err
+			}
+			exported++
+		}
+		if len(votes) < proposalExportBatch {
+			return exported, nil
+		}
+	}
+}
+
+// proposalResults итоги подсчета голосования в выгрузке
+type proposalResults struct {
+	results models.VoteResults
+	err     error
+}
+
+// countProposals подсчитывает итоги голосований не более чем в ProposalExportWorkers потоков
+// и возвращает их в порядке голосований
+func countProposals(votes []models.VoteInfo) []proposalResults {
+	counted := make([]proposalResults, len(votes))
+	workers := make(chan struct{}, max(ProposalExportWorkers, 1))
+	var wg sync.WaitGroup
+	for i, vote := range votes {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(i int, vote models.VoteInfo) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			counted[i].results, counted[i].err = countVotes(vote, false)
+		}(i, vote)
+	}
+	wg.Wait()
+	return counted
+}
+
+// proposalExport заполняет описание голосования в выгрузке
+func proposalExport(vote models.VoteInfo) models.ProposalExport {
+	return models.ProposalExport{
+		ID:         vote.ID,
+		Title:      vote.Title,
+		Subtitle:   vote.Subtitle,
+		VoteMode:   vote.VoteMode,
+		BallotType: vote.BallotType,
+		CreatedAt:  vote.CreatedAt,
+		Voters:     []models.ProposalVoter{},
+	}
+}
+
+// withProposalResults дополняет выгрузку итогами подсчета и учтенными голосами участников
+func withProposalResults(proposal *models.ProposalExport, results models.VoteResults) {
+	proposal.Status = proposalStatuses[results.VotingStatus]
+	proposal.Resolution = results.Resolution
+	proposal.DAOMembers = results.DAOMembers
+	proposal.VotedMembers = results.VotedMembers
+	proposal.Turnout = results.Turnout
+	proposal.VotesFor = results.VotesFor
+	proposal.VotesAgainst = results.VotesAgainst
+	proposal.TotalTransactions = results.TotalTransactions
+	for _, tx := range results.ValidTransactions {
+		proposal.Voters = append(proposal.Voters, models.ProposalVoter{
+			Wallet:          tx.From,
+			VotePower:       tx.VotePower,
+			Choice:          ClassifyChoice(tx.Message),
+			TransactionHash: tx.Hash,
+			Source:          tx.Source,
+		})
+	}
+}
@@ -0,0 +1,160 @@
+// Package services Сверка локальных голосов с транзакциями в блокчейне
+package services
+
+import (
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"fmt"
+	"github.com/sirupsen/logrus"
+)
+
+// ReconcileVotes сравнивает голоса из user_votes с транзакциями кошелька голосования.
+// При repair=true локальные записи исправляются по данным блокчейна.
+func ReconcileVotes(voteID int, repair bool) (models.ReconciliationReport, error) {
+	vote, err := repository.GetVoteByID(voteID)
+	if err != nil {
+		return models.ReconciliationReport{}, fmt.Errorf("failed to get vote by ID: %v", err)
+	}
+
+	apiResponse, err := fetchWalletTransactions(vote.WalletAddress)
+	if err != nil {
+		return models.ReconciliationReport{}, err
+	}
+
+	userVotes, err := repository.GetUserVotes(voteID)
+	if err != nil {
+		return models.ReconciliationReport{}, err
+	}
+
+	report := buildReconciliationReport(vote, userVotes, apiResponse.Result.Txs)
+	if repair {
+		repaired, err := repairUserVotes(report)
+		if err != nil {
+			return report, fmt.Errorf("failed to repair user votes: %v", err)
+		}
+		report.Repaired = true
+		report.RepairedRecords = repaired
+		logrus.Infof("Reconciliation of vote %d repaired %d records", voteID, repaired)
+	}
+
+	return report, nil
+}
+
+// buildReconciliationReport сопоставляет локальные голоса и транзакции.
+// Голос сопоставляется с транзакцией по хэшу, а при его отсутствии - по адресу отправителя.
+// Как и при подсчете голосов, голосом отправителя считается его первая транзакция с распознанным выбором.
+func buildReconciliationReport(vote models.VoteInfo, userVotes []models.UserVote, txs []models.Transaction) models.ReconciliationReport {
+	report := models.ReconciliationReport{
+		VoteID:           vote.ID,
+		WalletAddress:    vote.WalletAddress,
+		LocalVotes:       len(userVotes),
+		MissingOnChain:   []models.UserVote{},
+		MissingLocal:     []models.Transaction{},
+		NullVotePowerTxs: []models.Transaction{},
+		ChoiceMismatches: []models.ChoiceMismatch{},
+	}
+
+	byHash := make(map[string]models.Transaction)
+	bySender := make(map[string]models.Transaction)
+	var onChain []models.Transaction
+	for _, tx := range txs {
+		if ClassifyChoice(tx.Message) == "" {
+			continue
+		}
+		byHash[normalizeHash(tx.Hash)] = tx
+		if _, seen := bySender[tx.From]; !seen {
+			bySender[tx.From] = tx
+			onChain = append(onChain, tx)
+		}
+	}
+	report.OnChainVotes = len(onChain)
+
+	matchedHashes := make(map[string]bool)
+	localVoters := make(map[string]bool)
+	for _, userVote := range userVotes {
+		localVoters[userVote.Voter] = true
+
+		tx, found := models.Transaction{}, false
+		if userVote.TransactionHash != "" {
+			tx, found = byHash[normalizeHash(userVote.TransactionHash)]
+		}
+		if !found {
+			tx, found = bySender[userVote.Voter]
+		}
+		if !found {
+			report.MissingOnChain = append(report.MissingOnChain, userVote)
+			continue
+		}
+
+		matchedHashes[normalizeHash(tx.Hash)] = true
+		if ClassifyChoice(userVote.Choice) != ClassifyChoice(tx.Message) {
+			report.ChoiceMismatches = append(report.ChoiceMismatches, models.ChoiceMismatch{
+				UserVote:      userVote,
+				Transaction:   tx,
+				LocalChoice:   userVote.Choice,
+				OnChainChoice: tx.Message,
+			})
+			continue
+		}
+		report.Matched++
+	}
+
+	// Как и при подсчете голосов, транзакции кошельков без силы голоса не считаются голосами участников
+	for _, tx := range onChain {
+		if matchedHashes[normalizeHash(tx.Hash)] || localVoters[tx.From] {
+			continue
+		}
+		if tx.VotePower <= 0 {
+			report.NullVotePowerTxs = append(report.NullVotePowerTxs, tx)
+			continue
+		}
+		report.MissingLocal = append(report.MissingLocal, tx)
+	}
+
+	return report
+}
+
+// repairUserVotes восстанавливает локальные голоса по данным блокчейна.
+// Отсутствующие голоса участников добавляются, расхождения выбора исправляются; голоса без транзакции не удаляются.
+func repairUserVotes(report models.ReconciliationReport) (int, error) {
+	var inserts, updates []models.UserVote
+
+	for _, tx := range report.MissingLocal {
+		inserts = append(inserts, models.UserVote{
+			VoteID:          report.VoteID,
+			Voter:           tx.From,
+			Choice:          choiceLabel(ClassifyChoice(tx.Message)),
+			VotePower:       tx.VotePower,
+			TransactionHash: tx.Hash,
+			Status:          models.ReceiptStatusConfirmed,
+		})
+	}
+
+	for _, mismatch := range report.ChoiceMismatches {
+		userVote := mismatch.UserVote
+		userVote.Choice = choiceLabel(ClassifyChoice(mismatch.Transaction.Message))
+		userVote.TransactionHash = mismatch.Transaction.Hash
+		userVote.Status = models.ReceiptStatusConfirmed
+		updates = append(updates, userVote)
+	}
+
+	if len(inserts) == 0 && len(updates) == 0 {
+		return 0, nil
+	}
+	if err := repository.RepairUserVotes(inserts, updates); err != nil {
+		return 0, err
+	}
+	return len(inserts) + len(updates), nil
+}
+
+// choiceLabel возвращает вариант выбора в том виде, в котором он хранится в user_votes
+func choiceLabel(choice string) string {
+	switch choice {
+	case ChoiceFor:
+		return "За"
+	case ChoiceAgainst:
+		return "Против"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,152 @@
+// Package services Подписанные голоса без транзакций в блокчейне
+package services
+
+import (
+	"crypto/sha256"
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/utils"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SignedVoteMaxSkew допустимое отклонение времени подписи от времени сервера
+var SignedVoteMaxSkew = 10 * time.Minute
+
+var (
+	// ErrSignedVotesNotAccepted возвращается, если голосование принимает только транзакции
+	ErrSignedVotesNotAccepted = errors.New("proposal does not accept signed votes")
+	// ErrInvalidChoice возвращается при нераспознанном варианте выбора
+	ErrInvalidChoice = errors.New("invalid choice")
+	// ErrSignatureExpired возвращается, если время подписи выходит за допустимое окно
+	ErrSignatureExpired = errors.New("signature timestamp is outside of the allowed window")
+	// ErrStaleSignature возвращается, если подпись не новее уже сохраненного голоса кошелька
+	ErrStaleSignature = errors.New("signature is not newer than the stored vote")
+	// ErrSignerMismatch возвращается, если адрес подписанта не совпадает с адресом голосующего
+	ErrSignerMismatch = errors.New("signature does not belong to voter address")
+	// ErrNotDAOMember возвращается, если кошелек отсутствует в vote_strength
+	ErrNotDAOMember = errors.New("wallet is not a DAO member")
+)
+
+// SignedVoteMessage возвращает каноническое сообщение, которое участник подписывает ключом кошелька
+func SignedVoteMessage(voteID int, choice string, timestamp int64) string {
+	return fmt.Sprintf("GODAO vote\nproposal_id: %d\nchoice: %s\ntimestamp: %d", voteID, choice, timestamp)
+}
+
+// SubmitSignedVote проверяет подпись голоса и членство кошелька в DAO, затем сохраняет бюллетень
+// с учетом политики повторного голосования.
+func SubmitSignedVote(vote models.VoteInfo, req models.SignedVoteRequest) (models.SignedVote, error) {
+	signedVote, err := VerifySignedVote(vote, req)
+	if err != nil {
+		return models.SignedVote{}, err
+	}
+	return SaveSignedVote(vote, signedVote)
+}
+
+// VerifySignedVote проверяет подпись голоса и членство кошелька в DAO и возвращает бюллетень для сохранения.
+// Адрес голосующего в бюллетене подтвержден подписью.
+func VerifySignedVote(vote models.VoteInfo, req models.SignedVoteRequest) (models.SignedVote, error) {
+	if (vote.VoteMode != models.VoteModeOffChain && vote.VoteMode != models.VoteModeHybrid) || vote.BallotType == models.BallotCommitReveal {
+		return models.SignedVote{}, ErrSignedVotesNotAccepted
+	}
+	if ClassifyChoice(req.Choice) == "" {
+		return models.SignedVote{}, ErrInvalidChoice
+	}
+
+	signedAt := time.Unix(req.Timestamp, 0)
+	if skew := time.Since(signedAt); skew > SignedVoteMaxSkew || skew < -SignedVoteMaxSkew {
+		return models.SignedVote{}, ErrSignatureExpired
+	}
+
+	signature, err := utils.DecodeBytes(req.Signature)
+	if err != nil {
+		return models.SignedVote{}, utils.ErrInvalidSignature
+	}
+	var publicKey []byte
+	if req.PublicKey != "" {
+		if publicKey, err = utils.DecodeBytes(req.PublicKey); err != nil {
+			return models.SignedVote{}, utils.ErrInvalidPublicKey
+		}
+	}
+
+	message := SignedVoteMessage(vote.ID, req.Choice, req.Timestamp)
+	signer, err := utils.VerifyWalletSignature([]byte(message), signature, publicKey)
+	if err != nil {
+		return models.SignedVote{}, err
+	}
+	if signer != req.Voter {
+		return models.SignedVote{}, ErrSignerMismatch
+	}
+
+	votePower, err := repository.GetVoteStrength(signer)
+	if err != nil {
+		return models.SignedVote{}, ErrNotDAOMember
+	}
+
+	ballotHash := sha256.Sum256(signature)
+	return models.SignedVote{
+		VoteID:     vote.ID,
+		Voter:      signer,
+		Choice:     req.Choice,
+		VotePower:  votePower,
+		Timestamp:  req.Timestamp,
+		Signature:  hex.EncodeToString(signature),
+		PublicKey:  hex.EncodeToString(publicKey),
+		BallotHash: hex.EncodeToString(ballotHash[:]),
+	}, nil
+}
+
+// SaveSignedVote сохраняет проверенный VerifySignedVote бюллетень с учетом политики повторного голосования
+func SaveSignedVote(vote models.VoteInfo, signedVote models.SignedVote) (models.SignedVote, error) {
+	existing, err := repository.GetSignedVoteByVoter(vote.ID, signedVote.Voter)
+	if err == nil {
+		if vote.VoteChangePolicy != models.VoteChangeReplace {
+			return models.SignedVote{}, ErrAlreadyVoted
+		}
+		// Устаревшая подпись не может заменить более новый голос
+		if signedVote.Timestamp <= existing.Timestamp {
+			return models.SignedVote{}, ErrStaleSignature
+		}
+		if err := repository.ReplaceSignedVote(signedVote); err != nil {
+			return models.SignedVote{}, err
+		}
+		signedVote.ID = existing.ID
+		return signedVote, nil
+	}
+	if !errors.Is(err, repository.ErrSignedVoteNotFound) {
+		return models.SignedVote{}, err
+	}
+
+	id, err := repository.AddSignedVote(signedVote)
+	if errors.Is(err, repository.ErrUserVoteExists) {
+		return models.SignedVote{}, ErrAlreadyVoted
+	}
+	if err != nil {
+		return models.SignedVote{}, err
+	}
+	signedVote.ID = id
+	return signedVote, nil
+}
+
+// combineBallots объединяет транзакции и подписанные голоса согласно режиму голосования.
+// Транзакции идут первыми, поэтому в гибридном режиме при двойном голосовании учитывается транзакция.
+func combineBallots(voteMode string, txs []models.Transaction, ballots []models.SignedVote) []models.Transaction {
+	var combined []models.Transaction
+	if voteMode != models.VoteModeOffChain {
+		combined = append(combined, txs...)
+	}
+	if voteMode == models.VoteModeOffChain || voteMode == models.VoteModeHybrid {
+		for _, ballot := range ballots {
+			combined = append(combined, models.Transaction{
+				From:      ballot.Voter,
+				Message:   ballot.Choice,
+				VotePower: ballot.VotePower,
+				Hash:      ballot.BallotHash,
+				Source:    models.TransactionSourceSigned,
+			})
+		}
+	}
+	return combined
+}
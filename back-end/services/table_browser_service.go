@@ -0,0 +1,197 @@
+// Package services Просмотр таблиц базы данных по списку разрешенных таблиц и колонок
+package services
+
+import (
+	"bytes"
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrTableNotBrowsable возвращается, если таблица не входит в список разрешенных для просмотра
+	ErrTableNotBrowsable = errors.New("table is not browsable")
+	// ErrInvalidTableQuery возвращается при недопустимой колонке, сортировке или курсоре
+	ErrInvalidTableQuery = errors.New("invalid table query")
+)
+
+// BrowsableTables таблицы, доступные для просмотра, с колонками и скрытыми колонками.
+// Скрытые колонки не выбираются из базы, их значения заменяются на RedactedValue,
+// по ним нельзя фильтровать и сортировать.
+var BrowsableTables = map[string]models.BrowsableTable{
+	"votes": {Columns: []string{"id", "title", "subtitle", "description", "voter", "choice", "vote_power", "wallet_address",
+		"vote_change_policy", "vote_mode", "ballot_type", "commit_ends_at", "reveal_ends_at", "vote_coin", "min_vote_amount",
+		"created_at"}},
+	"user_votes": {Columns: []string{"id", "vote_id", "voter", "choice", "vote_power", "transaction_id", "transaction_hash",
+		"status", "created_at", "updated_at"}},
+	"signed_votes": {Columns: []string{"id", "vote_id", "voter", "choice", "vote_power", "timestamp", "signature", "public_key",
+		"ballot_hash", "created_at"}},
+	"vote_commitments": {Columns: []string{"id", "vote_id", "voter", "commitment", "vote_power", "choice", "salt", "created_at",
+		"revealed_at"}, Redacted: []string{"salt"}},
+	"vote_strength":         {Columns: []string{"id", "wallet_address", "vote_power"}},
+	"vote_strength_history": {Columns: []string{"id", "wallet_address", "old_vote_power", "new_vote_power", "actor_id", "actor", "reason", "changed_at"}},
+	"vote_power_sync_runs": {Columns: []string{"id", "actor_id", "actor", "applied", "formula", "checked", "discovered", "added",
+		"removed", "changed", "unchanged", "failed", "changes", "errors", "error", "started_at", "finished_at"}},
+	"jobs": {Columns: []string{"id", "type", "status", "user_id", "vote_id", "user_vote_id", "transaction_id", "transaction_hash",
+		"error", "attempts", "callback_url", "created_at", "updated_at"}, Redacted: []string{"callback_url"}},
+	"withdrawals": {Columns: []string{"id", "user_id", "requester", "amount", "coin", "address", "transaction_id", "transaction_hash",
+		"status", "error", "attempts", "created_at", "updated_at", "completed_at", "approvals_required", "expires_at"}},
+	"withdrawal_approvals": {Columns: []string{"id", "withdrawal_id", "admin_id", "admin_wallet", "decision", "comment", "created_at"}},
+	"withdrawal_policies": {Columns: []string{"id", "type", "amount", "coin", "address", "exempt_roles", "enabled", "description",
+		"created_at", "updated_at"}},
+	"withdrawal_policy_rejections": {Columns: []string{"id", "user_id", "requester", "amount", "coin", "address", "policy_id",
+		"policy_type", "reason", "created_at"}},
+	"idempotency_keys": {Columns: []string{"key", "request_hash", "status", "response_status", "response_body", "content_type",
+		"location", "created_at", "expires_at"}, Redacted: []string{"key", "response_body"}},
+	"api_keys": {Columns: []string{"id", "name", "prefix", "key_hash", "scopes", "allowed_ips", "wallet", "created_by", "created_at",
+		"expires_at", "last_used_at", "last_used_ip", "revoked_at"}, Redacted: []string{"key_hash"}},
+	"users": {Columns: []string{"id", "login", "email", "phone", "nick", "locale", "avatar", "wallet", "created_at", "updated_at"},
+		Redacted: []string{"email", "phone"}},
+	"user_roles": {Columns: []string{"user_id", "name", "permissions"}},
+	"user_subscriptions": {Columns: []string{"user_id", "id", "tag", "plan_id", "name", "description", "price", "currency",
+		"trial_period", "trial_interval", "grace_period", "grace_interval", "invoice_period", "invoice_interval", "tier",
+		"starts_at", "ends_at", "created_at", "updated_at"}},
+	"wallet_nonces":   {Columns: []string{"nonce", "wallet", "message", "created_at", "expires_at", "used_at"}, Redacted: []string{"nonce", "message"}},
+	"wallet_sessions": {Columns: []string{"id", "wallet", "token_hash", "created_at", "expires_at", "last_used_at", "revoked_at"}, Redacted: []string{"token_hash"}},
+	"audit_log": {Columns: []string{"id", "actor_id", "actor", "action", "target_type", "target_id", "before_value", "after_value",
+		"request_id", "ip", "created_at"}},
+}
+
+// ListBrowsableTables возвращает таблицы, доступные для просмотра и существующие в базе, по названию
+func ListBrowsableTables() ([]models.BrowsableTable, error) {
+	names, err := repository.GetTableNames()
+	if err != nil {
+		return nil, err
+	}
+	tables := []models.BrowsableTable{}
+	for _, name := range names {
+		if table, exists := BrowsableTables[name]; exists {
+			table.Name = name
+			if table.Redacted == nil {
+				table.Redacted = []string{}
+			}
+			tables = append(tables, table)
+		}
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	return tables, nil
+}
+
+// BrowseTable возвращает страницу строк таблицы из списка разрешенных. Фильтры и сортировка принимаются
+// только по видимым колонкам таблицы, значения скрытых колонок заменяются на RedactedValue.
+func BrowseTable(name string, query models.TableQuery) (models.TablePage, error) {
+	table, exists := BrowsableTables[name]
+	if !exists {
+		return models.TablePage{}, fmt.Errorf("%w: %s", ErrTableNotBrowsable, name)
+	}
+	redacted := make(map[string]bool, len(table.Redacted))
+	for _, column := range table.Redacted {
+		redacted[column] = true
+	}
+	visible := make(map[string]bool, len(table.Columns))
+	sel := models.TableSelect{Table: name, Limit: query.Limit, Offset: query.Offset}
+	for _, column := range table.Columns {
+		if !redacted[column] {
+			visible[column] = true
+			sel.Columns = append(sel.Columns, column)
+		}
+	}
+
+	columns := make([]string, 0, len(query.Filters))
+	for column := range query.Filters {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	for _, column := range columns {
+		if !visible[column] {
+			return models.TablePage{}, fmt.Errorf("%w: cannot filter by column %q", ErrInvalidTableQuery, column)
+		}
+		sel.Filters = append(sel.Filters, models.TableFilter{Column: column, Value: query.Filters[column]})
+	}
+
+	if query.Sort != "" {
+		sel.SortColumn = strings.TrimPrefix(query.Sort, "-")
+		sel.Desc = strings.HasPrefix(query.Sort, "-")
+		if !visible[sel.SortColumn] {
+			return models.TablePage{}, fmt.Errorf("%w: cannot sort by column %q", ErrInvalidTableQuery, sel.SortColumn)
+		}
+	}
+	if query.Cursor != "" {
+		if query.Offset != 0 {
+			return models.TablePage{}, fmt.Errorf("%w: cursor and offset cannot be combined", ErrInvalidTableQuery)
+		}
+		cursor, err := decodeTableCursor(query.Cursor)
+		if err != nil {
+			return models.TablePage{}, fmt.Errorf("%w: invalid cursor", ErrInvalidTableQuery)
+		}
+		sel.After = &cursor
+	}
+
+	rows, next, err := repository.SelectTableRows(sel)
+	if err != nil {
+		return models.TablePage{}, err
+	}
+	for _, row := range rows {
+		for _, column := range table.Redacted {
+			row[column] = RedactedValue
+		}
+	}
+
+	page := models.TablePage{
+		Table:    name,
+		Columns:  table.Columns,
+		Redacted: table.Redacted,
+		Rows:     rows,
+		Limit:    query.Limit,
+		Offset:   query.Offset,
+	}
+	if page.Redacted == nil {
+		page.Redacted = []string{}
+	}
+	if next != nil {
+		if page.NextCursor, err = encodeTableCursor(*next); err != nil {
+			return models.TablePage{}, err
+		}
+	}
+	return page, nil
+}
+
+// encodeTableCursor кодирует позицию строки в непрозрачный курсор
+func encodeTableCursor(cursor models.TableCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeTableCursor разбирает курсор; целые значения сортировки восстанавливаются как int64
+func decodeTableCursor(value string) (models.TableCursor, error) {
+	var cursor models.TableCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&cursor); err != nil {
+		return cursor, err
+	}
+	switch sortValue := cursor.Sort.(type) {
+	case json.Number:
+		if integer, err := sortValue.Int64(); err == nil {
+			cursor.Sort = integer
+		} else if cursor.Sort, err = sortValue.Float64(); err != nil {
+			return cursor, err
+		}
+	case string, nil:
+	default:
+		return cursor, fmt.Errorf("unsupported cursor value %v", sortValue)
+	}
+	return cursor, nil
+}
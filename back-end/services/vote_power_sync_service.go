@@ -0,0 +1,250 @@
+// Package services Синхронизация реестра участников DAO с балансами монеты PRO
+package services
+
+import (
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/utils"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"math"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// VotePowerFormulaConfig правило расчета силы голоса: по умолчанию 1 PRO = 1 голос без ограничения
+var VotePowerFormulaConfig = models.VotePowerFormula{Coin: "pro", Decimals: 18, CoinsPerVote: 1, MinVotePower: 1}
+
+// VotePowerSyncInterval интервал синхронизации по расписанию (0 - только по запросу)
+var VotePowerSyncInterval time.Duration
+
+// votePowerSyncActor автор изменений реестра при синхронизации по расписанию
+const votePowerSyncActor = "vote_power_sync"
+
+// coinHoldersPageSize размер страницы держателей монеты при запросе к эксплореру
+const coinHoldersPageSize = 100
+
+// votePowerSyncMu не допускает одновременных запусков синхронизации
+var votePowerSyncMu sync.Mutex
+
+// LoadVotePowerSyncConfig считывает правило расчета и интервал синхронизации из переменных окружения
+// VOTE_POWER_COIN, VOTE_POWER_DECIMALS, VOTE_POWER_COINS_PER_VOTE, VOTE_POWER_MIN, VOTE_POWER_CAP,
+// VOTE_POWER_DISCOVER и VOTE_POWER_SYNC_INTERVAL
+func LoadVotePowerSyncConfig() error {
+	formula := VotePowerFormulaConfig
+	if value := os.Getenv("VOTE_POWER_COIN"); value != "" {
+		formula.Coin = NormalizeCoin(value)
+	}
+
+	var err error
+	if value := os.Getenv("VOTE_POWER_DECIMALS"); value != "" {
+		if formula.Decimals, err = strconv.Atoi(value); err != nil || formula.Decimals < 0 || formula.Decimals > 36 {
+			return fmt.Errorf("invalid VOTE_POWER_DECIMALS %q", value)
+		}
+	}
+	if value := os.Getenv("VOTE_POWER_COINS_PER_VOTE"); value != "" {
+		if formula.CoinsPerVote, err = strconv.ParseInt(value, 10, 64); err != nil || formula.CoinsPerVote <= 0 {
+			return fmt.Errorf("invalid VOTE_POWER_COINS_PER_VOTE %q", value)
+		}
+	}
+	if value := os.Getenv("VOTE_POWER_MIN"); value != "" {
+		if formula.MinVotePower, err = strconv.Atoi(value); err != nil || formula.MinVotePower <= 0 {
+			return fmt.Errorf("invalid VOTE_POWER_MIN %q", value)
+		}
+	}
+	if value := os.Getenv("VOTE_POWER_CAP"); value != "" {
+		if formula.MaxVotePower, err = strconv.Atoi(value); err != nil || formula.MaxVotePower < 0 {
+			return fmt.Errorf("invalid VOTE_POWER_CAP %q", value)
+		}
+	}
+	if formula.MaxVotePower > 0 && formula.MaxVotePower < formula.MinVotePower {
+		return fmt.Errorf("VOTE_POWER_CAP %d is less than VOTE_POWER_MIN %d", formula.MaxVotePower, formula.MinVotePower)
+	}
+	if value := os.Getenv("VOTE_POWER_DISCOVER"); value != "" {
+		if formula.Discover, err = strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("invalid VOTE_POWER_DISCOVER %q", value)
+		}
+	}
+
+	interval := VotePowerSyncInterval
+	if value := os.Getenv("VOTE_POWER_SYNC_INTERVAL"); value != "" {
+		if interval, err = time.ParseDuration(value); err != nil || interval < 0 {
+			return fmt.Errorf("invalid VOTE_POWER_SYNC_INTERVAL %q", value)
+		}
+	}
+
+	VotePowerFormulaConfig = formula
+	VotePowerSyncInterval = interval
+	return nil
+}
+
+// VotePower рассчитывает силу голоса по балансу монеты в минимальных единицах.
+// Баланс ниже порога min_vote_power дает 0 - кошелек не является участником.
+func VotePower(formula models.VotePowerFormula, amount string) (int, error) {
+	balance, ok := new(big.Int).SetString(amount, 10)
+	if !ok || balance.Sign() < 0 {
+		return 0, fmt.Errorf("invalid %s balance %q", formula.Coin, amount)
+	}
+
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(formula.Decimals)), nil)
+	unit.Mul(unit, big.NewInt(formula.CoinsPerVote))
+	power := new(big.Int).Quo(balance, unit)
+
+	limit := big.NewInt(math.MaxInt32)
+	if formula.MaxVotePower > 0 {
+		limit = big.NewInt(int64(formula.MaxVotePower))
+	}
+	if power.Cmp(limit) > 0 {
+		power = limit
+	}
+	if power.Int64() < int64(formula.MinVotePower) {
+		return 0, nil
+	}
+	return int(power.Int64()), nil
+}
+
+// StartVotePowerSync запускает синхронизацию силы голоса по расписанию, если задан VotePowerSyncInterval
+func StartVotePowerSync() {
+	if VotePowerSyncInterval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(VotePowerSyncInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			run, err := SyncVotePower(true, models.VoteStrengthChangeMeta{Actor: votePowerSyncActor, Reason: "scheduled vote power sync"})
+			if err != nil {
+				logrus.Errorf("Scheduled vote power sync %d failed: %v", run.ID, err)
+				continue
+			}
+			logrus.Infof("Scheduled vote power sync %d: %d added, %d removed, %d changed, %d failed",
+				run.ID, run.Added, run.Removed, run.Changed, run.Failed)
+		}
+	}()
+}
+
+// SyncVotePower пересчитывает силу голоса участников (и держателей монеты при discover) по балансам
+// из эксплорера. Без apply изменения только рассчитываются. Кошельки, баланс которых получить
+// не удалось, остаются в реестре без изменений. Отчет каждого запуска сохраняется.
+func SyncVotePower(apply bool, meta models.VoteStrengthChangeMeta) (models.VotePowerSyncRun, error) {
+	votePowerSyncMu.Lock()
+	defer votePowerSyncMu.Unlock()
+
+	run := models.VotePowerSyncRun{
+		ActorID:   meta.ActorID,
+		Actor:     meta.Actor,
+		Formula:   VotePowerFormulaConfig,
+		Errors:    []models.VotePowerSyncError{},
+		StartedAt: time.Now().UTC(),
+	}
+
+	current, incoming, err := collectVotePower(&run)
+	if err == nil {
+		diff := DiffMembers(current, incoming)
+		if apply {
+			if err = repository.ApplyMemberDiff(diff, meta); err == nil {
+				diff.Applied = true
+			}
+		}
+		run.Applied = diff.Applied
+		run.Added, run.Removed, run.Changed, run.Unchanged = len(diff.Added), len(diff.Removed), len(diff.Changed), diff.Unchanged
+		run.Changes = &diff
+	}
+	if err != nil {
+		run.Error = err.Error()
+	}
+	run.Failed = len(run.Errors)
+	run.FinishedAt = time.Now().UTC()
+
+	id, saveErr := repository.SaveVotePowerSyncRun(run)
+	if saveErr != nil {
+		logrus.Errorf("Failed to save vote power sync report: %v", saveErr)
+	}
+	run.ID = id
+	if err != nil {
+		return run, err
+	}
+	return run, saveErr
+}
+
+// collectVotePower получает балансы участников и держателей монеты и возвращает текущий реестр
+// и рассчитанный список участников
+func collectVotePower(run *models.VotePowerSyncRun) ([]models.Member, []models.Member, error) {
+	formula := run.Formula
+	current, err := repository.ListMembers()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	balances := make(map[string]string)
+	if formula.Discover {
+		for offset := 0; ; offset += coinHoldersPageSize {
+			holders, total, err := Explorer.CoinHolders(formula.Coin, coinHoldersPageSize, offset)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, holder := range holders {
+				address, err := utils.NormalizeAddress(holder.Address)
+				if err != nil {
+					run.Errors = append(run.Errors, models.VotePowerSyncError{WalletAddress: holder.Address, Error: "invalid wallet address"})
+					continue
+				}
+				balances[address] = holder.Amount
+			}
+			if len(holders) < coinHoldersPageSize || offset+len(holders) >= total {
+				break
+			}
+		}
+		run.Discovered = len(balances)
+	}
+
+	failed := make(map[string]bool)
+	for _, member := range current {
+		if _, exists := balances[member.WalletAddress]; exists {
+			continue
+		}
+		amount, err := Explorer.CoinBalance(member.WalletAddress, formula.Coin)
+		if err != nil {
+			run.Errors = append(run.Errors, models.VotePowerSyncError{WalletAddress: member.WalletAddress, Error: err.Error()})
+			failed[member.WalletAddress] = true
+			continue
+		}
+		balances[member.WalletAddress] = amount
+	}
+
+	incoming := []models.Member{}
+	for address, amount := range balances {
+		votePower, err := VotePower(formula, amount)
+		if err != nil {
+			run.Errors = append(run.Errors, models.VotePowerSyncError{WalletAddress: address, Error: err.Error()})
+			failed[address] = true
+			continue
+		}
+		run.Checked++
+		if votePower > 0 {
+			incoming = append(incoming, models.Member{WalletAddress: address, VotePower: votePower})
+		}
+	}
+	for _, member := range current {
+		if failed[member.WalletAddress] {
+			incoming = append(incoming, member)
+		}
+	}
+
+	sort.Slice(run.Errors, func(i, j int) bool { return run.Errors[i].WalletAddress < run.Errors[j].WalletAddress })
+	return current, incoming, nil
+}
+
+// ListVotePowerSyncRuns возвращает сводки запусков синхронизации, начиная с последних
+func ListVotePowerSyncRuns(limit, offset int) ([]models.VotePowerSyncRun, error) {
+	return repository.ListVotePowerSyncRuns(limit, offset)
+}
+
+// GetVotePowerSyncRun возвращает отчет запуска синхронизации
+func GetVotePowerSyncRun(id int) (models.VotePowerSyncRun, error) {
+	return repository.GetVotePowerSyncRun(id)
+}
@@ -6,6 +6,7 @@ import (
 	"dao_vote/back-end/models"
 	"dao_vote/back-end/repository"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"io/ioutil"
@@ -19,6 +20,21 @@ const (
 	percentFactor    = 100 // Фактор для расчета процентов
 )
 
+// Варианты выбора, распознаваемые в сообщениях транзакций
+const (
+	ChoiceFor     = "for"     // Голос "За"
+	ChoiceAgainst = "against" // Голос "Против"
+)
+
+// Статусы голосования в результатах подсчета
+const (
+	VotingStatusActive    = "Активно"   // Требуемое большинство не набрано
+	VotingStatusCompleted = "Завершено" // Решение принято или отклонено большинством
+)
+
+// ExplorerAPIURL базовый адрес API эксплорера Decimal
+var ExplorerAPIURL = "https://mainnet-explorer-api.decimalchain.com/api"
+
 // / Функция для получения количества записей в таблице vote_strength
 func getVoteStrengthCount() (int, error) {
 	var count int
@@ -45,7 +61,7 @@ func FetchVoteResults(walletAddress string, offset int) (models.WithdrawOrderRes
 	log.Printf("Limit set to: %d\n", limit)
 
 	// Формируем URL для запроса к API
-	apiURL := fmt.Sprintf("https://mainnet-explorer-api.decimalchain.com/api/address/%s/txs?limit=%d&offset=%d", walletAddress, limit, offset)
+	apiURL := fmt.Sprintf("%s/address/%s/txs?limit=%d&offset=%d", ExplorerAPIURL, walletAddress, limit, offset)
 	log.Printf("API URL: %s\n", apiURL)
 
 	// Выполняем GET-запрос к API
@@ -93,8 +109,15 @@ func FetchVoteResults(walletAddress string, offset int) (models.WithdrawOrderRes
 	return apiResponse, nil
 }
 
-// PrepareVoteResults - функция для подготовки результатов голосования команды DAO
+// PrepareVoteResults - функция для подготовки результатов голосования команды DAO.
+// Голоса считаются открытыми: режим commit-reveal определяется только типом бюллетеня голосования
+// и не включается сообщениями транзакций.
 func PrepareVoteResults(apiResponse models.WithdrawOrderResponse) models.VoteResults {
+	return tallyVoteResults(apiResponse.Result.Txs)
+}
+
+// tallyVoteResults - функция для подсчета открытых голосов по сообщениям транзакций
+func tallyVoteResults(txs []models.Transaction) models.VoteResults {
 	// Инициализируем списки для различных категорий транзакций
 	validTxs := []models.Transaction{}
 	duplicateTxs := []models.Transaction{}
@@ -103,13 +126,12 @@ func PrepareVoteResults(apiResponse models.WithdrawOrderResponse) models.VoteRes
 	votesFor := []models.Transaction{}
 	votesAgainst := []models.Transaction{}
 	uniqueVoters := make(map[string]bool) // Карта уникальных голосующих
-	totalTransactions := len(apiResponse.Result.Txs)
+	totalTransactions := len(txs)
 
 	// Обрабатываем каждую транзакцию
-	for _, result := range apiResponse.Result.Txs {
+	for _, result := range txs {
 		// Приводим сообщение к нижнему регистру и удаляем лишние пробелы и кавычки
-		message := strings.TrimSpace(strings.ToLower(result.Message))
-		message = strings.Trim(message, `\"`)
+		message := normalizeMessage(result.Message)
 
 		// Логируем детали транзакции
 		log.Printf("Processing transaction from: %s, message: %s, vote power: %d, hash: %s", result.From, message, result.VotePower, result.Hash)
@@ -136,12 +158,12 @@ func PrepareVoteResults(apiResponse models.WithdrawOrderResponse) models.VoteRes
 		}
 
 		// Классификация транзакции на "За" или "Против"
-		switch message {
-		case "да", "дa", "д", "за", "зa", "z":
+		switch ClassifyChoice(message) {
+		case ChoiceFor:
 			votesFor = append(votesFor, result)
 			validTxs = append(validTxs, result)
 			log.Printf("VoteInfo for transaction: %s", result.Hash)
-		case "нет", "н", "против":
+		case ChoiceAgainst:
 			votesAgainst = append(votesAgainst, result)
 			validTxs = append(validTxs, result)
 			log.Printf("VoteInfo against transaction: %s", result.Hash)
@@ -163,13 +185,13 @@ func PrepareVoteResults(apiResponse models.WithdrawOrderResponse) models.VoteRes
 	percentAgainst := calculatePercentage(strengthAgainst, totalVoices)
 
 	// Определяем статус голосования и резолюцию
-	status := "Активно"
+	status := VotingStatusActive
 	resolution := "Решение не принято"
 	if percentFor >= requiredMajority {
-		status = "Завершено"
+		status = VotingStatusCompleted
 		resolution = "Принять изменения"
 	} else if percentAgainst >= requiredMajority {
-		status = "Завершено"
+		status = VotingStatusCompleted
 		resolution = "Отклонить изменения"
 	}
 
@@ -202,6 +224,25 @@ func PrepareVoteResults(apiResponse models.WithdrawOrderResponse) models.VoteRes
 	}
 }
 
+// normalizeMessage - функция для приведения сообщения транзакции к нижнему регистру без пробелов и кавычек
+func normalizeMessage(message string) string {
+	message = strings.TrimSpace(strings.ToLower(message))
+	return strings.Trim(message, `\"`)
+}
+
+// ClassifyChoice - функция для определения варианта голоса по тексту сообщения или выбора.
+// Возвращает ChoiceFor, ChoiceAgainst или пустую строку, если вариант не распознан.
+func ClassifyChoice(message string) string {
+	switch normalizeMessage(message) {
+	case "да", "дa", "д", "за", "зa", "z":
+		return ChoiceFor
+	case "нет", "н", "против":
+		return ChoiceAgainst
+	default:
+		return ""
+	}
+}
+
 // calculateStrength - функция для вычисления общей силы голосов из списка транзакций
 func calculateStrength(votes []models.Transaction) int {
 	strength := 0
@@ -251,6 +292,37 @@ func AddUserVote(vote models.UserVote) (int, error) {
 	return repository.AddUserVote(vote)
 }
 
+// ErrAlreadyVoted возвращается, если кошелек уже голосовал, а политика голосования запрещает менять голос
+var ErrAlreadyVoted = errors.New("user has already voted")
+
+// CastUserVote сохраняет голос пользователя с учетом политики повторного голосования.
+// Голос, транзакция которого не была отправлена (квитанция failed), заменяется при любой политике.
+// Возвращает ID голоса и признак того, что был заменен ранее сохраненный голос.
+func CastUserVote(vote models.VoteInfo, userVote models.UserVote) (int, bool, error) {
+	existing, err := repository.GetUserVoteByVoter(userVote.VoteID, userVote.Voter)
+	if err == nil {
+		if vote.VoteChangePolicy != models.VoteChangeReplace && existing.Status != models.ReceiptStatusFailed {
+			return 0, false, ErrAlreadyVoted
+		}
+		existing.Choice = userVote.Choice
+		existing.VotePower = userVote.VotePower
+		if err := repository.UpdateUserVote(existing); err != nil {
+			return 0, false, err
+		}
+		return existing.VoterID, true, nil
+	}
+	if !errors.Is(err, repository.ErrUserVoteNotFound) {
+		return 0, false, err
+	}
+
+	id, err := repository.AddUserVote(userVote)
+	if errors.Is(err, repository.ErrUserVoteExists) {
+		// Параллельный запрос того же кошелька успел сохранить голос раньше: применяем политику к сохраненному голосу
+		return CastUserVote(vote, userVote)
+	}
+	return id, false, err
+}
+
 // FetchVotes получает результаты голосования по ID голосования
 func FetchVotes(voteID int) (models.VoteResults, error) {
 	// Получаем голосование по ID
@@ -258,31 +330,129 @@ func FetchVotes(voteID int) (models.VoteResults, error) {
 	if err != nil {
 		return models.VoteResults{}, fmt.Errorf("failed to get vote by ID: %v", err)
 	}
+	return countVotes(vote, true)
+}
+
+// countVotes подсчитывает итоги голосования. При confirmReceipts квитанции голосов,
+// транзакции которых найдены в блокчейне, помечаются подтвержденными.
+func countVotes(vote models.VoteInfo, confirmReceipts bool) (models.VoteResults, error) {
+	voteID := vote.ID
+	var err error
 
 	// Логируем адрес кошелька для голосования
 	logrus.Infof("Parsing wallet address for vote ID %d: %s", voteID, vote.WalletAddress)
 
+	var apiResponse models.WithdrawOrderResponse
+	var insufficient []models.Transaction
+	if vote.VoteMode != models.VoteModeOffChain {
+		apiResponse, err = fetchWalletTransactions(vote.WalletAddress)
+		if err != nil {
+			return models.VoteResults{}, err
+		}
+
+		// Подтверждаем квитанции голосов, транзакции которых появились в блокчейне
+		if confirmReceipts {
+			if err := confirmUserVoteReceipts(voteID, apiResponse.Result.Txs); err != nil {
+				logrus.Errorf("Failed to confirm vote receipts for vote ID %d: %v", voteID, err)
+			}
+		}
+
+		// Транзакции без требуемого депозита не учитываются в подсчете
+		apiResponse.Result.Txs, insufficient = filterVoteDeposits(vote, apiResponse.Result.Txs)
+	}
+
+	// В тайном голосовании учитываются только раскрытые обязательства
+	if vote.BallotType == models.BallotCommitReveal {
+		commitments, err := repository.GetCommitments(voteID)
+		if err != nil {
+			return models.VoteResults{}, fmt.Errorf("failed to get commitments: %v", err)
+		}
+		txs := append(apiResponse.Result.Txs, commitmentTransactions(commitments)...)
+		return withInsufficientDeposits(prepareCommitRevealResults(txs, vote.CommitEndsAt, vote.RevealEndsAt), insufficient), nil
+	}
+
+	var ballots []models.SignedVote
+	if vote.VoteMode == models.VoteModeOffChain || vote.VoteMode == models.VoteModeHybrid {
+		ballots, err = repository.GetSignedVotes(voteID)
+		if err != nil {
+			return models.VoteResults{}, fmt.Errorf("failed to get signed votes: %v", err)
+		}
+	}
+
+	// Объединяем транзакции и подписанные голоса согласно режиму голосования
+	apiResponse.Result.Txs = combineBallots(vote.VoteMode, apiResponse.Result.Txs, ballots)
+
+	// Возвращаем обработанные результаты голосования
+	return withInsufficientDeposits(PrepareVoteResults(apiResponse), insufficient), nil
+}
+
+// withInsufficientDeposits добавляет к результатам транзакции, отклоненные правилом депозита
+func withInsufficientDeposits(results models.VoteResults, insufficient []models.Transaction) models.VoteResults {
+	results.InsufficientTxs = insufficient
+	results.TotalTransactions += len(insufficient)
+	return results
+}
+
+// GetUserVote возвращает голос кошелька в голосовании вместе с квитанцией транзакции.
+func GetUserVote(voteID int, voter string) (models.UserVote, error) {
+	return repository.GetUserVoteByVoter(voteID, voter)
+}
+
+// confirmUserVoteReceipts помечает подтвержденными голоса, хэши которых найдены среди транзакций кошелька голосования
+func confirmUserVoteReceipts(voteID int, txs []models.Transaction) error {
+	hashes := make(map[string]bool, len(txs))
+	for _, tx := range txs {
+		hashes[normalizeHash(tx.Hash)] = true
+	}
+
+	userVotes, err := repository.GetUserVotes(voteID)
+	if err != nil {
+		return err
+	}
+
+	for _, userVote := range userVotes {
+		if userVote.Status == models.ReceiptStatusConfirmed || userVote.TransactionHash == "" {
+			continue
+		}
+		if !hashes[normalizeHash(userVote.TransactionHash)] {
+			continue
+		}
+		if err := repository.UpdateUserVoteStatus(userVote.VoterID, models.ReceiptStatusConfirmed); err != nil {
+			return err
+		}
+		logrus.Infof("Vote receipt %d confirmed by transaction %s", userVote.VoterID, userVote.TransactionHash)
+	}
+	return nil
+}
+
+// normalizeHash приводит хэш транзакции к единому виду для сравнения
+func normalizeHash(hash string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(hash)), "0x")
+}
+
+// fetchWalletTransactions получает транзакции кошелька голосования из эксплорера и дополняет их силой голоса
+func fetchWalletTransactions(walletAddress string) (models.WithdrawOrderResponse, error) {
 	// Формируем URL для запроса транзакций кошелька
-	apiURL := fmt.Sprintf("https://mainnet-explorer-api.decimalchain.com/api/address/%s/txs", vote.WalletAddress)
+	apiURL := fmt.Sprintf("%s/address/%s/txs", ExplorerAPIURL, walletAddress)
 	resp, err := http.Get(apiURL)
 	if err != nil {
-		return models.VoteResults{}, fmt.Errorf("error making request: %v", err)
+		return models.WithdrawOrderResponse{}, fmt.Errorf("error making request: %v", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return models.VoteResults{}, fmt.Errorf("received non-200 response code: %d", resp.StatusCode)
+		return models.WithdrawOrderResponse{}, fmt.Errorf("received non-200 response code: %d", resp.StatusCode)
 	}
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return models.VoteResults{}, fmt.Errorf("error reading response body: %v", err)
+		return models.WithdrawOrderResponse{}, fmt.Errorf("error reading response body: %v", err)
 	}
 
 	// Парсим ответ API
 	var apiResponse models.WithdrawOrderResponse
 	if err := json.Unmarshal(body, &apiResponse); err != nil {
-		return models.VoteResults{}, fmt.Errorf("error unmarshalling response body: %v", err)
+		return models.WithdrawOrderResponse{}, fmt.Errorf("error unmarshalling response body: %v", err)
 	}
 
 	// Обновляем силу голосов и хэши для каждой транзакции
@@ -295,6 +465,5 @@ func FetchVotes(voteID int) (models.VoteResults, error) {
 		apiResponse.Result.Txs[i].Hash = result.Hash
 	}
 
-	// Возвращаем обработанные результаты голосования
-	return PrepareVoteResults(apiResponse), nil
+	return apiResponse, nil
 }
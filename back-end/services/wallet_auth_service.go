@@ -0,0 +1,174 @@
+// Package services Вход участников DAO подписью кошелька Decimal: nonce, проверка подписи и сессии
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/utils"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+// WalletSessionPrefix начало значения всех токенов сессий кошелька
+const WalletSessionPrefix = "wsess_"
+
+var (
+	// WalletNonceTTL срок действия nonce для входа подписью кошелька
+	WalletNonceTTL = 5 * time.Minute
+	// WalletSessionTTL срок действия сессии кошелька
+	WalletSessionTTL = 24 * time.Hour
+)
+
+var (
+	ErrInvalidWalletNonce   = errors.New("nonce is invalid, expired or already used")
+	ErrInvalidWalletSession = errors.New("invalid wallet session")
+	ErrWalletSessionExpired = errors.New("wallet session has expired")
+	ErrWalletSessionRevoked = errors.New("wallet session has been revoked")
+)
+
+// WalletSignInMessage возвращает сообщение, которое участник подписывает ключом кошелька для входа
+func WalletSignInMessage(wallet, nonce string, expiresAt int64) string {
+	return fmt.Sprintf("GODAO sign-in\nwallet: %s\nnonce: %s\nexpires_at: %d", wallet, nonce, expiresAt)
+}
+
+// IsWalletSessionToken проверяет, что значение заголовка Authorization содержит токен сессии кошелька
+func IsWalletSessionToken(token string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(token, "Bearer "), WalletSessionPrefix)
+}
+
+// CreateWalletChallenge выдает одноразовый nonce и сообщение для подписи кошельком
+func CreateWalletChallenge(wallet string) (models.WalletChallenge, error) {
+	wallet, err := utils.NormalizeAddress(wallet)
+	if err != nil {
+		return models.WalletChallenge{}, err
+	}
+
+	nonce, err := randomHex(16)
+	if err != nil {
+		return models.WalletChallenge{}, err
+	}
+	now := time.Now().UTC()
+	expiresAt := now.Add(WalletNonceTTL).Truncate(time.Second)
+	challenge := models.WalletChallenge{
+		Wallet:    wallet,
+		Nonce:     nonce,
+		Message:   WalletSignInMessage(wallet, nonce, expiresAt.Unix()),
+		ExpiresAt: expiresAt,
+	}
+
+	if err := repository.DeleteExpiredWalletNonces(now); err != nil {
+		logrus.Errorf("Failed to delete expired wallet nonces: %v", err)
+	}
+	if err := repository.CreateWalletNonce(challenge, now); err != nil {
+		return models.WalletChallenge{}, err
+	}
+	return challenge, nil
+}
+
+// SignInWithWallet проверяет подпись сообщения с nonce и членство кошелька в DAO, затем открывает сессию.
+// Nonce расходуется при любой попытке входа. Токен сессии возвращается только здесь, в базе хранится его sha256.
+func SignInWithWallet(req models.WalletSignInRequest) (models.WalletSignInResponse, error) {
+	wallet, err := utils.NormalizeAddress(req.Wallet)
+	if err != nil {
+		return models.WalletSignInResponse{}, err
+	}
+
+	now := time.Now().UTC()
+	message, err := repository.UseWalletNonce(req.Nonce, wallet, now)
+	if errors.Is(err, repository.ErrWalletNonceNotFound) {
+		return models.WalletSignInResponse{}, ErrInvalidWalletNonce
+	}
+	if err != nil {
+		return models.WalletSignInResponse{}, err
+	}
+
+	signature, err := utils.DecodeBytes(req.Signature)
+	if err != nil {
+		return models.WalletSignInResponse{}, utils.ErrInvalidSignature
+	}
+	var publicKey []byte
+	if req.PublicKey != "" {
+		if publicKey, err = utils.DecodeBytes(req.PublicKey); err != nil {
+			return models.WalletSignInResponse{}, utils.ErrInvalidPublicKey
+		}
+	}
+
+	signer, err := utils.VerifyWalletSignature([]byte(message), signature, publicKey)
+	if err != nil {
+		return models.WalletSignInResponse{}, err
+	}
+	if signer != wallet {
+		return models.WalletSignInResponse{}, ErrSignerMismatch
+	}
+
+	votePower, err := repository.GetVoteStrength(wallet)
+	if err != nil {
+		return models.WalletSignInResponse{}, ErrNotDAOMember
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		return models.WalletSignInResponse{}, err
+	}
+	token := WalletSessionPrefix + secret
+	session := models.WalletSession{Wallet: wallet, CreatedAt: now, ExpiresAt: now.Add(WalletSessionTTL)}
+	if _, err := repository.CreateWalletSession(session, hashWalletSessionToken(token)); err != nil {
+		return models.WalletSignInResponse{}, err
+	}
+
+	return models.WalletSignInResponse{Token: token, Wallet: wallet, VotePower: votePower, ExpiresAt: session.ExpiresAt}, nil
+}
+
+// AuthenticateWalletSession проверяет токен сессии кошелька (с префиксом "Bearer " или без) и записывает последнее использование
+func AuthenticateWalletSession(token string) (models.WalletSession, error) {
+	token = strings.TrimPrefix(token, "Bearer ")
+	session, err := repository.GetWalletSessionByHash(hashWalletSessionToken(token))
+	if errors.Is(err, repository.ErrWalletSessionNotFound) {
+		return session, ErrInvalidWalletSession
+	}
+	if err != nil {
+		return session, err
+	}
+
+	now := time.Now().UTC()
+	switch {
+	case session.RevokedAt != nil:
+		return session, ErrWalletSessionRevoked
+	case !now.Before(session.ExpiresAt):
+		return session, ErrWalletSessionExpired
+	}
+
+	if session.MemberID, err = repository.FirstWalletSessionID(session.Wallet); err != nil {
+		return session, err
+	}
+	if err := repository.TouchWalletSession(session.ID, now); err != nil {
+		logrus.Errorf("Wallet session %d: failed to record last use: %v", session.ID, err)
+	}
+	return session, nil
+}
+
+// RevokeWalletSession завершает сессию кошелька
+func RevokeWalletSession(id int) error {
+	return repository.RevokeWalletSession(id, time.Now())
+}
+
+// hashWalletSessionToken возвращает sha256 токена сессии кошелька
+func hashWalletSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex возвращает n случайных байт в hex
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,111 @@
+// Package services Клиент API вывода средств Decimal Dapps
+package services
+
+import (
+	"bytes"
+	"dao_vote/back-end/models"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// DDAppsAPIURL базовый адрес API Decimal Dapps
+var DDAppsAPIURL = "https://backend.ddapps.io/api/v1"
+
+// ddappsClient HTTP клиент API Decimal Dapps. Запросы выполняются и фоновыми обработчиками,
+// поэтому зависший запрос прерывается по таймауту и не останавливает опрос.
+var ddappsClient = &http.Client{Timeout: 30 * time.Second}
+
+// InitiateWithdrawal отправляет запрос на вывод средств во внешний API
+func InitiateWithdrawal(req models.WithdrawRequest, token string) (models.WithdrawResponse, error) {
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		logrus.Errorf("Failed to marshal JSON: %v", err)
+		return models.WithdrawResponse{}, err
+	}
+
+	logrus.Infof("Initiating HTTP request for withdrawal")
+	httpReq, err := http.NewRequest("POST", DDAppsAPIURL+"/withdraw", bytes.NewBuffer(jsonData))
+	if err != nil {
+		logrus.Errorf("Failed to create new HTTP request: %v", err)
+		return models.WithdrawResponse{}, err
+	}
+
+	httpReq.Header.Set("Authorization", token)
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+
+	// Логгирование содержимого HTTP-запроса
+	logrus.Infof("HTTP Request - URL: %s", httpReq.URL)
+	logrus.Infof("HTTP Request - Method: %s", httpReq.Method)
+	logrus.Infof("HTTP Request - Body: %s", jsonData)
+
+	logrus.Infof("Sending request to external API for withdrawal")
+	resp, err := ddappsClient.Do(httpReq)
+	if err != nil {
+		logrus.Errorf("Failed to send request to external API: %v", err)
+		return models.WithdrawResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		logrus.Errorf("Failed to read response body: %v", err)
+		return models.WithdrawResponse{}, err
+	}
+
+	logrus.Infof("Response from external API: %s", string(body))
+	if resp.StatusCode != http.StatusOK {
+		return models.WithdrawResponse{}, fmt.Errorf("error from external API: %s", string(body))
+	}
+
+	var response models.WithdrawResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		logrus.Errorf("Failed to unmarshal response JSON: %v", err)
+		return models.WithdrawResponse{}, err
+	}
+
+	return response, nil
+}
+
+// GetTransactionHash выполняет запрос для получения хэша транзакции
+func GetTransactionHash(transactionID int, token string) (models.TransactionHashResponse, error) {
+	hashReqURL := fmt.Sprintf("%s/transactions/%d", DDAppsAPIURL, transactionID)
+	hashReq, err := http.NewRequest("GET", hashReqURL, nil)
+	if err != nil {
+		logrus.Errorf("Failed to create new request for transaction hash: %v", err)
+		return models.TransactionHashResponse{}, err
+	}
+	hashReq.Header.Set("Authorization", token)
+	hashReq.Header.Set("Accept", "application/json")
+
+	logrus.Infof("Sending request to external API for transaction hash: %v", hashReqURL)
+	hashResp, err := ddappsClient.Do(hashReq)
+	if err != nil {
+		logrus.Errorf("Failed to send request to external API for transaction hash: %v", err)
+		return models.TransactionHashResponse{}, err
+	}
+	defer hashResp.Body.Close()
+
+	hashBody, err := ioutil.ReadAll(hashResp.Body)
+	if err != nil {
+		logrus.Errorf("Failed to read response body for transaction hash: %v", err)
+		return models.TransactionHashResponse{}, err
+	}
+
+	logrus.Infof("Response from external API for transaction hash: %s", string(hashBody))
+	if hashResp.StatusCode != http.StatusOK {
+		return models.TransactionHashResponse{}, fmt.Errorf("error from external API for transaction hash: %s", string(hashBody))
+	}
+
+	var hashResponse models.TransactionHashResponse
+	if err := json.Unmarshal(hashBody, &hashResponse); err != nil {
+		logrus.Errorf("Failed to unmarshal response JSON for transaction hash: %v", err)
+		return models.TransactionHashResponse{}, err
+	}
+
+	return hashResponse, nil
+}
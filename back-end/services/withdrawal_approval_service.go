@@ -0,0 +1,181 @@
+// Package services Одобрение крупных выводов средств несколькими администраторами
+package services
+
+import (
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Настройки одобрения крупных выводов средств
+var (
+	WithdrawalApprovalThreshold float64          // Сумма, выше которой вывод требует одобрения (0 - одобрение не требуется)
+	WithdrawalApprovalQuorum    = 2              // Количество одобрений администраторов для отправки вывода
+	WithdrawalApprovalTTL       = 48 * time.Hour // Срок ожидания одобрения
+)
+
+var (
+	ErrWithdrawalNotPending = errors.New("withdrawal is not awaiting approval")
+	ErrWithdrawalExpired    = errors.New("withdrawal approval window has expired")
+	ErrSelfApproval         = errors.New("admins cannot decide on their own withdrawals")
+	ErrAlreadyDecided       = errors.New("admin has already decided on this withdrawal")
+)
+
+// LoadWithdrawalApprovalConfig считывает настройки одобрения из переменных окружения
+// WITHDRAWAL_APPROVAL_THRESHOLD, WITHDRAWAL_APPROVAL_QUORUM, WITHDRAWAL_APPROVAL_TTL и WITHDRAWAL_TOKEN_TTL.
+// Одобренные выводы отправляются с сервисным токеном, поэтому порог требует DDAPPS_SERVICE_TOKEN.
+func LoadWithdrawalApprovalConfig() error {
+	if value := os.Getenv("WITHDRAWAL_APPROVAL_THRESHOLD"); value != "" {
+		threshold, err := strconv.ParseFloat(value, 64)
+		if err != nil || threshold < 0 {
+			return fmt.Errorf("invalid WITHDRAWAL_APPROVAL_THRESHOLD %q", value)
+		}
+		WithdrawalApprovalThreshold = threshold
+	}
+	if value := os.Getenv("WITHDRAWAL_APPROVAL_QUORUM"); value != "" {
+		quorum, err := strconv.Atoi(value)
+		if err != nil || quorum < 1 {
+			return fmt.Errorf("invalid WITHDRAWAL_APPROVAL_QUORUM %q", value)
+		}
+		WithdrawalApprovalQuorum = quorum
+	}
+	if value := os.Getenv("WITHDRAWAL_APPROVAL_TTL"); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid WITHDRAWAL_APPROVAL_TTL %q", value)
+		}
+		WithdrawalApprovalTTL = ttl
+	}
+	if value := os.Getenv("WITHDRAWAL_TOKEN_TTL"); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid WITHDRAWAL_TOKEN_TTL %q", value)
+		}
+		WithdrawalTokenTTL = ttl
+	}
+	if WithdrawalApprovalThreshold > 0 && ServiceToken() == "" {
+		return errors.New("WITHDRAWAL_APPROVAL_THRESHOLD requires DDAPPS_SERVICE_TOKEN to submit approved withdrawals")
+	}
+	return nil
+}
+
+// RequiresApproval проверяет, что вывод указанной суммы требует одобрения администраторов
+func RequiresApproval(amount float64) bool {
+	return WithdrawalApprovalThreshold > 0 && amount > WithdrawalApprovalThreshold
+}
+
+// ApproveWithdrawal записывает одобрение администратора.
+// Когда число одобрений достигает кворума, вывод передается во внешний API с сервисным токеном:
+// токен автора хранится только в памяти и может истечь или потеряться при перезапуске за время ожидания.
+func ApproveWithdrawal(admin models.Requester, id int, comment string) (models.Withdrawal, error) {
+	withdrawalMu.Lock()
+	withdrawal, err := recordDecision(admin, id, models.ApprovalDecisionApprove, comment)
+	if err != nil {
+		withdrawalMu.Unlock()
+		return withdrawal, err
+	}
+
+	approvals, err := repository.CountWithdrawalApprovals(id)
+	if err != nil {
+		withdrawalMu.Unlock()
+		return withdrawal, err
+	}
+	if approvals < withdrawal.ApprovalsRequired {
+		withdrawalMu.Unlock()
+		logrus.Infof("Withdrawal %d approved by admin %d (%d of %d)", id, admin.UserID, approvals, withdrawal.ApprovalsRequired)
+		return GetWithdrawal(id)
+	}
+
+	// Кворум достигнут: статус pending исключает повторную отправку последующими решениями
+	withdrawal.Status = models.WithdrawalStatusPending
+	if err := repository.UpdateWithdrawal(withdrawal); err != nil {
+		withdrawalMu.Unlock()
+		return withdrawal, err
+	}
+	withdrawalMu.Unlock()
+
+	logrus.Infof("Withdrawal %d reached approval quorum, submitting", id)
+	token := ServiceToken()
+	if token == "" {
+		withdrawal.Status = models.WithdrawalStatusFailed
+		withdrawal.Error = "service token is not configured to submit approved withdrawal"
+		saveWithdrawal(&withdrawal)
+		return withdrawal, errors.New(withdrawal.Error)
+	}
+	if _, err := submitWithdrawal(withdrawal, token); err != nil {
+		failed, getErr := GetWithdrawal(id)
+		if getErr != nil {
+			return withdrawal, err
+		}
+		return failed, err
+	}
+	return GetWithdrawal(id)
+}
+
+// RejectWithdrawal записывает отклонение администратора; одного отклонения достаточно, чтобы отменить вывод
+func RejectWithdrawal(admin models.Requester, id int, comment string) (models.Withdrawal, error) {
+	withdrawalMu.Lock()
+	defer withdrawalMu.Unlock()
+
+	withdrawal, err := recordDecision(admin, id, models.ApprovalDecisionReject, comment)
+	if err != nil {
+		return withdrawal, err
+	}
+
+	withdrawal.Status = models.WithdrawalStatusRejected
+	withdrawal.Error = "rejected by admin"
+	if err := repository.UpdateWithdrawal(withdrawal); err != nil {
+		return withdrawal, err
+	}
+	forgetWithdrawalToken(id)
+	logrus.Infof("Withdrawal %d rejected by admin %d", id, admin.UserID)
+	return GetWithdrawal(id)
+}
+
+// ExpireWithdrawalApprovals завершает выводы, не получившие кворума одобрений в срок
+func ExpireWithdrawalApprovals() {
+	ids, err := repository.ExpireWithdrawals(time.Now())
+	for _, id := range ids {
+		forgetWithdrawalToken(id)
+		logrus.Infof("Withdrawal %d expired without approval quorum", id)
+	}
+	if err != nil {
+		logrus.Errorf("Failed to expire withdrawals awaiting approval: %v", err)
+	}
+}
+
+// recordDecision проверяет, что администратор может принять решение по выводу, и записывает его.
+// Вызывается под withdrawalMu.
+func recordDecision(admin models.Requester, id int, decision, comment string) (models.Withdrawal, error) {
+	withdrawal, err := repository.GetWithdrawalByID(id)
+	if err != nil {
+		return withdrawal, err
+	}
+	if withdrawal.Status != models.WithdrawalStatusPendingApproval {
+		return withdrawal, ErrWithdrawalNotPending
+	}
+	if withdrawal.ExpiresAt != nil && !time.Now().Before(*withdrawal.ExpiresAt) {
+		ExpireWithdrawalApprovals()
+		return withdrawal, ErrWithdrawalExpired
+	}
+	if withdrawal.UserID == admin.UserID {
+		return withdrawal, ErrSelfApproval
+	}
+
+	err = repository.AddWithdrawalApproval(models.WithdrawalApproval{
+		WithdrawalID: id,
+		AdminID:      admin.UserID,
+		AdminWallet:  admin.Wallet,
+		Decision:     decision,
+		Comment:      comment,
+	})
+	if errors.Is(err, repository.ErrApprovalAlreadyRecorded) {
+		return withdrawal, ErrAlreadyDecided
+	}
+	return withdrawal, err
+}
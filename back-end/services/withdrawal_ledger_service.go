@@ -0,0 +1,259 @@
+// Package services Журнал выводов средств и опрос их статусов
+package services
+
+import (
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"os"
+	"sync"
+	"time"
+)
+
+// WithdrawalPollInterval интервал опроса статусов незавершенных выводов средств
+var WithdrawalPollInterval = 15 * time.Second
+
+// WithdrawalTokenTTL срок хранения в памяти токена автора вывода средств
+var WithdrawalTokenTTL = time.Hour
+
+// withdrawalTokenEntry токен автора вывода средств и срок его хранения
+type withdrawalTokenEntry struct {
+	token     string
+	expiresAt time.Time
+}
+
+// withdrawalTokens хранит в памяти токены пользователей для отправки и опроса их выводов средств
+var withdrawalTokens = struct {
+	sync.Mutex
+	byID map[int]withdrawalTokenEntry
+}{byID: make(map[int]withdrawalTokenEntry)}
+
+// withdrawalTokenUnavailable ошибка вывода, токен автора которого больше не хранится
+const withdrawalTokenUnavailable = "requester token is no longer available to poll transaction status"
+
+// ServiceToken возвращает сервисный токен внешнего API из переменной окружения DDAPPS_SERVICE_TOKEN
+func ServiceToken() string {
+	return os.Getenv("DDAPPS_SERVICE_TOKEN")
+}
+
+// withdrawalMu упорядочивает проверку политик и запись вывода, чтобы параллельные запросы не превысили лимиты
+var withdrawalMu sync.Mutex
+
+// CreateWithdrawal проверяет вывод средств по политикам, записывает его в журнал и передает во внешний API.
+// Вывод выше порога WithdrawalApprovalThreshold записывается со статусом pending_approval и ждет одобрения администраторов,
+// после которого отправляется с сервисным токеном.
+// Монета, не входящая в AllowedCoins, отклоняется с ErrCoinNotAllowed. Отказ политики возвращается как *PolicyViolation без записи в журнал.
+// При ошибке внешнего API запись получает статус failed и возвращается вместе с ошибкой.
+func CreateWithdrawal(requester models.Requester, req models.WithdrawRequest, token string) (models.Withdrawal, error) {
+	coin, err := resolveCoin(req.Coin)
+	if err != nil {
+		return models.Withdrawal{}, err
+	}
+	req.Coin = coin
+
+	withdrawal := models.Withdrawal{
+		UserID:    requester.UserID,
+		Requester: requester.Wallet,
+		Amount:    req.Amount,
+		Coin:      req.Coin,
+		Address:   req.Address,
+		Status:    models.WithdrawalStatusPending,
+	}
+	if RequiresApproval(req.Amount) {
+		expiresAt := time.Now().UTC().Add(WithdrawalApprovalTTL)
+		withdrawal.Status = models.WithdrawalStatusPendingApproval
+		withdrawal.ApprovalsRequired = WithdrawalApprovalQuorum
+		withdrawal.ExpiresAt = &expiresAt
+	}
+
+	withdrawalMu.Lock()
+	if err := EvaluateWithdrawalPolicies(requester, req); err != nil {
+		withdrawalMu.Unlock()
+		return models.Withdrawal{}, err
+	}
+	id, err := repository.CreateWithdrawal(withdrawal)
+	withdrawalMu.Unlock()
+	if err != nil {
+		return models.Withdrawal{}, fmt.Errorf("failed to record withdrawal: %v", err)
+	}
+	withdrawal.ID = id
+
+	if withdrawal.Status == models.WithdrawalStatusPendingApproval {
+		logrus.Infof("Withdrawal %d of %v %s awaits %d admin approvals", withdrawal.ID, withdrawal.Amount, withdrawal.Coin, withdrawal.ApprovalsRequired)
+		return repository.GetWithdrawalByID(withdrawal.ID)
+	}
+	return submitWithdrawal(withdrawal, token)
+}
+
+// submitWithdrawal передает записанный вывод средств во внешний API
+func submitWithdrawal(withdrawal models.Withdrawal, token string) (models.Withdrawal, error) {
+	req := models.WithdrawRequest{Amount: withdrawal.Amount, Address: withdrawal.Address, Coin: withdrawal.Coin}
+	response, err := InitiateWithdrawal(req, token)
+	if err == nil && response.Data.TransactionID == 0 {
+		err = fmt.Errorf("invalid transaction ID in response")
+	}
+	if err != nil {
+		withdrawal.Status = models.WithdrawalStatusFailed
+		withdrawal.Error = err.Error()
+		saveWithdrawal(&withdrawal)
+		forgetWithdrawalToken(withdrawal.ID)
+		return withdrawal, err
+	}
+
+	withdrawal.TransactionID = response.Data.TransactionID
+	withdrawal.Status = models.WithdrawalStatusSubmitted
+	saveWithdrawal(&withdrawal)
+	rememberWithdrawalToken(withdrawal.ID, token)
+
+	return GetWithdrawal(withdrawal.ID)
+}
+
+// GetWithdrawal возвращает вывод средств по ID вместе с решениями администраторов
+func GetWithdrawal(id int) (models.Withdrawal, error) {
+	withdrawal, err := repository.GetWithdrawalByID(id)
+	if err != nil {
+		return withdrawal, err
+	}
+	withdrawal.Approvals, err = repository.GetWithdrawalApprovals(id)
+	return withdrawal, err
+}
+
+// ListWithdrawals возвращает журнал выводов средств по фильтру
+func ListWithdrawals(filter models.WithdrawalFilter) ([]models.Withdrawal, error) {
+	return repository.ListWithdrawals(filter)
+}
+
+// FailInterruptedWithdrawals завершает выводы, прерванные перезапуском до получения ID транзакции
+func FailInterruptedWithdrawals() error {
+	count, err := repository.FailPendingWithdrawals("interrupted by service restart before submission was recorded")
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		logrus.Warnf("Marked %d interrupted withdrawals as failed", count)
+	}
+	return nil
+}
+
+// StartWithdrawalPoller запускает фоновый опрос статусов выводов средств до получения итогового статуса
+func StartWithdrawalPoller() {
+	go func() {
+		ticker := time.NewTicker(WithdrawalPollInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			PollWithdrawals()
+		}
+	}()
+}
+
+// PollWithdrawals выполняет один проход опроса незавершенных выводов средств.
+// Вывод без одобрения опрашивается только с токеном автора из памяти. Вывод, токен которого истек или потерян
+// при перезапуске, не опрашивается: его статус не меняется, а в поле error записывается причина.
+// Одобренный вывод отправлен с сервисным токеном и опрашивается с ним же.
+func PollWithdrawals() {
+	ExpireWithdrawalApprovals()
+	forgetExpiredWithdrawalTokens()
+
+	withdrawals, err := repository.ListUnfinishedWithdrawals()
+	if err != nil {
+		logrus.Errorf("Failed to list unfinished withdrawals: %v", err)
+		return
+	}
+
+	for _, withdrawal := range withdrawals {
+		token := withdrawalToken(withdrawal.ID)
+		if token == "" && withdrawal.ApprovalsRequired > 0 {
+			token = ServiceToken()
+		}
+		if token == "" {
+			if withdrawal.Error != withdrawalTokenUnavailable {
+				logrus.Warnf("Withdrawal %d: %s", withdrawal.ID, withdrawalTokenUnavailable)
+				withdrawal.Error = withdrawalTokenUnavailable
+				saveWithdrawal(&withdrawal)
+			}
+			continue
+		}
+		pollWithdrawal(withdrawal, token)
+	}
+}
+
+// pollWithdrawal запрашивает статус транзакции вывода и продвигает его статус
+func pollWithdrawal(withdrawal models.Withdrawal, token string) {
+	withdrawal.Attempts++
+
+	hashResponse, err := GetTransactionHash(withdrawal.TransactionID, token)
+	if err != nil {
+		logrus.Warnf("Withdrawal %d: attempt %d to retrieve transaction status failed: %v", withdrawal.ID, withdrawal.Attempts, err)
+		saveWithdrawal(&withdrawal)
+		return
+	}
+
+	if hashResponse.Data.Hash != "" {
+		withdrawal.TransactionHash = hashResponse.Data.Hash
+		withdrawal.Status = models.WithdrawalStatusSent
+	}
+	if hashResponse.Data.Complete {
+		if hashResponse.Data.Success {
+			withdrawal.Status = models.WithdrawalStatusCompleted
+		} else {
+			withdrawal.Status = models.WithdrawalStatusFailed
+			withdrawal.Error = fmt.Sprintf("transaction failed: %s", hashResponse.Data.Result)
+		}
+	}
+	saveWithdrawal(&withdrawal)
+
+	if withdrawal.IsFinal() {
+		forgetWithdrawalToken(withdrawal.ID)
+		logrus.Infof("Withdrawal %d finished with status %s", withdrawal.ID, withdrawal.Status)
+	}
+}
+
+// withdrawalToken возвращает токен автора вывода средств или пустую строку, если токен не хранится или истек.
+// Другой токен не подставляется, чтобы вывод не отправлялся и не опрашивался от чужого аккаунта.
+func withdrawalToken(id int) string {
+	withdrawalTokens.Lock()
+	defer withdrawalTokens.Unlock()
+	entry, exists := withdrawalTokens.byID[id]
+	if !exists {
+		return ""
+	}
+	if !time.Now().Before(entry.expiresAt) {
+		delete(withdrawalTokens.byID, id)
+		return ""
+	}
+	return entry.token
+}
+
+// rememberWithdrawalToken сохраняет в памяти токен пользователя для вывода средств на WithdrawalTokenTTL
+func rememberWithdrawalToken(id int, token string) {
+	withdrawalTokens.Lock()
+	withdrawalTokens.byID[id] = withdrawalTokenEntry{token: token, expiresAt: time.Now().Add(WithdrawalTokenTTL)}
+	withdrawalTokens.Unlock()
+}
+
+// forgetExpiredWithdrawalTokens удаляет из памяти истекшие токены выводов средств
+func forgetExpiredWithdrawalTokens() {
+	now := time.Now()
+	withdrawalTokens.Lock()
+	for id, entry := range withdrawalTokens.byID {
+		if !now.Before(entry.expiresAt) {
+			delete(withdrawalTokens.byID, id)
+		}
+	}
+	withdrawalTokens.Unlock()
+}
+
+// forgetWithdrawalToken удаляет из памяти токен пользователя для вывода средств
+func forgetWithdrawalToken(id int) {
+	withdrawalTokens.Lock()
+	delete(withdrawalTokens.byID, id)
+	withdrawalTokens.Unlock()
+}
+
+// saveWithdrawal сохраняет состояние вывода средств
+func saveWithdrawal(withdrawal *models.Withdrawal) {
+	if err := repository.UpdateWithdrawal(*withdrawal); err != nil {
+		logrus.Errorf("Withdrawal %d: failed to update withdrawal: %v", withdrawal.ID, err)
+	}
+}
@@ -0,0 +1,208 @@
+// Package services Политики вывода средств: лимиты, списки адресов и исключения для ролей
+package services
+
+import (
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+// ErrInvalidPolicy возвращается при некорректных параметрах правила политики
+var ErrInvalidPolicy = errors.New("invalid withdrawal policy")
+
+// PolicyViolation описывает правило, отклонившее вывод средств
+type PolicyViolation struct {
+	Policy models.WithdrawalPolicy // Правило, отклонившее вывод
+	Reason string                  // Причина отказа
+}
+
+func (v *PolicyViolation) Error() string {
+	return v.Reason
+}
+
+// CreateWithdrawalPolicy создает правило политики вывода средств
+func CreateWithdrawalPolicy(req models.WithdrawalPolicyRequest) (models.WithdrawalPolicy, error) {
+	policy, err := policyFromRequest(req)
+	if err != nil {
+		return models.WithdrawalPolicy{}, err
+	}
+	id, err := repository.CreateWithdrawalPolicy(policy)
+	if err != nil {
+		return models.WithdrawalPolicy{}, err
+	}
+	return repository.GetWithdrawalPolicyByID(id)
+}
+
+// UpdateWithdrawalPolicy изменяет правило политики вывода средств
+func UpdateWithdrawalPolicy(id int, req models.WithdrawalPolicyRequest) (models.WithdrawalPolicy, error) {
+	policy, err := policyFromRequest(req)
+	if err != nil {
+		return models.WithdrawalPolicy{}, err
+	}
+	policy.ID = id
+	if err := repository.UpdateWithdrawalPolicy(policy); err != nil {
+		return models.WithdrawalPolicy{}, err
+	}
+	return repository.GetWithdrawalPolicyByID(id)
+}
+
+// DeleteWithdrawalPolicy удаляет правило политики вывода средств
+func DeleteWithdrawalPolicy(id int) error {
+	return repository.DeleteWithdrawalPolicy(id)
+}
+
+// ListWithdrawalPolicies возвращает все правила политики вывода средств
+func ListWithdrawalPolicies() ([]models.WithdrawalPolicy, error) {
+	return repository.ListWithdrawalPolicies(false)
+}
+
+// ListPolicyRejections возвращает журнал отклоненных политикой выводов
+func ListPolicyRejections(limit, offset int) ([]models.PolicyRejection, error) {
+	return repository.ListPolicyRejections(limit, offset)
+}
+
+// policyFromRequest проверяет запрос и формирует правило политики
+func policyFromRequest(req models.WithdrawalPolicyRequest) (models.WithdrawalPolicy, error) {
+	policy := models.WithdrawalPolicy{
+		Type:        req.Type,
+		Amount:      req.Amount,
+		Address:     strings.TrimSpace(req.Address),
+		Coin:        NormalizeCoin(req.Coin),
+		ExemptRoles: []string{},
+		Enabled:     req.Enabled == nil || *req.Enabled,
+		Description: req.Description,
+	}
+	for _, role := range req.ExemptRoles {
+		if role = strings.ToLower(strings.TrimSpace(role)); role != "" && !strings.Contains(role, ",") {
+			policy.ExemptRoles = append(policy.ExemptRoles, role)
+		}
+	}
+
+	if policy.Coin != "" && !IsAllowedCoin(policy.Coin) {
+		return policy, fmt.Errorf("%w: coin %s is not allowed", ErrInvalidPolicy, policy.Coin)
+	}
+
+	switch policy.Type {
+	case models.PolicyPerTransactionMax, models.PolicyUserDailyCap, models.PolicyGlobalDailyCap:
+		if policy.Amount <= 0 {
+			return policy, fmt.Errorf("%w: amount must be positive for %s", ErrInvalidPolicy, policy.Type)
+		}
+		policy.Address = ""
+	case models.PolicyAllowlist, models.PolicyDenylist:
+		if policy.Address == "" {
+			return policy, fmt.Errorf("%w: address is required for %s", ErrInvalidPolicy, policy.Type)
+		}
+		policy.Amount = 0
+	default:
+		return policy, fmt.Errorf("%w: unknown type %q", ErrInvalidPolicy, policy.Type)
+	}
+	return policy, nil
+}
+
+// EvaluateWithdrawalPolicies проверяет вывод средств по действующим правилам.
+// Отказ сохраняется в журнал вместе с правилом, которое его вызвало, и возвращается как *PolicyViolation.
+func EvaluateWithdrawalPolicies(requester models.Requester, req models.WithdrawRequest) error {
+	policies, err := repository.ListWithdrawalPolicies(true)
+	if err != nil {
+		return fmt.Errorf("failed to load withdrawal policies: %v", err)
+	}
+
+	violation, err := findPolicyViolation(policies, requester, req, time.Now().UTC())
+	if err != nil || violation == nil {
+		return err
+	}
+
+	logrus.Warnf("Withdrawal of %v %s to %s by user %d rejected by policy %d (%s): %s",
+		req.Amount, req.Coin, req.Address, requester.UserID, violation.Policy.ID, violation.Policy.Type, violation.Reason)
+	if err := repository.AddPolicyRejection(models.PolicyRejection{
+		UserID:     requester.UserID,
+		Requester:  requester.Wallet,
+		Amount:     req.Amount,
+		Coin:       req.Coin,
+		Address:    req.Address,
+		PolicyID:   violation.Policy.ID,
+		PolicyType: violation.Policy.Type,
+		Reason:     violation.Reason,
+	}); err != nil {
+		logrus.Errorf("Failed to record policy rejection: %v", err)
+	}
+	return violation
+}
+
+// findPolicyViolation возвращает первое нарушенное правило: сначала списки адресов, затем лимиты.
+// Правило с указанной монетой применяется только к выводам в этой монете. Дневные лимиты суммируют
+// выводы в монете запроса, так как суммы в разных монетах несопоставимы.
+func findPolicyViolation(policies []models.WithdrawalPolicy, requester models.Requester, req models.WithdrawRequest, now time.Time) (*PolicyViolation, error) {
+	var applicable []models.WithdrawalPolicy
+	for _, policy := range policies {
+		if !isPolicyExempt(policy, requester.Roles) && (policy.Coin == "" || policy.Coin == req.Coin) {
+			applicable = append(applicable, policy)
+		}
+	}
+
+	var allowlist []models.WithdrawalPolicy
+	for _, policy := range applicable {
+		switch policy.Type {
+		case models.PolicyDenylist:
+			if strings.EqualFold(policy.Address, req.Address) {
+				return &PolicyViolation{Policy: policy, Reason: fmt.Sprintf("destination address %s is denied", req.Address)}, nil
+			}
+		case models.PolicyAllowlist:
+			allowlist = append(allowlist, policy)
+		}
+	}
+
+	// Если задан список разрешенных адресов, получатель должен в нем присутствовать
+	if len(allowlist) > 0 {
+		allowed := false
+		for _, policy := range allowlist {
+			if strings.EqualFold(policy.Address, req.Address) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return &PolicyViolation{Policy: allowlist[0], Reason: fmt.Sprintf("destination address %s is not in the allowlist", req.Address)}, nil
+		}
+	}
+
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for _, policy := range applicable {
+		switch policy.Type {
+		case models.PolicyPerTransactionMax:
+			if req.Amount > policy.Amount {
+				return &PolicyViolation{Policy: policy, Reason: fmt.Sprintf("amount %v exceeds the per-transaction maximum of %v", req.Amount, policy.Amount)}, nil
+			}
+		case models.PolicyUserDailyCap, models.PolicyGlobalDailyCap:
+			userID := requester.UserID
+			scope := "user"
+			if policy.Type == models.PolicyGlobalDailyCap {
+				userID, scope = 0, "global"
+			}
+			spent, err := repository.SumWithdrawals(userID, req.Coin, dayStart)
+			if err != nil {
+				return nil, fmt.Errorf("failed to sum withdrawals: %v", err)
+			}
+			if spent+req.Amount > policy.Amount {
+				return &PolicyViolation{Policy: policy, Reason: fmt.Sprintf("amount %v %s exceeds the %s daily cap of %v (already withdrawn today: %v)", req.Amount, req.Coin, scope, policy.Amount, spent)}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// isPolicyExempt проверяет, что у пользователя есть роль, освобожденная от правила
+func isPolicyExempt(policy models.WithdrawalPolicy, roles []string) bool {
+	for _, exempt := range policy.ExemptRoles {
+		for _, role := range roles {
+			if strings.EqualFold(exempt, role) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+// Package utils Проверка и преобразование адресов Decimal
+package utils
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-playground/validator/v10"
+)
+
+// Теги validator/v10 для адресов
+const (
+	DecimalAddressTag      = "decimal_address"        // Только bech32 адрес d0…
+	DecimalOrEVMAddressTag = "decimal_or_evm_address" // bech32 адрес d0… или EVM адрес 0x…
+)
+
+var (
+	ErrInvalidDecimalAddress = errors.New("invalid Decimal address")
+	ErrInvalidEVMAddress     = errors.New("invalid EVM address")
+)
+
+// ValidateDecimalAddress проверяет префикс, контрольную сумму и длину bech32 адреса Decimal
+func ValidateDecimalAddress(address string) error {
+	_, err := decodeDecimalAddress(address)
+	return err
+}
+
+// ValidateEVMAddress проверяет EVM адрес 0x…; адрес в смешанном регистре должен иметь верную контрольную сумму EIP-55
+func ValidateEVMAddress(address string) error {
+	if !common.IsHexAddress(address) || !strings.HasPrefix(address, "0x") {
+		return ErrInvalidEVMAddress
+	}
+	hexPart := address[2:]
+	if hexPart != strings.ToLower(hexPart) && hexPart != strings.ToUpper(hexPart) &&
+		common.HexToAddress(address).Hex() != address {
+		return ErrInvalidEVMAddress
+	}
+	return nil
+}
+
+// DecimalToEVM преобразует bech32 адрес Decimal в EVM адрес с контрольной суммой EIP-55
+func DecimalToEVM(address string) (string, error) {
+	raw, err := decodeDecimalAddress(address)
+	if err != nil {
+		return "", err
+	}
+	return common.BytesToAddress(raw).Hex(), nil
+}
+
+// EVMToDecimal преобразует EVM адрес в bech32 адрес Decimal
+func EVMToDecimal(address string) (string, error) {
+	if err := ValidateEVMAddress(address); err != nil {
+		return "", err
+	}
+	return bech32.ConvertAndEncode(DecimalAddressPrefix, common.HexToAddress(address).Bytes())
+}
+
+// NormalizeAddress принимает адрес Decimal или EVM и возвращает его каноническую форму d0…
+func NormalizeAddress(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if strings.HasPrefix(address, "0x") {
+		return EVMToDecimal(address)
+	}
+	raw, err := decodeDecimalAddress(address)
+	if err != nil {
+		return "", err
+	}
+	return bech32.ConvertAndEncode(DecimalAddressPrefix, raw)
+}
+
+// RegisterAddressValidators регистрирует теги адресов в валидаторе
+func RegisterAddressValidators(v *validator.Validate) error {
+	if err := v.RegisterValidation(DecimalAddressTag, func(fl validator.FieldLevel) bool {
+		return ValidateDecimalAddress(fl.Field().String()) == nil
+	}); err != nil {
+		return err
+	}
+	return v.RegisterValidation(DecimalOrEVMAddressTag, func(fl validator.FieldLevel) bool {
+		_, err := NormalizeAddress(fl.Field().String())
+		return err == nil
+	})
+}
+
+// decodeDecimalAddress декодирует bech32 адрес Decimal в 20 байт адреса
+func decodeDecimalAddress(address string) ([]byte, error) {
+	hrp, raw, err := bech32.DecodeAndConvert(address)
+	if err != nil || hrp != DecimalAddressPrefix || len(raw) != common.AddressLength {
+		return nil, ErrInvalidDecimalAddress
+	}
+	return raw, nil
+}
@@ -0,0 +1,77 @@
+// Package utils Проверка подписей кошельков Decimal
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// DecimalAddressPrefix префикс bech32 адресов сети Decimal
+const DecimalAddressPrefix = "d0"
+
+var (
+	// ErrInvalidSignature возвращается, если подпись не соответствует сообщению или ключу
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrInvalidPublicKey возвращается, если публичный ключ не удалось разобрать
+	ErrInvalidPublicKey = errors.New("invalid public key")
+	// ErrPublicKeyRequired возвращается для подписи без ID восстановления, переданной без публичного ключа
+	ErrPublicKeyRequired = errors.New("public key is required for signature without recovery ID")
+)
+
+// DecodeBytes декодирует значение в формате hex (с префиксом 0x или без) или base64
+func DecodeBytes(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if decoded, err := hex.DecodeString(strings.TrimPrefix(value, "0x")); err == nil {
+		return decoded, nil
+	}
+	return base64.StdEncoding.DecodeString(value)
+}
+
+// VerifyWalletSignature проверяет secp256k1 подпись сообщения ключом кошелька Decimal
+// и возвращает bech32 адрес подписанта. Сообщение хэшируется keccak256, как при подписи
+// ключом кошелька. Если публичный ключ не передан, он восстанавливается из 65-байтовой подписи.
+func VerifyWalletSignature(message, signature, publicKey []byte) (string, error) {
+	if len(signature) != crypto.SignatureLength && len(signature) != crypto.SignatureLength-1 {
+		return "", ErrInvalidSignature
+	}
+	hash := crypto.Keccak256(message)
+
+	if len(publicKey) == 0 {
+		if len(signature) != crypto.SignatureLength {
+			return "", ErrPublicKeyRequired
+		}
+		sig := append([]byte{}, signature...)
+		if sig[crypto.RecoveryIDOffset] >= 27 {
+			sig[crypto.RecoveryIDOffset] -= 27
+		}
+		recovered, err := crypto.SigToPub(hash, sig)
+		if err != nil {
+			return "", ErrInvalidSignature
+		}
+		publicKey = crypto.CompressPubkey(recovered)
+	}
+
+	if !crypto.VerifySignature(publicKey, hash, signature[:crypto.SignatureLength-1]) {
+		return "", ErrInvalidSignature
+	}
+
+	return PublicKeyToAddress(publicKey)
+}
+
+// PublicKeyToAddress возвращает bech32 адрес Decimal для сжатого или несжатого публичного ключа secp256k1
+func PublicKeyToAddress(publicKey []byte) (string, error) {
+	pub, err := crypto.DecompressPubkey(publicKey)
+	if err != nil {
+		pub, err = crypto.UnmarshalPubkey(publicKey)
+		if err != nil {
+			return "", ErrInvalidPublicKey
+		}
+	}
+
+	return bech32.ConvertAndEncode(DecimalAddressPrefix, crypto.PubkeyToAddress(*pub).Bytes())
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"dao_vote/back-end/handlers"
 	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
@@ -14,13 +15,68 @@ import (
 )
 
 func main() {
+	// Применение миграций (до инициализации, чтобы изменения схемы применялись к существующей базе)
+	applyMigrations()
+
 	// Инициализация базы данных
 	if err := repository.InitDB("./votes.db"); err != nil {
 		logrus.Fatalf("Не удалось инициализировать базу данных: %v", err)
 	}
 
-	// Применение миграций
-	applyMigrations()
+	// Завершение фоновых задач, прерванных предыдущим запуском
+	if err := services.FailInterruptedJobs(); err != nil {
+		logrus.Fatalf("Не удалось обработать прерванные задачи: %v", err)
+	}
+	if err := services.FailInterruptedWithdrawals(); err != nil {
+		logrus.Fatalf("Не удалось обработать прерванные выводы средств: %v", err)
+	}
+
+	// Поставщик удостоверений пользователей
+	if err := handlers.LoadIdentityProviderConfig(); err != nil {
+		logrus.Fatalf("Некорректные настройки поставщика удостоверений: %v", err)
+	}
+
+	// Доверенные прокси, по заголовкам которых определяется IP клиента
+	if err := handlers.LoadTrustedProxiesConfig(); err != nil {
+		logrus.Fatalf("Некорректный список доверенных прокси: %v", err)
+	}
+
+	// Переопределение разрешений ролей
+	if err := handlers.LoadPermissionsConfig(); err != nil {
+		logrus.Fatalf("Некорректные настройки разрешений ролей: %v", err)
+	}
+
+	// Правила допуска к действиям по подпискам
+	if err := handlers.LoadEligibilityConfig(); err != nil {
+		logrus.Fatalf("Некорректные правила допуска по подпискам: %v", err)
+	}
+
+	// Монеты, разрешенные для выводов средств и депозитов голосов
+	if err := services.LoadCoinConfig(); err != nil {
+		logrus.Fatalf("Некорректный список разрешенных монет: %v", err)
+	}
+
+	// Настройки одобрения крупных выводов средств
+	if err := services.LoadWithdrawalApprovalConfig(); err != nil {
+		logrus.Fatalf("Некорректные настройки одобрения выводов средств: %v", err)
+	}
+
+	// Адрес API эксплорера и синхронизация силы голоса с балансами PRO
+	if err := services.LoadExplorerConfig(); err != nil {
+		logrus.Fatalf("Некорректный адрес API эксплорера: %v", err)
+	}
+	if err := services.LoadVotePowerSyncConfig(); err != nil {
+		logrus.Fatalf("Некорректные настройки синхронизации силы голоса: %v", err)
+	}
+	if err := services.LoadJobCallbackConfig(); err != nil {
+		logrus.Fatalf("Некорректный список хостов уведомлений: %v", err)
+	}
+
+	// Фоновый опрос статусов выводов средств
+	services.StartWithdrawalPoller()
+
+	// Синхронизация силы голоса по расписанию
+	services.StartVotePowerSync()
 
 	r := setupRouter() // Настраиваем маршруты
 
@@ -67,8 +123,12 @@ func applyMigrations() {
 // setupRouter - это функция, которая настраивает маршруты и возвращает экземпляр gin.Engine.
 func setupRouter() *gin.Engine {
 	r := gin.Default()
+	if err := handlers.ConfigureTrustedProxies(r); err != nil {
+		logrus.Fatalf("Не удалось настроить доверенные прокси: %v", err)
+	}
 
-	// Middleware для логирования запросов
+	// Middleware для идентификатора запроса (X-Request-ID) и логирования запросов
+	r.Use(handlers.RequestIDMiddleware())
 	r.Use(requestLogger())
 
 	// Middleware для CORS
@@ -85,27 +145,80 @@ func setupRouter() *gin.Engine {
 		authRoutes.GET("/get-voting-results-by-wallet", handlers.GetVotingResultsByWallet)
 
 		// Маршруты для пользовательских голосований
-		authRoutes.POST("/votes", handlers.CreateVoteHandler)
+		authRoutes.POST("/votes", handlers.RequirePermission(handlers.PermVotesCreate), handlers.RequireEligibility(handlers.PermVotesCreate), handlers.CreateVoteHandler)
 		authRoutes.GET("/votes/:id", handlers.GetVoteHandler)
-		authRoutes.DELETE("/votes/:id", handlers.DeleteVoteHandler)
-		authRoutes.POST("/votes/:id/vote", handlers.AddUserVoteHandler)
+		authRoutes.DELETE("/votes/:id", handlers.RequirePermission(handlers.PermVotesDelete), handlers.DeleteVoteHandler)
+		authRoutes.POST("/votes/:id/vote", handlers.RequirePermission(handlers.PermVotesVote), handlers.RequireEligibility(handlers.PermVotesVote), handlers.IdempotencyMiddleware(), handlers.AddUserVoteHandler)
 		authRoutes.GET("/votes/:id/votes", handlers.GetUserVotesHandler)
+		authRoutes.GET("/votes/:id/my-vote", handlers.GetMyVoteHandler)
+		authRoutes.POST("/votes/:id/commit", handlers.RequirePermission(handlers.PermVotesVote), handlers.RequireEligibility(handlers.PermVotesVote), handlers.CommitVoteHandler)
+		authRoutes.POST("/votes/:id/reveal", handlers.RequirePermission(handlers.PermVotesVote), handlers.RequireEligibility(handlers.PermVotesVote), handlers.RevealVoteHandler)
+
+		// Маршруты для фоновых задач
+		authRoutes.GET("/jobs/:id", handlers.GetJobHandler)
 
 		// Маршруты для снятия средств
-		authRoutes.POST("/api/v1/withdraw", handlers.WithdrawHandler)
+		authRoutes.POST("/api/v1/withdraw", handlers.RequirePermission(handlers.PermWithdrawCreate), handlers.RequireEligibility(handlers.PermWithdrawCreate), handlers.IdempotencyMiddleware(), handlers.WithdrawHandler)
+		authRoutes.GET("/api/v1/withdraw", handlers.ListWithdrawalsHandler)
+		authRoutes.GET("/api/v1/withdraw/:id", handlers.GetWithdrawalHandler)
+		authRoutes.POST("/api/v1/withdraw/:id/approve", handlers.RequirePermission(handlers.PermWithdrawApprove), handlers.ApproveWithdrawalHandler)
+		authRoutes.POST("/api/v1/withdraw/:id/reject", handlers.RequirePermission(handlers.PermWithdrawApprove), handlers.RejectWithdrawalHandler)
 
 		// Маршруты для получения названий таблиц и элементов в таблице
-		authRoutes.GET("/tables", handlers.GetTableNamesHandler)
-		authRoutes.GET("/tables/:table_name/elements", handlers.GetTableElementsHandler)
+		authRoutes.GET("/tables", handlers.RequirePermission(handlers.PermTablesRead), handlers.GetTableNamesHandler)
+		authRoutes.GET("/tables/:table_name/elements", handlers.RequirePermission(handlers.PermTablesRead), handlers.GetTableElementsHandler)
 
 		// Маршруты для администрирования кошельков
-		authRoutes.POST("/wallets", handlers.AddWalletHandler)
-		authRoutes.DELETE("/wallets/:wallet_address", handlers.DeleteWalletHandler)
+		authRoutes.POST("/wallets", handlers.RequirePermission(handlers.PermWalletsWrite), handlers.AddWalletHandler)
+		authRoutes.DELETE("/wallets/:wallet_address", handlers.RequirePermission(handlers.PermWalletsWrite), handlers.DeleteWalletHandler)
+		authRoutes.POST("/wallets/import", handlers.RequirePermission(handlers.PermWalletsWrite), handlers.ImportMembersHandler)
+		authRoutes.GET("/wallets/export", handlers.RequirePermission(handlers.PermWalletsRead), handlers.ExportMembersHandler)
+		authRoutes.PATCH("/wallets/:wallet_address", handlers.RequirePermission(handlers.PermWalletsWrite), handlers.UpdateWalletHandler)
+		authRoutes.GET("/wallets/:wallet_address/history", handlers.RequirePermission(handlers.PermWalletsRead), handlers.GetWalletHistoryHandler)
+		authRoutes.GET("/wallets/:wallet_address/vote-power", handlers.RequirePermission(handlers.PermWalletsRead), handlers.GetWalletVotePowerHandler)
+
+		// Маршруты для синхронизации силы голоса с балансами PRO
+		authRoutes.POST("/admin/vote-power/sync", handlers.RequirePermission(handlers.PermWalletsWrite), handlers.SyncVotePowerHandler)
+		authRoutes.GET("/admin/vote-power/sync-runs", handlers.RequirePermission(handlers.PermWalletsRead), handlers.ListVotePowerSyncRunsHandler)
+		authRoutes.GET("/admin/vote-power/sync-runs/:id", handlers.RequirePermission(handlers.PermWalletsRead), handlers.GetVotePowerSyncRunHandler)
+
+		// Маршруты для сверки голосов с блокчейном
+		authRoutes.GET("/admin/votes/export", handlers.RequirePermission(handlers.PermVotesExport), handlers.ExportProposalsHandler)
+		authRoutes.GET("/admin/votes/:id/reconciliation", handlers.RequirePermission(handlers.PermVotesReconcile), handlers.GetReconciliationHandler)
+		authRoutes.POST("/admin/votes/:id/reconciliation/repair", handlers.RequirePermission(handlers.PermVotesReconcile), handlers.RepairReconciliationHandler)
+
+		// Маршруты для политик вывода средств
+		authRoutes.GET("/admin/withdrawal-policies", handlers.RequirePermission(handlers.PermWithdrawalPoliciesRead), handlers.ListWithdrawalPoliciesHandler)
+		authRoutes.POST("/admin/withdrawal-policies", handlers.RequirePermission(handlers.PermWithdrawalPoliciesWrite), handlers.CreateWithdrawalPolicyHandler)
+		authRoutes.PUT("/admin/withdrawal-policies/:id", handlers.RequirePermission(handlers.PermWithdrawalPoliciesWrite), handlers.UpdateWithdrawalPolicyHandler)
+		authRoutes.DELETE("/admin/withdrawal-policies/:id", handlers.RequirePermission(handlers.PermWithdrawalPoliciesWrite), handlers.DeleteWithdrawalPolicyHandler)
+		authRoutes.GET("/admin/withdrawal-policies/rejections", handlers.RequirePermission(handlers.PermWithdrawalPoliciesRead), handlers.ListPolicyRejectionsHandler)
+
+		// Маршруты для просмотра сохраненных пользователей
+		authRoutes.GET("/users", handlers.RequirePermission(handlers.PermUsersRead), handlers.ListUsersHandler)
+		authRoutes.GET("/users/:id", handlers.RequirePermission(handlers.PermUsersRead), handlers.GetUserByIDHandler)
+
+		// Маршруты для API ключей сервисного доступа
+		authRoutes.GET("/admin/api-keys", handlers.RequirePermission(handlers.PermAPIKeysManage), handlers.ListAPIKeysHandler)
+		authRoutes.POST("/admin/api-keys", handlers.RequirePermission(handlers.PermAPIKeysManage), handlers.CreateAPIKeyHandler)
+		authRoutes.POST("/admin/api-keys/:id/revoke", handlers.RequirePermission(handlers.PermAPIKeysManage), handlers.RevokeAPIKeyHandler)
+
+		// Маршрут для журнала действий администраторов
+		authRoutes.GET("/admin/audit-log", handlers.RequirePermission(handlers.PermAuditRead), handlers.ListAuditLogHandler)
+
+		// Маршрут для завершения сессии кошелька
+		authRoutes.POST("/auth/wallet/logout", handlers.WalletLogoutHandler)
 	}
 
 	// Маршруты для авторизации (не требуют авторизации)
 	r.POST("/auth/login", handlers.UserLoginHandler)
 	r.GET("/auth/me", handlers.UserMeHandler)
+	r.POST("/auth/wallet/challenge", handlers.WalletChallengeHandler)
+	r.POST("/auth/wallet/login", handlers.WalletSignInHandler)
+
+	// Маршруты для подписанных голосов (подпись кошелька заменяет авторизацию)
+	r.GET("/votes/:id/signed-vote/message", handlers.GetSignedVoteMessageHandler)
+	r.POST("/votes/:id/signed-vote", handlers.SubmitSignedVoteHandler)
 
 	return r
 }
@@ -114,8 +227,9 @@ func setupRouter() *gin.Engine {
 func requestLogger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		logrus.WithFields(logrus.Fields{
-			"method": c.Request.Method,
-			"path":   c.Request.URL.Path,
+			"method":     c.Request.Method,
+			"path":       c.Request.URL.Path,
+			"request_id": handlers.RequestID(c),
 		}).Info("Входящий запрос")
 		c.Next()
 	}
@@ -126,8 +240,8 @@ func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Authorization")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Authorization, X-API-Key, X-Request-ID, Idempotency-Key")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
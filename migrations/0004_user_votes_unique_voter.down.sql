@@ -0,0 +1,8 @@
+-- Функция для отката ограничения одного голоса на кошелек
+DROP INDEX IF EXISTS idx_user_votes_vote_voter;
+
+INSERT INTO user_votes (id, vote_id, voter, choice, vote_power)
+SELECT id, vote_id, voter, choice, vote_power FROM user_votes_duplicates;
+DROP TABLE user_votes_duplicates;
+
+ALTER TABLE votes DROP COLUMN vote_change_policy;
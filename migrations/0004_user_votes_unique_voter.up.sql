@@ -0,0 +1,20 @@
+-- Функция для ограничения одного голоса на кошелек и политики изменения голоса
+-- Повторные голоса кошелька переносятся в user_votes_duplicates, в user_votes остается последний голос
+CREATE TABLE IF NOT EXISTS user_votes_duplicates (
+                                                     id INTEGER PRIMARY KEY,
+                                                     vote_id INTEGER,
+                                                     voter TEXT,
+                                                     choice TEXT,
+                                                     vote_power INTEGER
+);
+
+INSERT INTO user_votes_duplicates (id, vote_id, voter, choice, vote_power)
+SELECT id, vote_id, voter, choice, vote_power FROM user_votes
+WHERE id NOT IN (SELECT MAX(id) FROM user_votes GROUP BY vote_id, voter);
+
+DELETE FROM user_votes
+WHERE id IN (SELECT id FROM user_votes_duplicates);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_votes_vote_voter ON user_votes (vote_id, voter);
+
+ALTER TABLE votes ADD COLUMN vote_change_policy TEXT NOT NULL DEFAULT 'reject';
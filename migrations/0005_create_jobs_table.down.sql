@@ -0,0 +1,2 @@
+-- Функция для отката таблицы jobs
+DROP TABLE jobs;
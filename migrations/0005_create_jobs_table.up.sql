@@ -0,0 +1,16 @@
+-- Функция для создания таблицы фоновых задач jobs
+CREATE TABLE IF NOT EXISTS jobs (
+                                    id TEXT PRIMARY KEY,
+                                    type TEXT NOT NULL,
+                                    status TEXT NOT NULL,
+                                    user_id INTEGER,
+                                    vote_id INTEGER,
+                                    user_vote_id INTEGER,
+                                    transaction_id INTEGER NOT NULL DEFAULT 0,
+                                    transaction_hash TEXT NOT NULL DEFAULT '',
+                                    error TEXT NOT NULL DEFAULT '',
+                                    attempts INTEGER NOT NULL DEFAULT 0,
+                                    callback_url TEXT NOT NULL DEFAULT '',
+                                    created_at DATETIME NOT NULL,
+                                    updated_at DATETIME NOT NULL
+);
@@ -0,0 +1,6 @@
+-- Функция для отката квитанции транзакции в таблице user_votes
+ALTER TABLE user_votes DROP COLUMN updated_at;
+ALTER TABLE user_votes DROP COLUMN created_at;
+ALTER TABLE user_votes DROP COLUMN status;
+ALTER TABLE user_votes DROP COLUMN transaction_hash;
+ALTER TABLE user_votes DROP COLUMN transaction_id;
@@ -0,0 +1,8 @@
+-- Функция для добавления квитанции транзакции в таблицу user_votes
+ALTER TABLE user_votes ADD COLUMN transaction_id INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE user_votes ADD COLUMN transaction_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_votes ADD COLUMN status TEXT NOT NULL DEFAULT 'pending';
+ALTER TABLE user_votes ADD COLUMN created_at DATETIME;
+ALTER TABLE user_votes ADD COLUMN updated_at DATETIME;
+
+UPDATE user_votes SET created_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE created_at IS NULL;
@@ -0,0 +1,4 @@
+-- Функция для отката таблицы signed_votes
+DROP TABLE signed_votes;
+
+ALTER TABLE votes DROP COLUMN vote_mode;
@@ -0,0 +1,16 @@
+-- Функция для создания таблицы подписанных голосов signed_votes и режима приема голосов
+CREATE TABLE IF NOT EXISTS signed_votes (
+                                            id INTEGER PRIMARY KEY AUTOINCREMENT,
+                                            vote_id INTEGER NOT NULL,
+                                            voter TEXT NOT NULL,
+                                            choice TEXT NOT NULL,
+                                            vote_power INTEGER NOT NULL,
+                                            timestamp INTEGER NOT NULL,
+                                            signature TEXT NOT NULL,
+                                            public_key TEXT NOT NULL DEFAULT '',
+                                            ballot_hash TEXT NOT NULL,
+                                            created_at DATETIME NOT NULL,
+                                            UNIQUE (vote_id, voter)
+);
+
+ALTER TABLE votes ADD COLUMN vote_mode TEXT NOT NULL DEFAULT 'onchain';
@@ -0,0 +1,6 @@
+-- Функция для отката таблицы vote_commitments
+DROP TABLE vote_commitments;
+
+ALTER TABLE votes DROP COLUMN reveal_ends_at;
+ALTER TABLE votes DROP COLUMN commit_ends_at;
+ALTER TABLE votes DROP COLUMN ballot_type;
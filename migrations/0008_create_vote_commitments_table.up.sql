@@ -0,0 +1,17 @@
+-- Функция для создания таблицы обязательств тайного голосования vote_commitments и окон commit-reveal
+CREATE TABLE IF NOT EXISTS vote_commitments (
+                                                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                                                vote_id INTEGER NOT NULL,
+                                                voter TEXT NOT NULL,
+                                                commitment TEXT NOT NULL,
+                                                vote_power INTEGER NOT NULL,
+                                                choice TEXT NOT NULL DEFAULT '',
+                                                salt TEXT NOT NULL DEFAULT '',
+                                                created_at DATETIME NOT NULL,
+                                                revealed_at DATETIME,
+                                                UNIQUE (vote_id, voter)
+);
+
+ALTER TABLE votes ADD COLUMN ballot_type TEXT NOT NULL DEFAULT 'open';
+ALTER TABLE votes ADD COLUMN commit_ends_at DATETIME;
+ALTER TABLE votes ADD COLUMN reveal_ends_at DATETIME;
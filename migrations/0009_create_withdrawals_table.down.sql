@@ -0,0 +1,5 @@
+-- Функция для отката таблицы withdrawals
+DROP INDEX IF EXISTS idx_withdrawals_created_at;
+DROP INDEX IF EXISTS idx_withdrawals_status;
+DROP INDEX IF EXISTS idx_withdrawals_user_id;
+DROP TABLE withdrawals;
@@ -0,0 +1,20 @@
+-- Функция для создания таблицы журнала выводов средств withdrawals
+CREATE TABLE IF NOT EXISTS withdrawals (
+                                           id INTEGER PRIMARY KEY AUTOINCREMENT,
+                                           user_id INTEGER NOT NULL,
+                                           requester TEXT NOT NULL DEFAULT '',
+                                           amount REAL NOT NULL,
+                                           address TEXT NOT NULL,
+                                           transaction_id INTEGER NOT NULL DEFAULT 0,
+                                           transaction_hash TEXT NOT NULL DEFAULT '',
+                                           status TEXT NOT NULL,
+                                           error TEXT NOT NULL DEFAULT '',
+                                           attempts INTEGER NOT NULL DEFAULT 0,
+                                           created_at DATETIME NOT NULL,
+                                           updated_at DATETIME NOT NULL,
+                                           completed_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_withdrawals_user_id ON withdrawals (user_id);
+CREATE INDEX IF NOT EXISTS idx_withdrawals_status ON withdrawals (status);
+CREATE INDEX IF NOT EXISTS idx_withdrawals_created_at ON withdrawals (created_at);
@@ -0,0 +1,2 @@
+-- Функция для отката таблицы idempotency_keys
+DROP TABLE idempotency_keys;
@@ -0,0 +1,12 @@
+-- Функция для создания таблицы ключей идемпотентности idempotency_keys
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+                                                key TEXT PRIMARY KEY,
+                                                request_hash TEXT NOT NULL,
+                                                status TEXT NOT NULL,
+                                                response_status INTEGER NOT NULL DEFAULT 0,
+                                                response_body BLOB,
+                                                content_type TEXT NOT NULL DEFAULT '',
+                                                location TEXT NOT NULL DEFAULT '',
+                                                created_at DATETIME NOT NULL,
+                                                expires_at DATETIME NOT NULL
+);
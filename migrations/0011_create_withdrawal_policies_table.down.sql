@@ -0,0 +1,3 @@
+-- Функция для отката таблиц withdrawal_policies и withdrawal_policy_rejections
+DROP TABLE withdrawal_policy_rejections;
+DROP TABLE withdrawal_policies;
@@ -0,0 +1,24 @@
+-- Функция для создания таблиц политик вывода средств withdrawal_policies и журнала отказов withdrawal_policy_rejections
+CREATE TABLE IF NOT EXISTS withdrawal_policies (
+                                                   id INTEGER PRIMARY KEY AUTOINCREMENT,
+                                                   type TEXT NOT NULL,
+                                                   amount REAL NOT NULL DEFAULT 0,
+                                                   address TEXT NOT NULL DEFAULT '',
+                                                   exempt_roles TEXT NOT NULL DEFAULT '',
+                                                   enabled BOOLEAN NOT NULL DEFAULT 1,
+                                                   description TEXT NOT NULL DEFAULT '',
+                                                   created_at DATETIME NOT NULL,
+                                                   updated_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS withdrawal_policy_rejections (
+                                                            id INTEGER PRIMARY KEY AUTOINCREMENT,
+                                                            user_id INTEGER NOT NULL,
+                                                            requester TEXT NOT NULL DEFAULT '',
+                                                            amount REAL NOT NULL,
+                                                            address TEXT NOT NULL,
+                                                            policy_id INTEGER NOT NULL,
+                                                            policy_type TEXT NOT NULL,
+                                                            reason TEXT NOT NULL,
+                                                            created_at DATETIME NOT NULL
+);
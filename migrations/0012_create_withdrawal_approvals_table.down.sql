@@ -0,0 +1,5 @@
+-- Функция для отката таблицы withdrawal_approvals
+DROP TABLE withdrawal_approvals;
+
+ALTER TABLE withdrawals DROP COLUMN expires_at;
+ALTER TABLE withdrawals DROP COLUMN approvals_required;
@@ -0,0 +1,14 @@
+-- Функция для создания таблицы решений администраторов по крупным выводам средств withdrawal_approvals
+CREATE TABLE IF NOT EXISTS withdrawal_approvals (
+                                                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                                                    withdrawal_id INTEGER NOT NULL,
+                                                    admin_id INTEGER NOT NULL,
+                                                    admin_wallet TEXT NOT NULL DEFAULT '',
+                                                    decision TEXT NOT NULL,
+                                                    comment TEXT NOT NULL DEFAULT '',
+                                                    created_at DATETIME NOT NULL,
+                                                    UNIQUE (withdrawal_id, admin_id)
+);
+
+ALTER TABLE withdrawals ADD COLUMN approvals_required INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE withdrawals ADD COLUMN expires_at DATETIME;
@@ -0,0 +1,6 @@
+-- Функция для отката колонок монеты
+ALTER TABLE votes DROP COLUMN min_vote_amount;
+ALTER TABLE votes DROP COLUMN vote_coin;
+ALTER TABLE withdrawal_policies DROP COLUMN coin;
+ALTER TABLE withdrawal_policy_rejections DROP COLUMN coin;
+ALTER TABLE withdrawals DROP COLUMN coin;
@@ -0,0 +1,6 @@
+-- Функция для добавления монеты в выводы средств, политики вывода и правила депозита голосов
+ALTER TABLE withdrawals ADD COLUMN coin TEXT NOT NULL DEFAULT 'del';
+ALTER TABLE withdrawal_policy_rejections ADD COLUMN coin TEXT NOT NULL DEFAULT 'del';
+ALTER TABLE withdrawal_policies ADD COLUMN coin TEXT NOT NULL DEFAULT '';
+ALTER TABLE votes ADD COLUMN vote_coin TEXT NOT NULL DEFAULT '';
+ALTER TABLE votes ADD COLUMN min_vote_amount REAL NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Функция для отката таблицы api_keys
+DROP TABLE api_keys;
@@ -0,0 +1,16 @@
+-- Функция для создания таблицы API ключей сервисного доступа api_keys
+CREATE TABLE IF NOT EXISTS api_keys (
+                                        id INTEGER PRIMARY KEY AUTOINCREMENT,
+                                        name TEXT NOT NULL,
+                                        prefix TEXT NOT NULL,
+                                        key_hash TEXT NOT NULL UNIQUE,
+                                        scopes TEXT NOT NULL DEFAULT '',
+                                        allowed_ips TEXT NOT NULL DEFAULT '',
+                                        wallet TEXT NOT NULL DEFAULT '',
+                                        created_by INTEGER NOT NULL,
+                                        created_at DATETIME NOT NULL,
+                                        expires_at DATETIME,
+                                        last_used_at DATETIME,
+                                        last_used_ip TEXT NOT NULL DEFAULT '',
+                                        revoked_at DATETIME
+);
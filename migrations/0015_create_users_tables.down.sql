@@ -0,0 +1,5 @@
+-- Функция для отката таблиц users, user_roles и user_subscriptions
+DROP TABLE user_subscriptions;
+DROP TABLE user_roles;
+DROP INDEX IF EXISTS idx_users_wallet;
+DROP TABLE users;
@@ -0,0 +1,45 @@
+-- Функция для создания таблиц пользователей users, их ролей user_roles и подписок user_subscriptions
+CREATE TABLE IF NOT EXISTS users (
+                                     id INTEGER PRIMARY KEY,
+                                     login TEXT NOT NULL DEFAULT '',
+                                     email TEXT NOT NULL DEFAULT '',
+                                     phone TEXT NOT NULL DEFAULT '',
+                                     nick TEXT NOT NULL DEFAULT '',
+                                     locale TEXT NOT NULL DEFAULT '',
+                                     avatar TEXT NOT NULL DEFAULT '',
+                                     wallet TEXT NOT NULL DEFAULT '',
+                                     created_at DATETIME NOT NULL,
+                                     updated_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_wallet ON users (wallet);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+                                          user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+                                          name TEXT NOT NULL,
+                                          permissions TEXT NOT NULL DEFAULT '',
+                                          PRIMARY KEY (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS user_subscriptions (
+                                                  user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+                                                  id INTEGER NOT NULL,
+                                                  tag TEXT NOT NULL DEFAULT '',
+                                                  plan_id INTEGER NOT NULL DEFAULT 0,
+                                                  name TEXT NOT NULL DEFAULT '',
+                                                  description TEXT NOT NULL DEFAULT '',
+                                                  price REAL NOT NULL DEFAULT 0,
+                                                  currency TEXT NOT NULL DEFAULT '',
+                                                  trial_period INTEGER NOT NULL DEFAULT 0,
+                                                  trial_interval TEXT NOT NULL DEFAULT '',
+                                                  grace_period INTEGER NOT NULL DEFAULT 0,
+                                                  grace_interval TEXT NOT NULL DEFAULT '',
+                                                  invoice_period INTEGER NOT NULL DEFAULT 0,
+                                                  invoice_interval TEXT NOT NULL DEFAULT '',
+                                                  tier INTEGER NOT NULL DEFAULT 0,
+                                                  starts_at TEXT NOT NULL DEFAULT '',
+                                                  ends_at TEXT NOT NULL DEFAULT '',
+                                                  created_at TEXT NOT NULL DEFAULT '',
+                                                  updated_at TEXT NOT NULL DEFAULT '',
+                                                  PRIMARY KEY (user_id, id)
+);
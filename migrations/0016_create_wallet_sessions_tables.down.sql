@@ -0,0 +1,3 @@
+-- Функция для отката таблиц wallet_sessions и wallet_nonces
+DROP TABLE wallet_sessions;
+DROP TABLE wallet_nonces;
@@ -0,0 +1,21 @@
+-- Функция для создания таблиц входа подписью кошелька: одноразовых nonce wallet_nonces и сессий wallet_sessions
+CREATE TABLE IF NOT EXISTS wallet_nonces (
+                                             nonce TEXT PRIMARY KEY,
+                                             wallet TEXT NOT NULL,
+                                             message TEXT NOT NULL,
+                                             created_at DATETIME NOT NULL,
+                                             expires_at DATETIME NOT NULL,
+                                             used_at DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS wallet_sessions (
+                                               id INTEGER PRIMARY KEY AUTOINCREMENT,
+                                               wallet TEXT NOT NULL,
+                                               token_hash TEXT NOT NULL UNIQUE,
+                                               created_at DATETIME NOT NULL,
+                                               expires_at DATETIME NOT NULL,
+                                               last_used_at DATETIME,
+                                               revoked_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_sessions_wallet ON wallet_sessions (wallet);
@@ -0,0 +1,2 @@
+-- Функция для отката таблицы vote_strength_history
+DROP TABLE vote_strength_history;
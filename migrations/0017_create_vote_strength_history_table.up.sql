@@ -0,0 +1,13 @@
+-- Функция для создания таблицы истории изменений силы голоса vote_strength_history
+CREATE TABLE IF NOT EXISTS vote_strength_history (
+                                                     id INTEGER PRIMARY KEY AUTOINCREMENT,
+                                                     wallet_address TEXT NOT NULL,
+                                                     old_vote_power INTEGER,
+                                                     new_vote_power INTEGER,
+                                                     actor_id INTEGER NOT NULL DEFAULT 0,
+                                                     actor TEXT NOT NULL DEFAULT '',
+                                                     reason TEXT NOT NULL DEFAULT '',
+                                                     changed_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_vote_strength_history_wallet ON vote_strength_history (wallet_address, changed_at);
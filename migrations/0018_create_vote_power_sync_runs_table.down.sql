@@ -0,0 +1,2 @@
+-- Функция для отката таблицы vote_power_sync_runs
+DROP TABLE vote_power_sync_runs;
@@ -0,0 +1,20 @@
+-- Функция для создания таблицы отчетов синхронизации силы голоса с балансами PRO vote_power_sync_runs
+CREATE TABLE IF NOT EXISTS vote_power_sync_runs (
+                                                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                                                    actor_id INTEGER NOT NULL DEFAULT 0,
+                                                    actor TEXT NOT NULL DEFAULT '',
+                                                    applied BOOLEAN NOT NULL DEFAULT FALSE,
+                                                    formula TEXT NOT NULL,
+                                                    checked INTEGER NOT NULL DEFAULT 0,
+                                                    discovered INTEGER NOT NULL DEFAULT 0,
+                                                    added INTEGER NOT NULL DEFAULT 0,
+                                                    removed INTEGER NOT NULL DEFAULT 0,
+                                                    changed INTEGER NOT NULL DEFAULT 0,
+                                                    unchanged INTEGER NOT NULL DEFAULT 0,
+                                                    failed INTEGER NOT NULL DEFAULT 0,
+                                                    changes TEXT NOT NULL DEFAULT '{}',
+                                                    errors TEXT NOT NULL DEFAULT '[]',
+                                                    error TEXT NOT NULL DEFAULT '',
+                                                    started_at DATETIME NOT NULL,
+                                                    finished_at DATETIME NOT NULL
+);
@@ -0,0 +1,4 @@
+-- Функция для отката таблицы audit_log
+DROP TRIGGER IF EXISTS audit_log_no_delete;
+DROP TRIGGER IF EXISTS audit_log_no_update;
+DROP TABLE audit_log;
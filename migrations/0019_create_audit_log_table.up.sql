@@ -0,0 +1,27 @@
+-- Функция для создания журнала действий администраторов audit_log (только добавление записей)
+CREATE TABLE IF NOT EXISTS audit_log (
+                                         id INTEGER PRIMARY KEY AUTOINCREMENT,
+                                         actor_id INTEGER NOT NULL DEFAULT 0,
+                                         actor TEXT NOT NULL DEFAULT '',
+                                         action TEXT NOT NULL,
+                                         target_type TEXT NOT NULL DEFAULT '',
+                                         target_id TEXT NOT NULL DEFAULT '',
+                                         before_value TEXT,
+                                         after_value TEXT,
+                                         request_id TEXT NOT NULL DEFAULT '',
+                                         ip TEXT NOT NULL DEFAULT '',
+                                         created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log (action, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log (target_type, target_id);
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
@@ -0,0 +1,3 @@
+-- Функция для отката времени создания голосования в таблице votes
+DROP INDEX IF EXISTS idx_votes_created_at;
+ALTER TABLE votes DROP COLUMN created_at;
@@ -0,0 +1,5 @@
+-- Функция для добавления времени создания голосования в таблицу votes
+-- У существующих голосований время создания неизвестно и остается NULL
+ALTER TABLE votes ADD COLUMN created_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_votes_created_at ON votes (created_at);
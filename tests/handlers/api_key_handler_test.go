@@ -0,0 +1,89 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"dao_vote/back-end/handlers"
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAPIKeyClientIP проверяет, что ограничение API ключа по IP нельзя обойти заголовком X-Forwarded-For
+// без доверенного прокси
+func TestAPIKeyClientIP(t *testing.T) {
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+	created, err := services.CreateAPIKey(models.APIKeyRequest{Name: "bot", Scopes: []string{handlers.PermTablesRead}, AllowedIPs: []string{"203.0.113.7"}}, 1)
+	require.NoError(t, err)
+	defer func(proxies []string) { handlers.TrustedProxies = proxies }(handlers.TrustedProxies)
+
+	send := func(proxies []string) int {
+		handlers.TrustedProxies = proxies
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		require.NoError(t, handlers.ConfigureTrustedProxies(router))
+		router.Use(handlers.AuthMiddleware())
+		router.GET("/tables", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		req, _ := http.NewRequest("GET", "/tables", nil)
+		req.Header.Set(handlers.APIKeyHeader, created.Key)
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		req.RemoteAddr = "198.51.100.1:5000"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, send(nil))
+	assert.Equal(t, http.StatusOK, send([]string{"198.51.100.0/24"}))
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.1, 192.168.0.0/16")
+	require.NoError(t, handlers.LoadTrustedProxiesConfig())
+	assert.Equal(t, []string{"10.0.0.1", "192.168.0.0/16"}, handlers.TrustedProxies)
+	t.Setenv("TRUSTED_PROXIES", "proxy.local")
+	assert.Error(t, handlers.LoadTrustedProxiesConfig())
+}
+
+// TestAuthMiddlewareAcceptsAPIKey проверяет авторизацию сервиса API ключом и ограничение разрешениями ключа
+func TestAuthMiddlewareAcceptsAPIKey(t *testing.T) {
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+	created, err := services.CreateAPIKey(models.APIKeyRequest{Name: "report", Scopes: []string{handlers.PermTablesRead}}, 1)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(handlers.AuthMiddleware())
+	router.GET("/tables", handlers.RequirePermission(handlers.PermTablesRead), func(c *gin.Context) {
+		user, _ := c.Get("user")
+		c.JSON(http.StatusOK, gin.H{"id": user.(handlers.User).ID})
+	})
+	router.POST("/votes", handlers.RequirePermission(handlers.PermVotesCreate), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	send := func(method, path, key string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set(handlers.APIKeyHeader, key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("GET", "/tables", created.Key)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"id":-1}`, w.Body.String())
+
+	// Разрешения по умолчанию на API ключ не распространяются
+	assert.Equal(t, http.StatusForbidden, send("POST", "/votes", created.Key).Code)
+	assert.Equal(t, http.StatusUnauthorized, send("GET", "/tables", "dao_unknown").Code)
+
+	_, err = services.RevokeAPIKey(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, send("GET", "/tables", created.Key).Code)
+}
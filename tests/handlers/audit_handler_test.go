@@ -0,0 +1,89 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"dao_vote/back-end/handlers"
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAuditLog проверяет запись действий администратора с ID запроса и IP, удаление секретов и фильтры журнала
+func TestAuditLog(t *testing.T) {
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+	wallet, _ := utils.NormalizeAddress("0x0000000000000000000000000000000000000001")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(handlers.RequestIDMiddleware())
+	router.Use(func(c *gin.Context) { c.Set("user", handlers.User{ID: 3, Login: "admin"}) })
+	router.POST("/wallets", handlers.AddWalletHandler)
+	router.DELETE("/wallets/:wallet_address", handlers.DeleteWalletHandler)
+	router.POST("/admin/api-keys", handlers.CreateAPIKeyHandler)
+	router.GET("/admin/audit-log", handlers.ListAuditLogHandler)
+
+	send := func(method, path, requestID, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer secret-token")
+		if requestID != "" {
+			req.Header.Set(handlers.RequestIDHeader, requestID)
+		}
+		req.RemoteAddr = "203.0.113.7:5000"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("POST", "/wallets", "req-add", `{"wallet_address":"`+wallet+`","vote_power":10}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "req-add", w.Header().Get(handlers.RequestIDHeader))
+	require.Equal(t, http.StatusOK, send("DELETE", "/wallets/"+wallet, "", "").Code)
+	w = send("POST", "/admin/api-keys", "bad id with spaces", `{"name":"bot","scopes":["votes.vote"]}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Len(t, w.Header().Get(handlers.RequestIDHeader), 32, "некорректный ID запроса заменяется сгенерированным")
+
+	list := func(query string) []models.AuditEntry {
+		w := send("GET", "/admin/audit-log"+query, "", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Entries []models.AuditEntry `json:"entries"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Entries
+	}
+
+	entries := list("")
+	require.Len(t, entries, 3)
+	assert.Equal(t, "api_key.create", entries[0].Action)
+	assert.NotContains(t, string(entries[0].After), `"key":"dvk_`)
+	assert.Contains(t, string(entries[0].After), `"key":"[REDACTED]"`)
+	assert.JSONEq(t, `null`, string(entries[0].Before))
+
+	entries = list("?target_type=wallet&target_id=" + wallet)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "wallet.delete", entries[0].Action)
+	assert.JSONEq(t, `{"wallet_address":"`+wallet+`","vote_power":10}`, string(entries[0].Before))
+	assert.JSONEq(t, `null`, string(entries[0].After))
+	assert.Equal(t, "wallet.add", entries[1].Action)
+	assert.Equal(t, 3, entries[1].ActorID)
+	assert.Equal(t, "admin", entries[1].Actor)
+	assert.Equal(t, "req-add", entries[1].RequestID)
+	assert.Equal(t, "203.0.113.7", entries[1].IP)
+
+	assert.Len(t, list("?request_id=req-add"), 1)
+	assert.Len(t, list("?action=wallet.delete&limit=1"), 1)
+	assert.Len(t, list("?limit=1&offset=1"), 1)
+	assert.Empty(t, list("?actor_id=4"))
+	assert.Empty(t, list("?to=2000-01-01"))
+	assert.Equal(t, http.StatusBadRequest, send("GET", "/admin/audit-log?from=yesterday", "", "").Code)
+}
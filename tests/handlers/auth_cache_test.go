@@ -0,0 +1,128 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"dao_vote/back-end/handlers"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuthService имитирует /auth/me: токен "valid" принадлежит пользователю, остальные отклоняются.
+// Пока down установлен, сервис отвечает 500.
+func fakeAuthService(t *testing.T, calls *int32, down *atomic.Bool, delay time.Duration) {
+	t.Helper()
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		time.Sleep(delay)
+		switch {
+		case down != nil && down.Load():
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.Header.Get("Authorization") == "Bearer valid-"+t.Name():
+			w.Write([]byte(`{"ok":true,"data":{"id":7,"wallet":"d01voter"}}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	oldURL := services.DDAppsAPIURL
+	services.DDAppsAPIURL = server.URL
+	t.Cleanup(func() { services.DDAppsAPIURL = oldURL })
+}
+
+// newAuthRouter создает роутер с AuthMiddleware, возвращающий кошелек пользователя из контекста
+func newAuthRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/me", handlers.AuthMiddleware(), func(c *gin.Context) {
+		user, _ := c.Get("user")
+		c.JSON(http.StatusOK, gin.H{"wallet": user.(handlers.User).Wallet})
+	})
+	return router
+}
+
+// sendAuth отправляет запрос с токеном
+func sendAuth(router *gin.Engine, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/me", nil)
+	req.Header.Set("Authorization", token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// setAuthCacheTTL задает сроки хранения кэша на время теста
+func setAuthCacheTTL(t *testing.T, ttl, negativeTTL, staleTTL time.Duration) {
+	t.Helper()
+	oldTTL, oldNegative, oldStale := handlers.AuthCacheTTL, handlers.AuthCacheNegativeTTL, handlers.AuthCacheStaleTTL
+	handlers.AuthCacheTTL, handlers.AuthCacheNegativeTTL, handlers.AuthCacheStaleTTL = ttl, negativeTTL, staleTTL
+	t.Cleanup(func() {
+		handlers.AuthCacheTTL, handlers.AuthCacheNegativeTTL, handlers.AuthCacheStaleTTL = oldTTL, oldNegative, oldStale
+	})
+}
+
+// TestAuthCacheReusesVerification проверяет, что повторные и параллельные запросы проверяют токен один раз
+func TestAuthCacheReusesVerification(t *testing.T) {
+	var calls int32
+	fakeAuthService(t, &calls, nil, 50*time.Millisecond)
+	setAuthCacheTTL(t, time.Minute, time.Minute, time.Minute)
+	router := newAuthRouter()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := sendAuth(router, "valid-"+t.Name())
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.JSONEq(t, `{"wallet":"d01voter"}`, w.Body.String())
+		}()
+	}
+	wg.Wait()
+	sendAuth(router, "valid-"+t.Name())
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// TestAuthCacheNegative проверяет кэширование отказа для недействительного токена
+func TestAuthCacheNegative(t *testing.T) {
+	var calls int32
+	fakeAuthService(t, &calls, nil, 0)
+	setAuthCacheTTL(t, time.Minute, time.Minute, time.Minute)
+	router := newAuthRouter()
+
+	for i := 0; i < 3; i++ {
+		w := sendAuth(router, "invalid-"+t.Name())
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// TestAuthCacheServesStaleWhenServiceDown проверяет использование просроченной записи при недоступности сервиса
+func TestAuthCacheServesStaleWhenServiceDown(t *testing.T) {
+	var calls int32
+	var down atomic.Bool
+	fakeAuthService(t, &calls, &down, 0)
+	setAuthCacheTTL(t, 10*time.Millisecond, time.Minute, time.Minute)
+	router := newAuthRouter()
+
+	assert.Equal(t, http.StatusOK, sendAuth(router, "valid-"+t.Name()).Code)
+	time.Sleep(20 * time.Millisecond)
+	down.Store(true)
+
+	assert.Equal(t, http.StatusOK, sendAuth(router, "valid-"+t.Name()).Code)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// Токен без успешной проверки при недоступном сервисе не принимается
+	assert.Equal(t, http.StatusServiceUnavailable, sendAuth(router, "other-"+t.Name()).Code)
+}
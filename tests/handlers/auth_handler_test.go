@@ -5,11 +5,16 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"dao_vote/back-end/handlers"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // mockAuthRequest представляет тестовый запрос для авторизации
@@ -19,8 +24,30 @@ var mockAuthRequest = handlers.AuthRequest{
 	DeviceName: "your device",              // Тестовое имя устройства
 }
 
+// useLocalIdentity подключает локального поставщика удостоверений с пользователем из mockAuthRequest на время теста
+func useLocalIdentity(t *testing.T) *handlers.LocalIdentityProvider {
+	t.Helper()
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+	provider, err := handlers.NewLocalIdentityProvider("test-secret-0123456789", time.Hour, []handlers.LocalUser{{
+		User: handlers.User{
+			ID:     1,
+			Login:  mockAuthRequest.Login,
+			Wallet: mockUserVoteRequest.Voter,
+			Roles:  []handlers.Role{{Name: "admin"}},
+		},
+		Password: mockAuthRequest.Password,
+	}})
+	require.NoError(t, err)
+
+	oldIdentity := handlers.Identity
+	handlers.Identity = provider
+	t.Cleanup(func() { handlers.Identity = oldIdentity })
+	return provider
+}
+
 // TestUserLoginHandler тестирует обработчик UserLoginHandler
 func TestUserLoginHandler(t *testing.T) {
+	useLocalIdentity(t)
 	router := gin.Default()                               // Создаем новый Gin роутер
 	router.POST("/auth/login", handlers.UserLoginHandler) // Регистрируем обработчик для маршрута POST /auth/login
 
@@ -39,13 +66,33 @@ func TestUserLoginHandler(t *testing.T) {
 	assert.NotEmpty(t, response.Token)               // Проверяем, что в ответе присутствует поле token
 }
 
+// TestUserLoginHandlerInvalidPassword проверяет отказ при неверном пароле
+func TestUserLoginHandlerInvalidPassword(t *testing.T) {
+	useLocalIdentity(t)
+	router := gin.New()
+	router.POST("/auth/login", handlers.UserLoginHandler)
+
+	requestBody, _ := json.Marshal(handlers.AuthRequest{Login: mockAuthRequest.Login, Password: "wrong password"})
+	req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
 // TestUserMeHandler тестирует обработчик UserMeHandler
 func TestUserMeHandler(t *testing.T) {
+	provider := useLocalIdentity(t)
+	token, err := provider.IssueToken(1)
+	require.NoError(t, err)
+
 	router := gin.Default()                        // Создаем новый Gin роутер
 	router.GET("/auth/me", handlers.UserMeHandler) // Регистрируем обработчик для маршрута GET /auth/me
 
 	req, _ := http.NewRequest("GET", "/auth/me", nil)  // Создаем новый HTTP GET запрос
-	req.Header.Set("Authorization", "Bearer ")         // Устанавливаем заголовок Authorization
+	req.Header.Set("Authorization", "Bearer "+token)   // Устанавливаем заголовок Authorization
 	req.Header.Set("Content-Type", "application/json") // Устанавливаем заголовок Content-Type
 
 	w := httptest.NewRecorder() // Создаем ResponseRecorder для записи ответа
@@ -53,8 +100,68 @@ func TestUserMeHandler(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code) // Проверяем, что статус код ответа 200 OK
 
-	var response handlers.UserMeResponse             // Объявляем переменную для хранения JSON ответа
-	err := json.Unmarshal(w.Body.Bytes(), &response) // Распаковываем JSON ответ в переменную
-	assert.NoError(t, err)                           // Проверяем, что при распаковке не возникло ошибок
-	assert.NotEmpty(t, response.Data.ID)             // Проверяем, что в ответе присутствует поле ID
+	var response handlers.UserMeResponse            // Объявляем переменную для хранения JSON ответа
+	err = json.Unmarshal(w.Body.Bytes(), &response) // Распаковываем JSON ответ в переменную
+	assert.NoError(t, err)                          // Проверяем, что при распаковке не возникло ошибок
+	assert.NotEmpty(t, response.Data.ID)            // Проверяем, что в ответе присутствует поле ID
+}
+
+// TestUserPersistedWithVoteStrength проверяет сохранение пользователя при проверке токена и поиск администратором
+func TestUserPersistedWithVoteStrength(t *testing.T) {
+	useLocalIdentity(t)
+	wallet, err := utils.EVMToDecimal("0x00000000000000000000000000000000000000a1")
+	require.NoError(t, err)
+	provider, err := handlers.NewLocalIdentityProvider("test-secret-0123456789", time.Hour, []handlers.LocalUser{{
+		User: handlers.User{
+			ID:            42,
+			Login:         "member",
+			Wallet:        wallet,
+			Roles:         []handlers.Role{{Name: "member", Permissions: []handlers.Permission{{Name: "votes.vote"}}}},
+			Subscriptions: []handlers.Subscription{{ID: 3, Tag: "pro", Tier: 2}},
+		},
+	}, {
+		User: handlers.User{ID: 1, Login: "admin", Roles: []handlers.Role{{Name: "admin"}}},
+	}})
+	require.NoError(t, err)
+	handlers.Identity = provider
+	require.NoError(t, repository.AddWalletStrength(wallet, 15))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(handlers.AuthMiddleware())
+	router.GET("/users", handlers.RequirePermission(handlers.PermUsersRead), handlers.ListUsersHandler)
+	router.GET("/users/:id", handlers.RequirePermission(handlers.PermUsersRead), handlers.GetUserByIDHandler)
+
+	send := func(path string, userID int) *httptest.ResponseRecorder {
+		token, err := provider.IssueToken(userID)
+		require.NoError(t, err)
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Запрос участника сохраняет его, но просмотр пользователей ему недоступен
+	assert.Equal(t, http.StatusForbidden, send("/users/42", 42).Code)
+
+	w := send("/users/42", 1)
+	require.Equal(t, http.StatusOK, w.Code)
+	var user repository.User
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+	assert.Equal(t, wallet, user.Wallet)
+	assert.Equal(t, []repository.UserRole{{Name: "member", Permissions: []string{"votes.vote"}}}, user.Roles)
+	require.Len(t, user.Subscriptions, 1)
+	assert.Equal(t, "pro", user.Subscriptions[0].Tag)
+	require.NotNil(t, user.VoteStrength)
+	assert.Equal(t, 15, user.VoteStrength.VotePower)
+
+	evmWallet, err := utils.DecimalToEVM(wallet)
+	require.NoError(t, err)
+	w = send("/users?wallet="+evmWallet, 1)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":42`)
+
+	assert.Equal(t, http.StatusNotFound, send("/users/99", 1).Code)
+	assert.Equal(t, http.StatusBadRequest, send("/users?wallet=bad", 1).Code)
 }
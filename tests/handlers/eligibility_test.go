@@ -0,0 +1,170 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bitbucket.org/decimalteam/dsc-go-sdk/wallet"
+	"dao_vote/back-end/handlers"
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// restoreEligibilityRules восстанавливает правила допуска после теста
+func restoreEligibilityRules(t *testing.T) {
+	t.Helper()
+	oldRules := handlers.EligibilityRules
+	t.Cleanup(func() { handlers.EligibilityRules = oldRules })
+}
+
+// TestCheckEligibility проверяет тег, уровень и сроки действия подписки
+func TestCheckEligibility(t *testing.T) {
+	restoreEligibilityRules(t)
+	handlers.EligibilityRules = map[string]handlers.EligibilityRule{
+		handlers.PermVotesCreate: {Tags: []string{"pro"}, MinTier: 2, ExemptRoles: []string{"admin"}},
+	}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	user := func(subs ...handlers.Subscription) handlers.User {
+		return handlers.User{ID: 1, Subscriptions: subs}
+	}
+
+	_, eligible := handlers.CheckEligibility(user(handlers.Subscription{Tag: "PRO", Tier: 2, StartsAt: "2024-01-01 00:00:00", EndsAt: "2025-01-01T00:00:00Z"}), handlers.PermVotesCreate, now)
+	assert.True(t, eligible)
+
+	// Действие без правила доступно без подписки
+	_, eligible = handlers.CheckEligibility(user(), handlers.PermVotesVote, now)
+	assert.True(t, eligible)
+
+	// Роль-исключение
+	admin := handlers.User{ID: 2, Roles: []handlers.Role{{Name: "Admin"}}}
+	_, eligible = handlers.CheckEligibility(admin, handlers.PermVotesCreate, now)
+	assert.True(t, eligible)
+
+	for name, tc := range map[string]struct {
+		user   handlers.User
+		reason string
+	}{
+		"none":    {user(), "votes.create requires an active subscription pro of tier 2 or higher: user has no subscriptions"},
+		"tag":     {user(handlers.Subscription{Tag: "basic", Tier: 3}), `subscription "basic" has a different tag`},
+		"tier":    {user(handlers.Subscription{Tag: "pro", Tier: 1}), `subscription "pro" has tier 1`},
+		"expired": {user(handlers.Subscription{Tag: "pro", Tier: 2, EndsAt: "2024-05-01"}), `subscription "pro" expired at 2024-05-01T00:00:00Z`},
+		"future":  {user(handlers.Subscription{Tag: "pro", Tier: 2, StartsAt: "2024-07-01"}), `subscription "pro" starts at 2024-07-01T00:00:00Z`},
+		"invalid": {user(handlers.Subscription{Tag: "pro", Tier: 2, EndsAt: "soon"}), `subscription "pro" has invalid ends_at "soon"`},
+	} {
+		reason, eligible := handlers.CheckEligibility(tc.user, handlers.PermVotesCreate, now)
+		assert.False(t, eligible, name)
+		assert.Contains(t, reason, tc.reason, name)
+	}
+}
+
+// TestRequireEligibility проверяет отказ 403 с причиной и загрузку правил из файла
+func TestRequireEligibility(t *testing.T) {
+	restoreEligibilityRules(t)
+	path := filepath.Join(t.TempDir(), "eligibility.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"withdraw.create": {"tags": ["pro"], "min_tier": 1}}`), 0o600))
+	t.Setenv("ELIGIBILITY_RULES_FILE", path)
+	require.NoError(t, handlers.LoadEligibilityConfig())
+
+	gin.SetMode(gin.TestMode)
+	send := func(user handlers.User) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.POST("/withdraw", func(c *gin.Context) { c.Set("user", user) },
+			handlers.RequireEligibility(handlers.PermWithdrawCreate), func(c *gin.Context) { c.Status(http.StatusCreated) })
+		req, _ := http.NewRequest("POST", "/withdraw", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusCreated, send(handlers.User{ID: 1, Subscriptions: []handlers.Subscription{{Tag: "pro", Tier: 1}}}).Code)
+
+	w := send(handlers.User{ID: 1, Subscriptions: []handlers.Subscription{{Tag: "pro", Tier: 0}}})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{"error":"Not eligible","action":"withdraw.create",
+		"reason":"withdraw.create requires an active subscription pro of tier 1 or higher: subscription \"pro\" has tier 0"}`, w.Body.String())
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"withdraw.create": {"min_tier": -1}}`), 0o600))
+	assert.Error(t, handlers.LoadEligibilityConfig())
+}
+
+// TestSignedVoteEligibility проверяет допуск подписанного голоса по подпискам пользователей с кошельком голосующего
+func TestSignedVoteEligibility(t *testing.T) {
+	restoreEligibilityRules(t)
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+	account, err := wallet.NewAccountFromMnemonicWords("gasp history river forget aware wide dance velvet weather rain rail dry cliff assault coach jelly choose spirit shoulder isolate kidney outer trust message", "")
+	require.NoError(t, err)
+	require.NoError(t, repository.AddWalletStrength(account.Address(), 100))
+	voteID, err := services.CreateVote(models.VoteInfo{Title: "t", VoteMode: models.VoteModeOffChain, VoteChangePolicy: models.VoteChangeReplace})
+	require.NoError(t, err)
+
+	handlers.EligibilityRules = map[string]handlers.EligibilityRule{handlers.PermVotesVote: {Tags: []string{"pro"}}}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/votes/:id/signed-vote", handlers.SubmitSignedVoteHandler)
+	now := time.Now().Unix()
+	send := func() *httptest.ResponseRecorder {
+		now++ // Каждая следующая подпись новее предыдущей
+		timestamp := now
+		signature, err := account.Sign([]byte(services.SignedVoteMessage(voteID, "За", timestamp)))
+		require.NoError(t, err)
+		body, _ := json.Marshal(models.SignedVoteRequest{Voter: account.Address(), Choice: "За", Timestamp: timestamp, Signature: hex.EncodeToString(signature)})
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/votes/%d/signed-vote", voteID), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Кошелек без сохраненного пользователя с подпиской не допускается
+	w := send()
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "user has no subscriptions")
+
+	require.NoError(t, repository.SaveUser(repository.User{ID: 7, Login: "member", Wallet: account.Address(),
+		Subscriptions: []repository.Subscription{{ID: 1, Tag: "pro", Tier: 1}}}))
+	assert.Equal(t, http.StatusCreated, send().Code)
+
+	// Роль wallet в исключениях допускает кошелек без подписки
+	handlers.EligibilityRules = map[string]handlers.EligibilityRule{handlers.PermVotesVote: {Tags: []string{"gold"}, ExemptRoles: []string{"wallet"}}}
+	assert.Equal(t, http.StatusCreated, send().Code)
+}
+
+// TestRequireEligibilityWalletSession проверяет, что участник, вошедший подписью кошелька,
+// допускается по сохраненным пользователям с его кошельком, как и подписанный голос
+func TestRequireEligibilityWalletSession(t *testing.T) {
+	restoreEligibilityRules(t)
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+	handlers.EligibilityRules = map[string]handlers.EligibilityRule{handlers.PermVotesVote: {Tags: []string{"pro"}}}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/votes/:id/commit", func(c *gin.Context) {
+		c.Set("user", handlers.User{ID: -1000000001, Wallet: "d01member", Roles: []handlers.Role{{Name: "wallet"}}, WalletSessionID: 1})
+	}, handlers.RequireEligibility(handlers.PermVotesVote), func(c *gin.Context) { c.Status(http.StatusCreated) })
+	send := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/votes/1/commit", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send()
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "user has no subscriptions")
+
+	require.NoError(t, repository.SaveUser(repository.User{ID: 7, Login: "member", Wallet: "d01member",
+		Subscriptions: []repository.Subscription{{ID: 1, Tag: "pro", Tier: 1}}}))
+	assert.Equal(t, http.StatusCreated, send().Code)
+}
@@ -0,0 +1,152 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"dao_vote/back-end/handlers"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newIdempotentRouter создает роутер с обработчиком, выполнение которого можно приостановить
+func newIdempotentRouter(t *testing.T, calls *int32, release chan struct{}) *gin.Engine {
+	t.Helper()
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v1/withdraw", handlers.IdempotencyMiddleware(), func(c *gin.Context) {
+		n := atomic.AddInt32(calls, 1)
+		if release != nil {
+			<-release
+		}
+		c.Header("Location", "/api/v1/withdraw/1")
+		c.JSON(http.StatusAccepted, gin.H{"call": n})
+	})
+	return router
+}
+
+// sendIdempotent отправляет запрос с ключом идемпотентности
+func sendIdempotent(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/api/v1/withdraw", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(handlers.IdempotencyKeyHeader, key)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestIdempotencyReplaysStoredResponse проверяет, что повторный запрос получает сохраненный ответ
+func TestIdempotencyReplaysStoredResponse(t *testing.T) {
+	var calls int32
+	router := newIdempotentRouter(t, &calls, nil)
+
+	first := sendIdempotent(router, "key-1", `{"amount":1,"address":"d01target"}`)
+	second := sendIdempotent(router, "key-1", `{"amount":1,"address":"d01target"}`)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, http.StatusAccepted, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "/api/v1/withdraw/1", second.Header().Get("Location"))
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+
+	// Тот же ключ с другим телом запроса отклоняется
+	other := sendIdempotent(router, "key-1", `{"amount":2,"address":"d01target"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, other.Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// TestIdempotencyServerErrors проверяет, что ответ с ошибкой сервера сохраняется только после внешнего действия
+func TestIdempotencyServerErrors(t *testing.T) {
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+
+	var calls int32
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v1/withdraw", handlers.IdempotencyMiddleware(), func(c *gin.Context) {
+		atomic.AddInt32(&calls, 1)
+		if c.Query("submitted") != "" {
+			handlers.MarkIdempotentSideEffect(c)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "upstream failed"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database failed"})
+	})
+	send := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, strings.NewReader(`{}`))
+		req.Header.Set(handlers.IdempotencyKeyHeader, "key-3")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Ошибка до внешнего действия освобождает ключ, и запрос выполняется повторно
+	assert.Equal(t, http.StatusInternalServerError, send("/api/v1/withdraw").Code)
+	assert.Equal(t, http.StatusInternalServerError, send("/api/v1/withdraw").Code)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// Ошибка после внешнего действия сохраняется и возвращается повтору
+	first := send("/api/v1/withdraw?submitted=1")
+	second := send("/api/v1/withdraw?submitted=1")
+	assert.Equal(t, http.StatusBadGateway, first.Code)
+	assert.Equal(t, http.StatusBadGateway, second.Code)
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+// TestIdempotencyConcurrentDuplicate проверяет ответ 409 на дубликат во время выполнения первого запроса
+func TestIdempotencyConcurrentDuplicate(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	router := newIdempotentRouter(t, &calls, release)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- sendIdempotent(router, "key-2", `{}`) }()
+
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, time.Millisecond)
+	duplicate := sendIdempotent(router, "key-2", `{}`)
+	assert.Equal(t, http.StatusConflict, duplicate.Code)
+
+	close(release)
+	assert.Equal(t, http.StatusAccepted, (<-done).Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// TestIdempotencyStaleInFlightTakeover проверяет, что повтор занимает ключ запроса, не завершенного за время аренды
+func TestIdempotencyStaleInFlightTakeover(t *testing.T) {
+	lease := services.IdempotencyInFlightLease
+	services.IdempotencyInFlightLease = 50 * time.Millisecond
+	t.Cleanup(func() { services.IdempotencyInFlightLease = lease })
+
+	var calls int32
+	release := make(chan struct{})
+	router := newIdempotentRouter(t, &calls, release)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- sendIdempotent(router, "key-3", `{}`) }()
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, time.Millisecond)
+
+	time.Sleep(2 * services.IdempotencyInFlightLease)
+	retried := make(chan *httptest.ResponseRecorder)
+	go func() { retried <- sendIdempotent(router, "key-3", `{}`) }()
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 2 }, time.Second, time.Millisecond)
+
+	close(release)
+	<-done
+	retry := <-retried
+	assert.Equal(t, http.StatusAccepted, retry.Code)
+	assert.Empty(t, retry.Header().Get("Idempotent-Replayed"))
+
+	duplicate := sendIdempotent(router, "key-3", `{}`)
+	assert.Equal(t, "true", duplicate.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,79 @@
+package handlers_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"dao_vote/back-end/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLocalIdentityProviderLoadFromFile проверяет вход и проверку токена пользователем из файла конфигурации
+func TestLocalIdentityProviderLoadFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "identity.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"secret": "local-secret-0123456789",
+		"token_ttl": "1h",
+		"users": [{"id": 5, "login": "dev", "email": "dev@example.com", "password": "devpass",
+			"wallet": "d01dev", "roles": [{"name": "admin"}]}]
+	}`), 0o600))
+
+	provider, err := handlers.LoadLocalIdentityProvider(path)
+	require.NoError(t, err)
+
+	resp, err := provider.Login(handlers.AuthRequest{Login: "DEV@example.com", Password: "devpass"})
+	require.NoError(t, err)
+
+	user, err := provider.ResolveToken("Bearer " + resp.Token)
+	require.NoError(t, err)
+	assert.Equal(t, 5, user.ID)
+	assert.Equal(t, "d01dev", user.Wallet)
+	assert.Equal(t, "admin", user.Roles[0].Name)
+
+	_, err = provider.Login(handlers.AuthRequest{Login: "dev", Password: "wrong"})
+	assert.ErrorIs(t, err, handlers.ErrInvalidCredentials)
+}
+
+// TestLocalIdentityProviderRejectsInvalidTokens проверяет отказ для подделанного, чужого и просроченного токена
+func TestLocalIdentityProviderRejectsInvalidTokens(t *testing.T) {
+	users := []handlers.LocalUser{{User: handlers.User{ID: 1, Login: "dev"}, Password: "devpass"}}
+	provider, err := handlers.NewLocalIdentityProvider("local-secret-0123456789", time.Hour, users)
+	require.NoError(t, err)
+	other, err := handlers.NewLocalIdentityProvider("other-secret-0123456789", time.Hour, users)
+	require.NoError(t, err)
+	expired, err := handlers.NewLocalIdentityProvider("local-secret-0123456789", time.Nanosecond, users)
+	require.NoError(t, err)
+
+	token, err := provider.IssueToken(1)
+	require.NoError(t, err)
+	otherToken, err := other.IssueToken(1)
+	require.NoError(t, err)
+	expiredToken, err := expired.IssueToken(1)
+	require.NoError(t, err)
+	time.Sleep(time.Second)
+
+	for name, invalid := range map[string]string{
+		"tampered": "x" + token,
+		"foreign":  otherToken,
+		"expired":  expiredToken,
+		"garbage":  "not-a-token",
+	} {
+		_, err := provider.ResolveToken(invalid)
+		assert.ErrorIs(t, err, handlers.ErrInvalidToken, name)
+	}
+}
+
+// TestNewLocalIdentityProviderValidation проверяет проверку конфигурации
+func TestNewLocalIdentityProviderValidation(t *testing.T) {
+	_, err := handlers.NewLocalIdentityProvider("short", time.Hour, nil)
+	assert.Error(t, err)
+
+	_, err = handlers.NewLocalIdentityProvider("local-secret-0123456789", time.Hour, []handlers.LocalUser{
+		{User: handlers.User{ID: 1, Login: "dev"}},
+		{User: handlers.User{ID: 2, Login: "DEV"}},
+	})
+	assert.Error(t, err)
+}
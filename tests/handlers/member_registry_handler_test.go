@@ -0,0 +1,139 @@
+package handlers_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"dao_vote/back-end/handlers"
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"dao_vote/back-end/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestImportExportMembers проверяет пробный импорт, применение файла и выгрузку реестра участников
+func TestImportExportMembers(t *testing.T) {
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+	first, _ := utils.NormalizeAddress("0x0000000000000000000000000000000000000001")
+	second, _ := utils.NormalizeAddress("0x0000000000000000000000000000000000000002")
+	require.NoError(t, repository.AddWalletStrength(first, 10))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user", handlers.User{ID: 1}) })
+	router.POST("/wallets/import", handlers.ImportMembersHandler)
+	router.GET("/wallets/export", handlers.ExportMembersHandler)
+
+	send := func(method, path, contentType string, body []byte) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	list := []byte("wallet_address,vote_power\n" + second + ",50\n")
+	w := send("POST", "/wallets/import", "text/csv", list)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"added":[{"wallet_address":"`+second+`","vote_power":50}],
+		"removed":[{"wallet_address":"`+first+`","vote_power":10}],"changed":[],"unchanged":0,"applied":false}`, w.Body.String())
+	_, err := repository.GetVoteStrength(first)
+	assert.NoError(t, err)
+
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	part, _ := writer.CreateFormFile("file", "members.csv")
+	part.Write(list)
+	writer.Close()
+	w = send("POST", "/wallets/import?apply=true", writer.FormDataContentType(), form.Bytes())
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"applied":true`)
+
+	w = send("GET", "/wallets/export", "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, string(list), w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "vote_strength.csv")
+
+	w = send("GET", "/wallets/export?format=json", "", nil)
+	assert.JSONEq(t, `[{"wallet_address":"`+second+`","vote_power":50}]`, w.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/wallets/import", "application/json", []byte(`[{"wallet_address":"bad","vote_power":1}]`)).Code)
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/wallets/import", "text/plain", list).Code)
+}
+
+// TestUpdateWalletHandler проверяет изменение силы голоса через PATCH и запись автора в историю
+func TestUpdateWalletHandler(t *testing.T) {
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+	wallet, _ := utils.NormalizeAddress("0x0000000000000000000000000000000000000001")
+	require.NoError(t, repository.AddWalletStrength(wallet, 10))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user", handlers.User{ID: 1, Login: "admin"}) })
+	router.PATCH("/wallets/:wallet_address", handlers.UpdateWalletHandler)
+	router.GET("/wallets/:wallet_address/history", handlers.GetWalletHistoryHandler)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("PATCH", "/wallets/"+wallet, `{"vote_power":15,"reason":"PRO round"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"old_vote_power":10,"new_vote_power":15,"actor_id":1,"actor":"admin","reason":"PRO round"`)
+
+	power, err := repository.GetVoteStrength(wallet)
+	require.NoError(t, err)
+	assert.Equal(t, 15, power)
+
+	assert.Equal(t, http.StatusBadRequest, send("PATCH", "/wallets/"+wallet, `{"vote_power":0}`).Code)
+	assert.Equal(t, http.StatusBadRequest, send("PATCH", "/wallets/d01invalid", `{"vote_power":5}`).Code)
+	missing, _ := utils.NormalizeAddress("0x0000000000000000000000000000000000000002")
+	assert.Equal(t, http.StatusNotFound, send("PATCH", "/wallets/"+missing, `{"vote_power":5}`).Code)
+
+	w = send("GET", "/wallets/"+wallet+"/history", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"reason":"PRO round"`)
+}
+
+// TestDeleteWalletHandler проверяет удаление кошелька по адресу EVM, отказ для некорректного и отсутствующего адреса
+// и запись в журнал только фактического удаления
+func TestDeleteWalletHandler(t *testing.T) {
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+	evmWallet := "0x0000000000000000000000000000000000000001"
+	wallet, _ := utils.NormalizeAddress(evmWallet)
+	require.NoError(t, repository.AddWalletStrength(wallet, 10))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user", handlers.User{ID: 1, Login: "admin"}) })
+	router.DELETE("/wallets/:wallet_address", handlers.DeleteWalletHandler)
+	send := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("DELETE", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, send("/wallets/d01invalid").Code)
+	assert.Equal(t, http.StatusOK, send("/wallets/"+evmWallet).Code)
+	_, err := repository.GetVoteStrength(wallet)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, send("/wallets/"+evmWallet).Code)
+
+	entries, err := services.ListAuditLog(models.AuditFilter{Action: "wallet.delete", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, wallet, entries[0].TargetID)
+	assert.Contains(t, string(entries[0].Before), `"vote_power":10`)
+}
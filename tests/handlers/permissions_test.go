@@ -0,0 +1,97 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dao_vote/back-end/handlers"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// restorePermissions восстанавливает разрешения ролей после теста
+func restorePermissions(t *testing.T) {
+	t.Helper()
+	oldDefault := handlers.DefaultPermissions
+	oldRoles := make(map[string][]string)
+	for role, permissions := range handlers.RolePermissions {
+		oldRoles[role] = permissions
+	}
+	t.Cleanup(func() {
+		handlers.DefaultPermissions = oldDefault
+		handlers.RolePermissions = oldRoles
+	})
+}
+
+// TestHasPermission проверяет объединение разрешений по умолчанию, ролей и поставщика удостоверений
+func TestHasPermission(t *testing.T) {
+	restorePermissions(t)
+	handlers.RolePermissions["treasurer"] = []string{"withdraw.*"}
+
+	member := handlers.User{ID: 1, Roles: []handlers.Role{{Name: "member"}}}
+	admin := handlers.User{ID: 2, Roles: []handlers.Role{{Name: "Admin"}}}
+	treasurer := handlers.User{ID: 3, Roles: []handlers.Role{{Name: "treasurer"}}}
+	curator := handlers.User{ID: 4, Roles: []handlers.Role{{
+		Name:        "curator",
+		Permissions: []handlers.Permission{{Name: handlers.PermTablesRead}},
+	}}}
+
+	assert.True(t, handlers.HasPermission(member, handlers.PermVotesCreate))
+	assert.False(t, handlers.HasPermission(member, handlers.PermWalletsWrite))
+	assert.True(t, handlers.HasPermission(admin, handlers.PermWalletsWrite))
+	assert.True(t, handlers.HasPermission(treasurer, handlers.PermWithdrawApprove))
+	assert.False(t, handlers.HasPermission(treasurer, handlers.PermVotesDelete))
+	assert.True(t, handlers.HasPermission(curator, handlers.PermTablesRead))
+	assert.False(t, handlers.HasPermission(curator, handlers.PermWalletsWrite))
+}
+
+// TestLoadPermissionsConfig проверяет переопределение разрешений ролей из файла
+func TestLoadPermissionsConfig(t *testing.T) {
+	restorePermissions(t)
+	path := filepath.Join(t.TempDir(), "permissions.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"default": ["votes.vote"],
+		"roles": {"Moderator": ["votes.delete"]}
+	}`), 0o600))
+	t.Setenv("ROLE_PERMISSIONS_FILE", path)
+
+	require.NoError(t, handlers.LoadPermissionsConfig())
+
+	member := handlers.User{ID: 1}
+	moderator := handlers.User{ID: 2, Roles: []handlers.Role{{Name: "moderator"}}}
+	admin := handlers.User{ID: 3, Roles: []handlers.Role{{Name: "admin"}}}
+
+	assert.True(t, handlers.HasPermission(member, handlers.PermVotesVote))
+	assert.False(t, handlers.HasPermission(member, handlers.PermVotesCreate))
+	assert.True(t, handlers.HasPermission(moderator, handlers.PermVotesDelete))
+	assert.True(t, handlers.HasPermission(admin, handlers.PermVotesDelete))
+}
+
+// TestRequirePermission проверяет ответы middleware
+func TestRequirePermission(t *testing.T) {
+	restorePermissions(t)
+	gin.SetMode(gin.TestMode)
+
+	send := func(user *handlers.User) int {
+		router := gin.New()
+		router.DELETE("/wallets/:wallet_address", func(c *gin.Context) {
+			if user != nil {
+				c.Set("user", *user)
+			}
+		}, handlers.RequirePermission(handlers.PermWalletsWrite), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		req, _ := http.NewRequest("DELETE", "/wallets/d01wallet", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, send(nil))
+	assert.Equal(t, http.StatusForbidden, send(&handlers.User{ID: 1}))
+	assert.Equal(t, http.StatusOK, send(&handlers.User{ID: 2, Roles: []handlers.Role{{Name: "admin"}}}))
+}
@@ -0,0 +1,205 @@
+package handlers_test
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"dao_vote/back-end/handlers"
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"dao_vote/back-end/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExportProposals проверяет выгрузку итогов голосований в CSV, JSON Lines и XLSX с фильтрами периода и статуса
+func TestExportProposals(t *testing.T) {
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+	wallets := make([]string, 3)
+	for i, power := range []int{7000000, 100, 50} {
+		wallets[i], _ = utils.NormalizeAddress(fmt.Sprintf("0x%040d", i+1))
+		require.NoError(t, repository.AddWalletStrength(wallets[i], power))
+	}
+
+	// Голосование 1 принято большинством, 2 активно, 3 без голосов создано в 2020 году,
+	// 4 создано до учета времени создания
+	ballots := [][]models.SignedVote{
+		{{Voter: wallets[0], Choice: "за", VotePower: 7000000, BallotHash: "h1"}, {Voter: wallets[1], Choice: "против", VotePower: 100, BallotHash: "h2"}},
+		{{Voter: wallets[2], Choice: "за", VotePower: 50, BallotHash: "h3"}},
+		nil,
+		nil,
+	}
+	for i, votes := range ballots {
+		id, err := services.CreateVote(models.VoteInfo{Title: fmt.Sprintf("Proposal %d", i+1), Subtitle: "s", VoteMode: models.VoteModeOffChain})
+		require.NoError(t, err)
+		for _, vote := range votes {
+			vote.VoteID = id
+			_, err := repository.AddSignedVote(vote)
+			require.NoError(t, err)
+		}
+	}
+	_, err := repository.GetDB().Exec("UPDATE votes SET created_at = '2020-06-01 00:00:00+00:00', subtitle = '=HYPERLINK(\"x\")' WHERE id = 3")
+	require.NoError(t, err)
+	_, err = repository.GetDB().Exec("UPDATE votes SET created_at = NULL WHERE id = 4")
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user", handlers.User{ID: 1, Login: "admin"}) })
+	router.GET("/admin/votes/export", handlers.ExportProposalsHandler)
+	get := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/admin/votes/export"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	records := func(query string) [][]string {
+		w := get(query)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		rows, err := csv.NewReader(w.Body).ReadAll()
+		require.NoError(t, err)
+		return rows
+	}
+
+	assert.Equal(t, http.StatusBadRequest, get("?format=pdf").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?status=finished").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?from=yesterday").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?from=2024-01-02&to=2024-01-01").Code)
+
+	w := get("?format=jsonl")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), ".jsonl")
+	var proposals []models.ProposalExport
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var proposal models.ProposalExport
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &proposal))
+		proposals = append(proposals, proposal)
+	}
+	require.Len(t, proposals, 4)
+	assert.Equal(t, services.ProposalStatusCompleted, proposals[0].Status)
+	assert.Equal(t, "Принять изменения", proposals[0].Resolution)
+	assert.Equal(t, 3, proposals[0].DAOMembers)
+	assert.Equal(t, 2, proposals[0].VotedMembers)
+	assert.Equal(t, "66.67%", proposals[0].Turnout)
+	assert.Equal(t, []models.ProposalVoter{
+		{Wallet: wallets[0], VotePower: 7000000, Choice: services.ChoiceFor, TransactionHash: "h1", Source: models.TransactionSourceSigned},
+		{Wallet: wallets[1], VotePower: 100, Choice: services.ChoiceAgainst, TransactionHash: "h2", Source: models.TransactionSourceSigned},
+	}, proposals[0].Voters)
+	assert.Equal(t, services.ProposalStatusActive, proposals[1].Status)
+	assert.NotNil(t, proposals[1].CreatedAt)
+	assert.Empty(t, proposals[2].Voters)
+	assert.Equal(t, `=HYPERLINK("x")`, proposals[2].Subtitle, "JSON Lines выгружает значения без экранирования")
+	assert.Nil(t, proposals[3].CreatedAt, "время создания старого голосования неизвестно")
+
+	rows := records("")
+	require.Len(t, rows, 6, "заголовок, два голоса первого голосования, по строке на второе, третье и четвертое")
+	assert.Equal(t, "proposal_id", rows[0][0])
+	assert.Equal(t, []string{"1", "Proposal 1", "completed", wallets[1], "100", "against", "h2"},
+		[]string{rows[2][0], rows[2][1], rows[2][6], rows[2][14], rows[2][15], rows[2][16], rows[2][17]})
+	assert.Equal(t, "", rows[4][14], "голосование без голосов выгружается одной строкой")
+	assert.Equal(t, `'=HYPERLINK("x")`, rows[4][2], "значение, начинающееся с символа формулы, выгружается как текст")
+
+	rows = records("?status=completed")
+	require.Len(t, rows, 3)
+	rows = records("?status=active")
+	require.Len(t, rows, 4)
+	rows = records("?status=active&from=2024-01-01")
+	require.Len(t, rows, 2, "голосование с неизвестным временем создания не попадает в период")
+	assert.Equal(t, "Proposal 2", rows[1][1])
+
+	w = get("?format=xlsx&to=2021-01-01")
+	require.Equal(t, http.StatusOK, w.Code)
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	require.NoError(t, err)
+	var sheet string
+	for _, file := range archive.File {
+		if file.Name == "xl/worksheets/sheet1.xml" {
+			reader, err := file.Open()
+			require.NoError(t, err)
+			content, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			sheet = string(content)
+		}
+	}
+	assert.Contains(t, sheet, "proposal_id")
+	assert.Contains(t, sheet, "Proposal 3")
+	assert.NotContains(t, sheet, "Proposal 1")
+	assert.NotContains(t, sheet, "Proposal 4")
+	assert.Contains(t, sheet, `<c r="A2"><v>3</v></c>`)
+	assert.Contains(t, sheet, `&#39;=HYPERLINK(&#34;x&#34;)`)
+}
+
+// TestExportProposalsExplorerErrors проверяет, что голосование без итогов не попадает в выгрузку по статусу,
+// а выгрузка не подтверждает квитанции голосов
+func TestExportProposalsExplorerErrors(t *testing.T) {
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+	wallet, _ := utils.NormalizeAddress(fmt.Sprintf("0x%040d", 1))
+	require.NoError(t, repository.AddWalletStrength(wallet, 7000000))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/address/d0ok/txs" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprintf(w, `{"result":{"txs":[{"from":%q,"message":"за","hash":"h1"}]}}`, wallet)
+	}))
+	defer server.Close()
+	defer func(url string) { services.ExplorerAPIURL = url }(services.ExplorerAPIURL)
+	services.ExplorerAPIURL = server.URL
+
+	okID, err := services.CreateVote(models.VoteInfo{Title: "Counted", WalletAddress: "d0ok"})
+	require.NoError(t, err)
+	_, err = services.CreateVote(models.VoteInfo{Title: "Unavailable", WalletAddress: "d0down"})
+	require.NoError(t, err)
+	userVoteID, err := repository.AddUserVote(models.UserVote{VoteID: okID, Voter: wallet, Choice: "за", VotePower: 7000000})
+	require.NoError(t, err)
+	require.NoError(t, repository.UpdateUserVoteReceipt(userVoteID, 1, "h1", models.ReceiptStatusSent))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin/votes/export", handlers.ExportProposalsHandler)
+	export := func(query string) []models.ProposalExport {
+		req, _ := http.NewRequest("GET", "/admin/votes/export?format=jsonl"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var proposals []models.ProposalExport
+		scanner := bufio.NewScanner(w.Body)
+		for scanner.Scan() {
+			var proposal models.ProposalExport
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &proposal))
+			proposals = append(proposals, proposal)
+		}
+		return proposals
+	}
+
+	proposals := export("")
+	require.Len(t, proposals, 2)
+	assert.Equal(t, services.ProposalStatusCompleted, proposals[0].Status)
+	assert.Empty(t, proposals[0].Error)
+	assert.Equal(t, "Unavailable", proposals[1].Title)
+	assert.Contains(t, proposals[1].Error, "502")
+
+	proposals = export("&status=completed")
+	require.Len(t, proposals, 1)
+	assert.Equal(t, "Counted", proposals[0].Title)
+	assert.Empty(t, export("&status=active"))
+
+	userVote, err := repository.GetUserVoteByVoter(okID, wallet)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReceiptStatusSent, userVote.Status, "выгрузка не подтверждает квитанции")
+}
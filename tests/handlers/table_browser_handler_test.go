@@ -0,0 +1,108 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"dao_vote/back-end/handlers"
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"dao_vote/back-end/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTableBrowser проверяет список разрешенных таблиц, фильтры, сортировку, постраничный вывод и скрытие секретов
+func TestTableBrowser(t *testing.T) {
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+	wallets := make([]string, 5)
+	for i, power := range []int{10, 20, 20, 30, 20} {
+		wallets[i], _ = utils.NormalizeAddress(fmt.Sprintf("0x%040d", i+1))
+		require.NoError(t, repository.AddWalletStrength(wallets[i], power))
+	}
+	expiresAt := time.Now().Add(time.Hour).UTC()
+	for _, expires := range []*time.Time{nil, &expiresAt, nil} {
+		_, err := services.CreateAPIKey(models.APIKeyRequest{Name: "bot", Scopes: []string{"votes.vote"}, ExpiresAt: expires}, 1)
+		require.NoError(t, err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user", handlers.User{ID: 1, Login: "admin"}) })
+	router.GET("/tables", handlers.GetTableNamesHandler)
+	router.GET("/tables/:table_name/elements", handlers.GetTableElementsHandler)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	page := func(path string) models.TablePage {
+		w := get(path)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response models.TablePage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	w := get("/tables")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"api_keys"`)
+	assert.NotContains(t, w.Body.String(), "sqlite_sequence")
+
+	assert.Equal(t, http.StatusNotFound, get("/tables/votes%3B%20DROP%20TABLE%20votes/elements").Code)
+	assert.Equal(t, http.StatusNotFound, get("/tables/sqlite_master/elements").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/tables/vote_strength/elements?filter[power]=1").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/tables/vote_strength/elements?sort=vote_power%3BDROP").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/tables/api_keys/elements?filter[key_hash]=x").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/tables/api_keys/elements?sort=key_hash").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/tables/vote_strength/elements?cursor=garbage").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/tables/vote_strength/elements?limit=0").Code)
+
+	filtered := page("/tables/vote_strength/elements?filter[vote_power]=20&sort=-id")
+	require.Len(t, filtered.Rows, 3)
+	assert.Equal(t, wallets[4], filtered.Rows[0]["wallet_address"])
+	assert.Empty(t, filtered.NextCursor)
+
+	last := page("/tables/vote_strength/elements?limit=2&offset=4")
+	require.Len(t, last.Rows, 1)
+	assert.Equal(t, wallets[4], last.Rows[0]["wallet_address"])
+
+	first := page("/tables/vote_strength/elements?limit=2&sort=-vote_power")
+	require.NotEmpty(t, first.NextCursor)
+	assert.Equal(t, http.StatusBadRequest, get("/tables/vote_strength/elements?offset=2&cursor="+first.NextCursor).Code)
+	var seen []interface{}
+	for path := "/tables/vote_strength/elements?limit=2&sort=-vote_power"; ; {
+		current := page(path)
+		for _, row := range current.Rows {
+			seen = append(seen, row["wallet_address"])
+		}
+		if current.NextCursor == "" {
+			break
+		}
+		path = "/tables/vote_strength/elements?limit=2&sort=-vote_power&cursor=" + current.NextCursor
+	}
+	assert.Equal(t, []interface{}{wallets[3], wallets[4], wallets[2], wallets[1], wallets[0]}, seen)
+
+	var ids []interface{}
+	for path := "/tables/api_keys/elements?limit=1&sort=expires_at"; ; {
+		current := page(path)
+		require.Len(t, current.Rows, 1)
+		assert.Equal(t, services.RedactedValue, current.Rows[0]["key_hash"])
+		assert.Equal(t, []string{"key_hash"}, current.Redacted)
+		ids = append(ids, current.Rows[0]["id"])
+		if current.NextCursor == "" {
+			break
+		}
+		path = "/tables/api_keys/elements?limit=1&sort=expires_at&cursor=" + current.NextCursor
+	}
+	assert.Equal(t, []interface{}{float64(1), float64(3), float64(2)}, ids, "NULL идут первыми при сортировке по возрастанию")
+}
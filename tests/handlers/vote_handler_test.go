@@ -4,15 +4,22 @@ import (
 	"bytes"
 	"dao_vote/back-end/handlers"
 	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"dao_vote/back-end/utils"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // mockVoteRequest представляет тестовый запрос для создания голосования
@@ -31,79 +38,172 @@ var mockUserVoteRequest = models.UserVote{
 	VotePower: 1000000,                                     // Тестовая сила голоса
 }
 
+// useDDAppsStub подменяет API Decimal Dapps на время теста: вывод получает транзакцию 42 с хэшем ABCDEF,
+// а хэш транзакции голоса опрашивается без задержек
+func useDDAppsStub(t *testing.T) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/withdraw" {
+			w.Write([]byte(`{"type":"success","data":{"transaction_id":42}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"id":42,"hash":"ABCDEF","complete":true,"success":true}}`))
+	}))
+	oldURL, oldBackoff := services.DDAppsAPIURL, services.VoteJobBackoff
+	services.DDAppsAPIURL = server.URL
+	services.VoteJobBackoff = services.Backoff{Initial: time.Millisecond, Max: time.Millisecond, Factor: 1, MaxAttempts: 3}
+	t.Cleanup(func() {
+		services.DDAppsAPIURL, services.VoteJobBackoff = oldURL, oldBackoff
+		server.Close()
+	})
+}
+
 // TestCreateVoteHandler тестирует обработчик CreateVoteHandler
 func TestCreateVoteHandler(t *testing.T) {
-	gin.SetMode(gin.TestMode)                         // Устанавливаем режим тестирования Gin
-	router := gin.Default()                           // Создаем новый роутер Gin
-	router.POST("/votes", handlers.CreateVoteHandler) // Регистрируем обработчик для маршрута POST /votes
+	provider := useLocalIdentity(t)                                                 // Подключаем локального поставщика удостоверений
+	require.NoError(t, repository.AddWalletStrength(mockUserVoteRequest.Voter, 10)) // Добавляем силу голоса автору голосования
+	token, err := provider.IssueToken(1)                                            // Выдаем токен пользователю
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)                                                    // Устанавливаем режим тестирования Gin
+	router := gin.Default()                                                      // Создаем новый роутер Gin
+	router.POST("/votes", handlers.AuthMiddleware(), handlers.CreateVoteHandler) // Регистрируем обработчик для маршрута POST /votes
 
 	req, _ := http.NewRequest("POST", "/votes", strings.NewReader(mockVoteRequest.Encode())) // Создаем новый HTTP запрос с данными формы
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")                      // Устанавливаем заголовок Content-Type
-	req.Header.Set("Authorization", "Bearer ")                                               // Устанавливаем заголовок Authorization
+	req.Header.Set("Authorization", "Bearer "+token)                                         // Устанавливаем заголовок Authorization
 
 	w := httptest.NewRecorder() // Создаем ResponseRecorder для записи ответа
 	router.ServeHTTP(w, req)    // Передаем запрос в роутер
 
-	assert.Equal(t, http.StatusCreated, w.Code) // Проверяем, что статус код ответа 201 Created
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String()) // Проверяем, что статус код ответа 201 Created
 
-	var response models.VoteInfo                     // Объявляем переменную для хранения JSON ответа
-	err := json.Unmarshal(w.Body.Bytes(), &response) // Распаковываем JSON ответ в переменную
-	assert.NoError(t, err)                           // Проверяем, что при распаковке не возникло ошибок
-	assert.NotEmpty(t, response.ID)                  // Проверяем, что в ответе присутствует поле ID
+	var response models.VoteInfo                    // Объявляем переменную для хранения JSON ответа
+	err = json.Unmarshal(w.Body.Bytes(), &response) // Распаковываем JSON ответ в переменную
+	assert.NoError(t, err)                          // Проверяем, что при распаковке не возникло ошибок
+	assert.NotEmpty(t, response.ID)                 // Проверяем, что в ответе присутствует поле ID
+	assert.Equal(t, mockUserVoteRequest.Voter, response.Voter)
+	assert.Equal(t, 10, response.VotePower)
 }
 
 // TestGetVoteHandler тестирует обработчик GetVoteHandler
 func TestGetVoteHandler(t *testing.T) {
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+	voteID, err := repository.SaveVote(models.VoteInfo{Title: "Голосование №1"}) // Сохраняем тестовое голосование
+	require.NoError(t, err)
+
 	gin.SetMode(gin.TestMode)                         // Устанавливаем режим тестирования Gin
 	router := gin.Default()                           // Создаем новый роутер Gin
 	router.GET("/votes/:id", handlers.GetVoteHandler) // Регистрируем обработчик для маршрута GET /votes/:id
 
-	req, _ := http.NewRequest("GET", "/votes/1", nil) // Создаем новый HTTP GET запрос
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/votes/%d", voteID), nil) // Создаем новый HTTP GET запрос
 
 	w := httptest.NewRecorder() // Создаем ResponseRecorder для записи ответа
 	router.ServeHTTP(w, req)    // Передаем запрос в роутер
 
-	assert.Equal(t, http.StatusOK, w.Code) // Проверяем, что статус код ответа 200 OK
+	require.Equal(t, http.StatusOK, w.Code) // Проверяем, что статус код ответа 200 OK
 
-	var response models.VoteInfo                     // Объявляем переменную для хранения JSON ответа
-	err := json.Unmarshal(w.Body.Bytes(), &response) // Распаковываем JSON ответ в переменную
-	assert.NoError(t, err)                           // Проверяем, что при распаковке не возникло ошибок
-	assert.NotEmpty(t, response.ID)                  // Проверяем, что в ответе присутствует поле ID
+	var response models.VoteInfo                    // Объявляем переменную для хранения JSON ответа
+	err = json.Unmarshal(w.Body.Bytes(), &response) // Распаковываем JSON ответ в переменную
+	assert.NoError(t, err)                          // Проверяем, что при распаковке не возникло ошибок
+	assert.Equal(t, voteID, response.ID)            // Проверяем, что в ответе то же голосование
 }
 
 // TestDeleteVoteHandler тестирует обработчик DeleteVoteHandler
 func TestDeleteVoteHandler(t *testing.T) {
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+	voteID, err := repository.SaveVote(models.VoteInfo{Title: "Голосование №1"}) // Сохраняем тестовое голосование
+	require.NoError(t, err)
+
 	gin.SetMode(gin.TestMode)                               // Устанавливаем режим тестирования Gin
 	router := gin.Default()                                 // Создаем новый роутер Gin
 	router.DELETE("/votes/:id", handlers.DeleteVoteHandler) // Регистрируем обработчик для маршрута DELETE /votes/:id
 
-	req, _ := http.NewRequest("DELETE", "/votes/1", nil) // Создаем новый HTTP DELETE запрос
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/votes/%d", voteID), nil) // Создаем новый HTTP DELETE запрос
 
 	w := httptest.NewRecorder() // Создаем ResponseRecorder для записи ответа
 	router.ServeHTTP(w, req)    // Передаем запрос в роутер
 
 	assert.Equal(t, http.StatusNoContent, w.Code) // Проверяем, что статус код ответа 204 No Content
+	_, err = repository.GetVoteByID(voteID)
+	assert.Error(t, err) // Проверяем, что голосование удалено
 }
 
 // TestAddUserVoteHandler тестирует обработчик AddUserVoteHandler
 func TestAddUserVoteHandler(t *testing.T) {
-	gin.SetMode(gin.TestMode)                                   // Устанавливаем режим тестирования Gin
-	router := gin.Default()                                     // Создаем новый роутер Gin
-	router.POST("/votes/:id/vote", handlers.AddUserVoteHandler) // Регистрируем обработчик для маршрута POST /votes/:id/vote
-
-	requestBody, _ := json.Marshal(mockUserVoteRequest)                              // Преобразуем тестовый запрос в JSON
-	req, _ := http.NewRequest("POST", "/votes/1/vote", bytes.NewBuffer(requestBody)) // Создаем новый HTTP POST запрос с JSON телом
-	req.Header.Set("Content-Type", "application/json")                               // Устанавливаем заголовок Content-Type
-	req.Header.Set("Authorization", "Bearer testtoken")                              // Устанавливаем заголовок Authorization
+	provider := useLocalIdentity(t) // Подключаем локального поставщика удостоверений
+	useDDAppsStub(t)                // Подменяем API вывода средств
+	require.NoError(t, repository.AddWalletStrength(mockUserVoteRequest.Voter, mockUserVoteRequest.VotePower))
+	voteID, err := repository.SaveVote(models.VoteInfo{Title: "Голосование №1", WalletAddress: mockWithdrawRequest.Address})
+	require.NoError(t, err)
+	token, err := provider.IssueToken(1) // Выдаем токен пользователю
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)                                                              // Устанавливаем режим тестирования Gin
+	router := gin.Default()                                                                // Создаем новый роутер Gin
+	router.POST("/votes/:id/vote", handlers.AuthMiddleware(), handlers.AddUserVoteHandler) // Регистрируем обработчик для маршрута POST /votes/:id/vote
+
+	requestBody, _ := json.Marshal(mockUserVoteRequest)                                                    // Преобразуем тестовый запрос в JSON
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/votes/%d/vote", voteID), bytes.NewBuffer(requestBody)) // Создаем новый HTTP POST запрос с JSON телом
+	req.Header.Set("Content-Type", "application/json")                                                     // Устанавливаем заголовок Content-Type
+	req.Header.Set("Authorization", "Bearer "+token)                                                       // Устанавливаем заголовок Authorization
 
 	w := httptest.NewRecorder() // Создаем ResponseRecorder для записи ответа
 	router.ServeHTTP(w, req)    // Передаем запрос в роутер
 
-	assert.Equal(t, http.StatusOK, w.Code) // Проверяем, что статус код ответа 200 OK
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String()) // Проверяем, что статус код ответа 202 Accepted
+
+	var response map[string]interface{}             // Объявляем переменную для хранения JSON ответа
+	err = json.Unmarshal(w.Body.Bytes(), &response) // Распаковываем JSON ответ в переменную
+	assert.NoError(t, err)                          // Проверяем, что при распаковке не возникло ошибок
+	assert.NotEmpty(t, response["job_id"])          // Проверяем, что в ответе присутствует поле job_id
+
+	// Дожидаемся отправки транзакции голоса, чтобы задача не пережила тест
+	require.Eventually(t, func() bool {
+		receipt, err := repository.GetUserVoteByVoter(voteID, mockUserVoteRequest.Voter)
+		return err == nil && receipt.Status == models.ReceiptStatusSent
+	}, 2*time.Second, 5*time.Millisecond)
+}
 
-	var response map[string]interface{}              // Объявляем переменную для хранения JSON ответа
-	err := json.Unmarshal(w.Body.Bytes(), &response) // Распаковываем JSON ответ в переменную
-	assert.NoError(t, err)                           // Проверяем, что при распаковке не возникло ошибок
-	assert.NotEmpty(t, response["transaction_id"])   // Проверяем, что в ответе присутствует поле transaction_id
-	assert.NotEmpty(t, response["transaction_hash"]) // Проверяем, что в ответе присутствует поле transaction_hash
+// TestAddUserVoteChoiceAndWallet проверяет отказ в нераспознанном выборе и приведение кошелька EVM к форме d0…
+// для силы голоса и ограничения одного голоса на кошелек
+func TestAddUserVoteChoiceAndWallet(t *testing.T) {
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+	voter := mockUserVoteRequest.Voter
+	evmVoter, err := utils.DecimalToEVM(voter)
+	require.NoError(t, err)
+	require.NoError(t, repository.AddWalletStrength(voter, 100))
+	voteID, err := repository.SaveVote(models.VoteInfo{Title: "t", WalletAddress: "d01juva4qeqjyavwaf4s2vfzpg2y8vj6gl9dtne45"})
+	require.NoError(t, err)
+
+	useDDAppsStub(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/votes/:id/vote", func(c *gin.Context) { c.Set("user", handlers.User{ID: 1, Wallet: evmVoter}) }, handlers.AddUserVoteHandler)
+	send := func(choice string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.UserVoteRequest{Choice: choice})
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/votes/%d/vote", voteID), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer user")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("возможно")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), services.ErrInvalidChoice.Error())
+
+	w = send("За")
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	stored, err := repository.GetUserVoteByVoter(voteID, voter)
+	require.NoError(t, err)
+	assert.Equal(t, 100, stored.VotePower)
+
+	assert.Equal(t, http.StatusConflict, send("Против").Code)
+	require.Eventually(t, func() bool {
+		stored, err := repository.GetUserVoteByVoter(voteID, voter)
+		return err == nil && stored.Status == models.ReceiptStatusSent
+	}, 2*time.Second, 5*time.Millisecond)
 }
@@ -0,0 +1,163 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bitbucket.org/decimalteam/dsc-go-sdk/wallet"
+	"dao_vote/back-end/handlers"
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWalletSignInSession проверяет вход подписью кошелька и права участника DAO по сессии
+func TestWalletSignInSession(t *testing.T) {
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+	account, err := wallet.NewAccountFromMnemonicWords("gasp history river forget aware wide dance velvet weather rain rail dry cliff assault coach jelly choose spirit shoulder isolate kidney outer trust message", "")
+	require.NoError(t, err)
+	require.NoError(t, repository.AddWalletStrength(account.Address(), 300))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/auth/wallet/challenge", handlers.WalletChallengeHandler)
+	router.POST("/auth/wallet/login", handlers.WalletSignInHandler)
+	authRoutes := router.Group("/", handlers.AuthMiddleware())
+	authRoutes.POST("/votes/:id/vote", handlers.RequirePermission(handlers.PermVotesVote), func(c *gin.Context) {
+		user, _ := c.Get("user")
+		c.JSON(http.StatusOK, gin.H{"id": user.(handlers.User).ID, "wallet": user.(handlers.User).Wallet})
+	})
+	authRoutes.POST("/proposals/:id/vote", handlers.AddUserVoteHandler)
+	authRoutes.POST("/votes", handlers.RequirePermission(handlers.PermVotesCreate), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	authRoutes.POST("/auth/wallet/logout", handlers.WalletLogoutHandler)
+
+	send := func(path, token string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	signIn := func() models.WalletSignInResponse {
+		w := send("/auth/wallet/challenge", "", models.WalletChallengeRequest{Wallet: account.Address()})
+		require.Equal(t, http.StatusOK, w.Code)
+		var challenge models.WalletChallenge
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &challenge))
+
+		signature, err := account.Sign([]byte(challenge.Message))
+		require.NoError(t, err)
+		w = send("/auth/wallet/login", "", models.WalletSignInRequest{
+			Wallet:    account.Address(),
+			Nonce:     challenge.Nonce,
+			Signature: hex.EncodeToString(signature),
+		})
+		require.Equal(t, http.StatusOK, w.Code)
+		var session models.WalletSignInResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &session))
+		return session
+	}
+	session := signIn()
+	assert.Equal(t, 300, session.VotePower)
+
+	// Пользователь сессии получает отрицательный ID, общий для всех сессий кошелька
+	w := send("/votes/1/vote", session.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"id":-1000000001,"wallet":"`+account.Address()+`"}`, w.Body.String())
+	assert.JSONEq(t, w.Body.String(), send("/votes/1/vote", signIn().Token, nil).Body.String())
+
+	// Голос транзакцией из сессии кошелька не отправляется с сервисным токеном
+	t.Setenv("DDAPPS_SERVICE_TOKEN", "Bearer service")
+	voteID, err := repository.SaveVote(models.VoteInfo{Title: "t", WalletAddress: "d01proposal"})
+	require.NoError(t, err)
+	w = send(fmt.Sprintf("/proposals/%d/vote", voteID), session.Token, models.UserVoteRequest{Choice: "за"})
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	// Разрешения по умолчанию на сессию кошелька не распространяются
+	assert.Equal(t, http.StatusForbidden, send("/votes", session.Token, nil).Code)
+
+	// Удаление кошелька из vote_strength лишает права голоса
+	require.NoError(t, repository.DeleteWalletStrength(account.Address()))
+	assert.Equal(t, http.StatusForbidden, send("/votes/1/vote", session.Token, nil).Code)
+
+	assert.Equal(t, http.StatusNoContent, send("/auth/wallet/logout", session.Token, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, send("/votes/1/vote", session.Token, nil).Code)
+}
+
+// TestWalletSessionCommitReveal проверяет, что участник, вошедший подписью кошелька, голосует через commit-reveal
+// с правилом допуска votes.vote, пройденным по сохраненному пользователю с его кошельком
+func TestWalletSessionCommitReveal(t *testing.T) {
+	restoreEligibilityRules(t)
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+	account, err := wallet.NewAccountFromMnemonicWords("gasp history river forget aware wide dance velvet weather rain rail dry cliff assault coach jelly choose spirit shoulder isolate kidney outer trust message", "")
+	require.NoError(t, err)
+	require.NoError(t, repository.AddWalletStrength(account.Address(), 300))
+	require.NoError(t, repository.SaveUser(repository.User{ID: 7, Login: "member", Wallet: account.Address(),
+		Subscriptions: []repository.Subscription{{ID: 1, Tag: "pro", Tier: 1}}}))
+	handlers.EligibilityRules = map[string]handlers.EligibilityRule{handlers.PermVotesVote: {Tags: []string{"pro"}}}
+
+	commitEndsAt := time.Now().Add(time.Hour)
+	voteID, err := repository.SaveVote(models.VoteInfo{Title: "t", WalletAddress: "d01proposal", BallotType: models.BallotCommitReveal, CommitEndsAt: &commitEndsAt})
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/auth/wallet/challenge", handlers.WalletChallengeHandler)
+	router.POST("/auth/wallet/login", handlers.WalletSignInHandler)
+	authRoutes := router.Group("/", handlers.AuthMiddleware())
+	authRoutes.POST("/votes/:id/commit", handlers.RequirePermission(handlers.PermVotesVote), handlers.RequireEligibility(handlers.PermVotesVote), handlers.CommitVoteHandler)
+	authRoutes.POST("/votes/:id/reveal", handlers.RequirePermission(handlers.PermVotesVote), handlers.RequireEligibility(handlers.PermVotesVote), handlers.RevealVoteHandler)
+
+	send := func(path, token string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("/auth/wallet/challenge", "", models.WalletChallengeRequest{Wallet: account.Address()})
+	require.Equal(t, http.StatusOK, w.Code)
+	var challenge models.WalletChallenge
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &challenge))
+	signature, err := account.Sign([]byte(challenge.Message))
+	require.NoError(t, err)
+	w = send("/auth/wallet/login", "", models.WalletSignInRequest{Wallet: account.Address(), Nonce: challenge.Nonce, Signature: hex.EncodeToString(signature)})
+	require.Equal(t, http.StatusOK, w.Code)
+	var session models.WalletSignInResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &session))
+
+	commitment := services.BallotCommitment(account.Address(), "за", "salt")
+	w = send(fmt.Sprintf("/votes/%d/commit", voteID), session.Token, models.CommitRequest{Commitment: commitment})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	// Фаза обязательств завершена: раскрытие из той же сессии засчитывает голос
+	_, err = repository.GetDB().Exec("UPDATE votes SET commit_ends_at = ? WHERE id = ?", time.Now().Add(-time.Minute).UTC(), voteID)
+	require.NoError(t, err)
+	w = send(fmt.Sprintf("/votes/%d/reveal", voteID), session.Token, models.RevealRequest{Choice: "за", Salt: "salt"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var revealed models.VoteCommitment
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &revealed))
+	assert.Equal(t, account.Address(), revealed.Voter)
+	assert.NotNil(t, revealed.RevealedAt)
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // mockWithdrawRequest представляет тестовый запрос для вывода средств
@@ -21,22 +22,28 @@ var mockWithdrawRequest = models.WithdrawRequest{
 
 // TestWithdrawHandler тестирует обработчик WithdrawHandler
 func TestWithdrawHandler(t *testing.T) {
-	gin.SetMode(gin.TestMode)                                 // Устанавливаем режим тестирования Gin
-	router := gin.Default()                                   // Создаем новый роутер Gin
-	router.POST("/api/v1/withdraw", handlers.WithdrawHandler) // Регистрируем обработчик для маршрута POST /api/v1/withdraw
+	provider := useLocalIdentity(t)      // Подключаем локального поставщика удостоверений
+	useDDAppsStub(t)                     // Подменяем API вывода средств
+	token, err := provider.IssueToken(1) // Выдаем токен пользователю
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)                                                            // Устанавливаем режим тестирования Gin
+	router := gin.Default()                                                              // Создаем новый роутер Gin
+	router.POST("/api/v1/withdraw", handlers.AuthMiddleware(), handlers.WithdrawHandler) // Регистрируем обработчик для маршрута POST /api/v1/withdraw
 
 	requestBody, _ := json.Marshal(mockWithdrawRequest)                                 // Преобразуем тестовый запрос в JSON
 	req, _ := http.NewRequest("POST", "/api/v1/withdraw", bytes.NewBuffer(requestBody)) // Создаем новый HTTP запрос с JSON телом
 	req.Header.Set("Content-Type", "application/json")                                  // Устанавливаем заголовок Content-Type
-	req.Header.Set("Authorization", "Bearer ")                                          // Устанавливаем заголовок Authorization
+	req.Header.Set("Authorization", "Bearer "+token)                                    // Устанавливаем заголовок Authorization
 
 	w := httptest.NewRecorder() // Создаем ResponseRecorder для записи ответа
 	router.ServeHTTP(w, req)    // Передаем запрос в роутер
 
-	assert.Equal(t, http.StatusOK, w.Code) // Проверяем, что статус код ответа 200 OK
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String()) // Проверяем, что статус код ответа 202 Accepted
+	assert.NotEmpty(t, w.Header().Get("Location"))                 // Проверяем адрес статуса вывода
 
-	var response map[string]interface{}              // Объявляем переменную для хранения JSON ответа
-	err := json.Unmarshal(w.Body.Bytes(), &response) // Распаковываем JSON ответ в переменную
-	assert.NoError(t, err)                           // Проверяем, что при распаковке не возникло ошибок
-	assert.NotEmpty(t, response["transaction_id"])   // Проверяем, что в ответе присутствует поле transaction_id
+	var response map[string]interface{}                      // Объявляем переменную для хранения JSON ответа
+	err = json.Unmarshal(w.Body.Bytes(), &response)          // Распаковываем JSON ответ в переменную
+	assert.NoError(t, err)                                   // Проверяем, что при распаковке не возникло ошибок
+	assert.Equal(t, float64(42), response["transaction_id"]) // Проверяем транзакцию, созданную во внешнем API
 }
@@ -0,0 +1,79 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAPIKeyLifecycle проверяет выдачу, проверку, учет использования и отзыв API ключа
+func TestAPIKeyLifecycle(t *testing.T) {
+	setupTestDB(t)
+
+	created, err := services.CreateAPIKey(models.APIKeyRequest{
+		Name:       "treasury-bot",
+		Scopes:     []string{"withdraw.create", "withdraw.*"},
+		AllowedIPs: []string{"10.0.0.0/8", "192.168.1.5"},
+	}, 7)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Key, services.APIKeyPrefix))
+	assert.True(t, strings.HasPrefix(created.Key, created.Prefix))
+	assert.Equal(t, 7, created.CreatedBy)
+
+	key, err := services.AuthenticateAPIKey(created.Key, "10.1.2.3")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"withdraw.create", "withdraw.*"}, key.Scopes)
+
+	_, err = services.AuthenticateAPIKey(created.Key, "192.168.1.6")
+	assert.ErrorIs(t, err, services.ErrAPIKeyIPNotAllowed)
+	_, err = services.AuthenticateAPIKey(created.Key+"x", "10.1.2.3")
+	assert.ErrorIs(t, err, services.ErrInvalidAPIKey)
+
+	keys, err := services.ListAPIKeys()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.NotNil(t, keys[0].LastUsedAt)
+	assert.Equal(t, "10.1.2.3", keys[0].LastUsedIP)
+
+	revoked, err := services.RevokeAPIKey(created.ID)
+	require.NoError(t, err)
+	require.NotNil(t, revoked.RevokedAt)
+	_, err = services.AuthenticateAPIKey(created.Key, "10.1.2.3")
+	assert.ErrorIs(t, err, services.ErrAPIKeyRevoked)
+}
+
+// TestAPIKeyExpiry проверяет отказ для просроченного ключа
+func TestAPIKeyExpiry(t *testing.T) {
+	setupTestDB(t)
+
+	expiresAt := time.Now().Add(time.Second)
+	created, err := services.CreateAPIKey(models.APIKeyRequest{Name: "report", Scopes: []string{"tables.read"}, ExpiresAt: &expiresAt}, 1)
+	require.NoError(t, err)
+
+	_, err = services.AuthenticateAPIKey(created.Key, "127.0.0.1")
+	require.NoError(t, err)
+
+	time.Sleep(time.Until(expiresAt) + 10*time.Millisecond)
+	_, err = services.AuthenticateAPIKey(created.Key, "127.0.0.1")
+	assert.ErrorIs(t, err, services.ErrAPIKeyExpired)
+}
+
+// TestCreateAPIKeyValidation проверяет отказ для некорректных разрешений, адресов и срока действия
+func TestCreateAPIKeyValidation(t *testing.T) {
+	setupTestDB(t)
+
+	past := time.Now().Add(-time.Hour)
+	for name, req := range map[string]models.APIKeyRequest{
+		"scope":   {Name: "bot", Scopes: []string{"Withdraw Create"}},
+		"ip":      {Name: "bot", Scopes: []string{"withdraw.create"}, AllowedIPs: []string{"10.0.0.300"}},
+		"expired": {Name: "bot", Scopes: []string{"withdraw.create"}, ExpiresAt: &past},
+	} {
+		_, err := services.CreateAPIKey(req, 1)
+		assert.ErrorIs(t, err, services.ErrInvalidAPIKeyRequest, name)
+	}
+}
@@ -0,0 +1,50 @@
+package services_test
+
+import (
+	"testing"
+
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRedactSecrets проверяет замену секретных полей на любой глубине
+func TestRedactSecrets(t *testing.T) {
+	redacted, err := services.RedactSecrets(map[string]interface{}{
+		"name":     "bot",
+		"key":      "dvk_plaintext",
+		"Password": "hunter2",
+		"headers":  map[string]interface{}{"Authorization": "Bearer abc", "Accept": "*/*"},
+		"sessions": []interface{}{map[string]interface{}{"token_hash": "abc", "wallet": "d0x"}},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"bot","key":"[REDACTED]","Password":"[REDACTED]",
+		"headers":{"Authorization":"[REDACTED]","Accept":"*/*"},
+		"sessions":[{"token_hash":"[REDACTED]","wallet":"d0x"}]}`, string(redacted))
+
+	redacted, err = services.RedactSecrets(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "null", string(redacted))
+}
+
+// TestAuditLogAppendOnly проверяет, что записи журнала действий нельзя изменить или удалить
+func TestAuditLogAppendOnly(t *testing.T) {
+	setupTestDB(t)
+	id, err := services.RecordAudit(models.AuditEntry{ActorID: 1, Actor: "admin", Action: "vote.delete", TargetType: "vote", TargetID: "5"},
+		map[string]interface{}{"title": "t"}, nil)
+	require.NoError(t, err)
+
+	_, err = repository.GetDB().Exec("UPDATE audit_log SET actor = 'intruder' WHERE id = ?", id)
+	assert.ErrorContains(t, err, "append-only")
+	_, err = repository.GetDB().Exec("DELETE FROM audit_log WHERE id = ?", id)
+	assert.ErrorContains(t, err, "append-only")
+
+	entries, err := services.ListAuditLog(models.AuditFilter{Action: "vote.delete", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "admin", entries[0].Actor)
+	assert.JSONEq(t, `{"title":"t"}`, string(entries[0].Before))
+	assert.JSONEq(t, `null`, string(entries[0].After))
+}
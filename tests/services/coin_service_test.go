@@ -0,0 +1,102 @@
+package services_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVoteDepositRule проверяет, что подсчет учитывает только депозиты в монете голосования не меньше минимальной суммы
+func TestVoteDepositRule(t *testing.T) {
+	setupTestDB(t)
+	for _, wallet := range []string{"d0alice", "d0bob", "d0carol", "d0dave"} {
+		require.NoError(t, repository.AddWalletStrength(wallet, 100))
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":{"txs":[
+			{"from":"d0alice","message":"за","hash":"H1","coin":"DEL","amount":"5"},
+			{"from":"d0bob","message":"за","hash":"H2","coin":"del","amount":1},
+			{"from":"d0carol","message":"против","hash":"H3","coin":"usdt","amount":"10"},
+			{"from":"d0dave","message":"против","hash":"H4","coin":"del","amount":7.5}
+		]}}`))
+	}))
+	defer server.Close()
+
+	defer func(url string) { services.ExplorerAPIURL = url }(services.ExplorerAPIURL)
+	services.ExplorerAPIURL = server.URL
+
+	voteID, err := repository.SaveVote(models.VoteInfo{Title: "t", WalletAddress: "d01proposal", VoteCoin: "del", MinVoteAmount: 5})
+	require.NoError(t, err)
+
+	results, err := services.FetchVotes(voteID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, results.TotalTransactions)
+	require.Len(t, results.ValidTransactions, 2)
+	assert.Equal(t, "H1", results.ValidTransactions[0].Hash)
+	assert.Equal(t, "H4", results.ValidTransactions[1].Hash)
+	require.Len(t, results.InsufficientTxs, 2)
+	assert.Equal(t, "H2", results.InsufficientTxs[0].Hash)
+	assert.Equal(t, "H3", results.InsufficientTxs[1].Hash)
+
+	// Без монеты голосования правило депозита не действует
+	voteID, err = repository.SaveVote(models.VoteInfo{Title: "t", WalletAddress: "d01proposal"})
+	require.NoError(t, err)
+	results, err = services.FetchVotes(voteID)
+	require.NoError(t, err)
+	assert.Len(t, results.ValidTransactions, 4)
+	assert.Empty(t, results.InsufficientTxs)
+}
+
+// TestVoteDeposit проверяет перевод депозита голоса
+func TestVoteDeposit(t *testing.T) {
+	deposit := services.VoteDeposit(models.VoteInfo{VoteCoin: "usdt", MinVoteAmount: 3}, "d01proposal")
+	assert.Equal(t, models.WithdrawRequest{Amount: 3, Address: "d01proposal", Coin: "usdt"}, deposit)
+
+	deposit = services.VoteDeposit(models.VoteInfo{}, "d01proposal")
+	assert.Equal(t, models.WithdrawRequest{Amount: 1, Address: "d01proposal", Coin: services.DefaultCoin()}, deposit)
+}
+
+// TestWithdrawalCoins проверяет монету вывода средств и правила политики для отдельной монеты
+func TestWithdrawalCoins(t *testing.T) {
+	setupTestDB(t)
+
+	defer func(coins []string) { services.AllowedCoins = coins }(services.AllowedCoins)
+	services.AllowedCoins = []string{"del", "usdt"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"type":"success","data":{"transaction_id":5}}`))
+	}))
+	defer server.Close()
+
+	defer func(url string) { services.DDAppsAPIURL = url }(services.DDAppsAPIURL)
+	services.DDAppsAPIURL = server.URL
+
+	_, err := services.CreateWithdrawalPolicy(models.WithdrawalPolicyRequest{Type: models.PolicyPerTransactionMax, Amount: 10, Coin: "USDT"})
+	require.NoError(t, err)
+	_, err = services.CreateWithdrawalPolicy(models.WithdrawalPolicyRequest{Type: models.PolicyPerTransactionMax, Amount: 10, Coin: "btc"})
+	assert.ErrorIs(t, err, services.ErrInvalidPolicy)
+
+	requester := models.Requester{UserID: 1, Wallet: "d01requester"}
+
+	withdrawal, err := services.CreateWithdrawal(requester, models.WithdrawRequest{Amount: 50, Address: "d01target"}, "token")
+	require.NoError(t, err)
+	assert.Equal(t, "del", withdrawal.Coin)
+
+	_, err = services.CreateWithdrawal(requester, models.WithdrawRequest{Amount: 50, Address: "d01target", Coin: "usdt"}, "token")
+	var violation *services.PolicyViolation
+	assert.ErrorAs(t, err, &violation)
+
+	withdrawal, err = services.CreateWithdrawal(requester, models.WithdrawRequest{Amount: 5, Address: "d01target", Coin: " USDT "}, "token")
+	require.NoError(t, err)
+	assert.Equal(t, "usdt", withdrawal.Coin)
+
+	_, err = services.CreateWithdrawal(requester, models.WithdrawRequest{Amount: 5, Address: "d01target", Coin: "btc"}, "token")
+	assert.ErrorIs(t, err, services.ErrCoinNotAllowed)
+}
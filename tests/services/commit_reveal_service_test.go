@@ -0,0 +1,163 @@
+package services_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPrepareVoteResultsCommitReveal проверяет, что учитываются только раскрытые голоса, совпавшие с обязательствами
+func TestPrepareVoteResultsCommitReveal(t *testing.T) {
+	setupTestDB(t)
+	for _, wallet := range []string{"d0alice", "d0bob", "d0carol", "d0dave"} {
+		require.NoError(t, repository.AddWalletStrength(wallet, 100))
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":{"txs":[
+			{"from":"d0alice","message":"commit:` + services.BallotCommitment("d0alice", "за", "s1") + `","hash":"a1"},
+			{"from":"d0bob","message":"commit:` + services.BallotCommitment("d0bob", "против", "s2") + `","hash":"b1"},
+			{"from":"d0carol","message":"commit:` + services.BallotCommitment("d0carol", "за", "s3") + `","hash":"c1"},
+			{"from":"d0dave","message":"за","hash":"d1"},
+			{"from":"d0alice","message":"reveal:за:s1","hash":"a2"},
+			{"from":"d0bob","message":"reveal:за:s2","hash":"b2"}
+		]}}`))
+	}))
+	defer server.Close()
+
+	defer func(url string) { services.ExplorerAPIURL = url }(services.ExplorerAPIURL)
+	services.ExplorerAPIURL = server.URL
+
+	voteID, err := repository.SaveVote(models.VoteInfo{Title: "t", WalletAddress: "d01proposal", BallotType: models.BallotCommitReveal})
+	require.NoError(t, err)
+
+	results, err := services.FetchVotes(voteID)
+	require.NoError(t, err)
+
+	require.Len(t, results.ValidTransactions, 1)
+	assert.Equal(t, "a1", results.ValidTransactions[0].Hash)
+	assert.Equal(t, 1, results.VotedMembers)
+	assert.True(t, strings.HasPrefix(results.VotesFor, "100/"), results.VotesFor)
+
+	require.Len(t, results.UnrevealedTxs, 1)
+	assert.Equal(t, "d0carol", results.UnrevealedTxs[0].From)
+	require.Len(t, results.MismatchedReveals, 1)
+	assert.Equal(t, "b2", results.MismatchedReveals[0].Hash)
+	require.Len(t, results.InvalidMessageTxs, 1)
+	assert.Equal(t, "d1", results.InvalidMessageTxs[0].Hash)
+}
+
+// TestOpenBallotIgnoresCommitMemo проверяет, что сообщение commit: не переводит открытое голосование в режим commit-reveal
+func TestOpenBallotIgnoresCommitMemo(t *testing.T) {
+	setupTestDB(t)
+	for _, wallet := range []string{"d0alice", "d0bob"} {
+		require.NoError(t, repository.AddWalletStrength(wallet, 100))
+	}
+
+	openTxs := `{"from":"d0alice","message":"за","hash":"a1"},{"from":"d0bob","message":"против","hash":"b1"}`
+	txs := openTxs
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":{"txs":[` + txs + `]}}`))
+	}))
+	defer server.Close()
+
+	defer func(url string) { services.ExplorerAPIURL = url }(services.ExplorerAPIURL)
+	services.ExplorerAPIURL = server.URL
+
+	voteID, err := repository.SaveVote(models.VoteInfo{Title: "t", WalletAddress: "d01proposal", BallotType: models.BallotOpen})
+	require.NoError(t, err)
+	expected, err := services.FetchVotes(voteID)
+	require.NoError(t, err)
+	require.Len(t, expected.ValidTransactions, 2)
+
+	txs = openTxs + `,{"from":"d0mallory","message":"commit:` + services.BallotCommitment("d0mallory", "за", "s") + `","hash":"m1"}`
+	results, err := services.FetchVotes(voteID)
+	require.NoError(t, err)
+	assert.Equal(t, expected.ValidTransactions, results.ValidTransactions)
+	assert.Equal(t, expected.VotesFor, results.VotesFor)
+	assert.Equal(t, expected.VotesAgainst, results.VotesAgainst)
+	assert.Equal(t, expected.VotedMembers, results.VotedMembers)
+	assert.Empty(t, results.UnrevealedTxs)
+
+	daoResults := services.PrepareVoteResults(models.WithdrawOrderResponse{Result: struct {
+		Txs []models.Transaction `json:"txs"`
+	}{Txs: []models.Transaction{
+		{From: "d0alice", Message: "за", VotePower: 100, Hash: "a1"},
+		{From: "d0mallory", Message: "commit:" + services.BallotCommitment("d0mallory", "за", "s"), Hash: "m1"},
+	}}})
+	require.Len(t, daoResults.ValidTransactions, 1)
+	assert.Equal(t, "a1", daoResults.ValidTransactions[0].Hash)
+}
+
+// TestCommitRevealInvalidTimestamp проверяет, что транзакции без распознаваемого времени не учитываются в окнах голосования
+func TestCommitRevealInvalidTimestamp(t *testing.T) {
+	setupTestDB(t)
+	for _, wallet := range []string{"d0alice", "d0bob"} {
+		require.NoError(t, repository.AddWalletStrength(wallet, 100))
+	}
+
+	commitEndsAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":{"txs":[
+			{"from":"d0alice","message":"commit:` + services.BallotCommitment("d0alice", "за", "s1") + `","hash":"a1","timestamp":"2023-12-31T00:00:00Z"},
+			{"from":"d0alice","message":"reveal:за:s1","hash":"a2","timestamp":"2024-01-02T00:00:00Z"},
+			{"from":"d0bob","message":"commit:` + services.BallotCommitment("d0bob", "за", "s2") + `","hash":"b1","timestamp":"not a time"},
+			{"from":"d0bob","message":"reveal:за:s2","hash":"b2"}
+		]}}`))
+	}))
+	defer server.Close()
+
+	defer func(url string) { services.ExplorerAPIURL = url }(services.ExplorerAPIURL)
+	services.ExplorerAPIURL = server.URL
+
+	voteID, err := repository.SaveVote(models.VoteInfo{Title: "t", WalletAddress: "d01proposal", BallotType: models.BallotCommitReveal, CommitEndsAt: &commitEndsAt})
+	require.NoError(t, err)
+
+	results, err := services.FetchVotes(voteID)
+	require.NoError(t, err)
+	require.Len(t, results.ValidTransactions, 1)
+	assert.Equal(t, "a1", results.ValidTransactions[0].Hash)
+	require.Len(t, results.InvalidMessageTxs, 2)
+	assert.Equal(t, "b1", results.InvalidMessageTxs[0].Hash)
+	assert.Equal(t, "b2", results.InvalidMessageTxs[1].Hash)
+}
+
+// TestCommitRevealThroughAPI проверяет подачу обязательства и раскрытие через API с учетом окон голосования
+func TestCommitRevealThroughAPI(t *testing.T) {
+	setupTestDB(t)
+	require.NoError(t, repository.AddWalletStrength("d0alice", 100))
+
+	commitEndsAt := time.Now().Add(time.Hour)
+	vote := models.VoteInfo{ID: 1, BallotType: models.BallotCommitReveal, CommitEndsAt: &commitEndsAt}
+
+	commitment := services.BallotCommitment("d0alice", "За", "secret")
+	_, err := services.SubmitCommitment(vote, "d0alice", commitment)
+	require.NoError(t, err)
+
+	_, err = services.SubmitCommitment(vote, "d0alice", commitment)
+	assert.ErrorIs(t, err, services.ErrAlreadyVoted)
+
+	// Раскрытие до окончания приема обязательств отклоняется
+	_, err = services.RevealVote(vote, "d0alice", models.RevealRequest{Choice: "За", Salt: "secret"})
+	assert.ErrorIs(t, err, services.ErrRevealPhaseClosed)
+
+	commitEndsAt = time.Now().Add(-time.Minute)
+	_, err = services.SubmitCommitment(vote, "d0bob", commitment)
+	assert.ErrorIs(t, err, services.ErrCommitPhaseClosed)
+
+	_, err = services.RevealVote(vote, "d0alice", models.RevealRequest{Choice: "Против", Salt: "secret"})
+	assert.ErrorIs(t, err, services.ErrCommitmentMismatch)
+
+	revealed, err := services.RevealVote(vote, "d0alice", models.RevealRequest{Choice: "За", Salt: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, "За", revealed.Choice)
+	assert.NotNil(t, revealed.RevealedAt)
+}
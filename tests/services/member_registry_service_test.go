@@ -0,0 +1,152 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"dao_vote/back-end/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memberAddress возвращает адрес d0… для EVM адреса
+func memberAddress(t *testing.T, evm string) string {
+	t.Helper()
+	address, err := utils.NormalizeAddress(evm)
+	require.NoError(t, err)
+	return address
+}
+
+// TestParseMembers проверяет разбор CSV с заголовком и без, JSON и отказ для некорректных записей
+func TestParseMembers(t *testing.T) {
+	first := memberAddress(t, "0x0000000000000000000000000000000000000001")
+
+	members, err := services.ParseMembers(services.MemberFormatCSV, strings.NewReader("vote_power,wallet_address\n10, 0x0000000000000000000000000000000000000001\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []models.Member{{WalletAddress: first, VotePower: 10}}, members)
+
+	members, err = services.ParseMembers(services.MemberFormatCSV, strings.NewReader(first+",20\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []models.Member{{WalletAddress: first, VotePower: 20}}, members)
+
+	members, err = services.ParseMembers(services.MemberFormatJSON, strings.NewReader(`[{"wallet_address":"`+first+`","vote_power":30}]`))
+	require.NoError(t, err)
+	assert.Equal(t, []models.Member{{WalletAddress: first, VotePower: 30}}, members)
+
+	for name, input := range map[string]string{
+		"address":   "d01invalid,10\n",
+		"power":     first + ",ten\n",
+		"zero":      first + ",0\n",
+		"duplicate": first + ",10\n0x0000000000000000000000000000000000000001,20\n",
+		"header":    "wallet_address,power\n" + first + ",10\n",
+	} {
+		_, err := services.ParseMembers(services.MemberFormatCSV, strings.NewReader(input))
+		assert.ErrorIs(t, err, services.ErrInvalidMemberList, name)
+	}
+}
+
+// TestImportMembers проверяет пробный расчет разницы и ее атомарное применение
+func TestImportMembers(t *testing.T) {
+	setupTestDB(t)
+	first := memberAddress(t, "0x0000000000000000000000000000000000000001")
+	second := memberAddress(t, "0x0000000000000000000000000000000000000002")
+	third := memberAddress(t, "0x0000000000000000000000000000000000000003")
+	fourth := memberAddress(t, "0x0000000000000000000000000000000000000004")
+	require.NoError(t, repository.AddWalletStrength(first, 10))
+	require.NoError(t, repository.AddWalletStrength(second, 20))
+	require.NoError(t, repository.AddWalletStrength(third, 30))
+
+	incoming := []models.Member{{WalletAddress: first, VotePower: 10}, {WalletAddress: second, VotePower: 25}, {WalletAddress: fourth, VotePower: 40}}
+	diff, err := services.ImportMembers(incoming, false, models.VoteStrengthChangeMeta{})
+	require.NoError(t, err)
+	assert.False(t, diff.Applied)
+	assert.Equal(t, []models.Member{{WalletAddress: fourth, VotePower: 40}}, diff.Added)
+	assert.Equal(t, []models.Member{{WalletAddress: third, VotePower: 30}}, diff.Removed)
+	assert.Equal(t, []models.MemberPowerChange{{WalletAddress: second, OldVotePower: 20, NewVotePower: 25}}, diff.Changed)
+	assert.Equal(t, 1, diff.Unchanged)
+
+	// Пробный запуск не меняет реестр
+	members, err := services.ListMembers()
+	require.NoError(t, err)
+	assert.Len(t, members, 3)
+
+	// Разница, рассчитанная по устаревшему реестру, не применяется частично
+	require.NoError(t, repository.DeleteWalletStrength(third))
+	assert.ErrorIs(t, repository.ApplyMemberDiff(diff, models.VoteStrengthChangeMeta{}), repository.ErrMemberRegistryChanged)
+	members, err = services.ListMembers()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []models.Member{{WalletAddress: first, VotePower: 10}, {WalletAddress: second, VotePower: 20}}, members)
+
+	diff, err = services.ImportMembers(incoming, true, models.VoteStrengthChangeMeta{ActorID: 1, Reason: "import"})
+	require.NoError(t, err)
+	assert.True(t, diff.Applied)
+	members, err = services.ListMembers()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, incoming, members)
+}
+
+// TestVoteStrengthHistory проверяет историю изменений силы голоса и силу голоса на момент времени
+func TestVoteStrengthHistory(t *testing.T) {
+	setupTestDB(t)
+	wallet := memberAddress(t, "0x0000000000000000000000000000000000000001")
+	legacy := memberAddress(t, "0x0000000000000000000000000000000000000002")
+	meta := models.VoteStrengthChangeMeta{ActorID: 1, Actor: "admin"}
+
+	// Кошелек, добавленный до ведения истории
+	_, err := repository.GetDB().Exec("INSERT INTO vote_strength (wallet_address, vote_power) VALUES (?, ?)", legacy, 5)
+	require.NoError(t, err)
+
+	beforeAdd := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, repository.AddMember(models.Member{WalletAddress: wallet, VotePower: 10}, meta))
+	afterAdd := time.Now()
+	time.Sleep(5 * time.Millisecond)
+
+	change, err := services.UpdateMemberPower(wallet, models.VoteStrengthUpdateRequest{VotePower: 20, Reason: " PRO round 2 "}, meta)
+	require.NoError(t, err)
+	assert.Equal(t, 10, *change.OldVotePower)
+	assert.Equal(t, 20, *change.NewVotePower)
+	assert.Equal(t, "PRO round 2", change.Reason)
+	afterUpdate := time.Now()
+	time.Sleep(5 * time.Millisecond)
+
+	// Та же сила голоса не пополняет историю
+	change, err = services.UpdateMemberPower(wallet, models.VoteStrengthUpdateRequest{VotePower: 20}, meta)
+	require.NoError(t, err)
+	assert.Nil(t, change.NewVotePower)
+
+	_, err = services.UpdateMemberPower(memberAddress(t, "0x0000000000000000000000000000000000000003"), models.VoteStrengthUpdateRequest{VotePower: 1}, meta)
+	assert.ErrorIs(t, err, repository.ErrMemberNotFound)
+
+	require.NoError(t, repository.RemoveMember(wallet, meta))
+
+	history, err := services.GetVoteStrengthHistory(wallet)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Nil(t, history[0].NewVotePower)
+	assert.Equal(t, 20, *history[0].OldVotePower)
+	assert.Nil(t, history[2].OldVotePower)
+	assert.Equal(t, "admin", history[2].Actor)
+
+	for name, tc := range map[string]struct {
+		wallet    string
+		asOf      time.Time
+		member    bool
+		votePower int
+	}{
+		"before add":    {wallet, beforeAdd, false, 0},
+		"after add":     {wallet, afterAdd, true, 10},
+		"after update":  {wallet, afterUpdate, true, 20},
+		"after removal": {wallet, time.Now(), false, 0},
+		"legacy":        {legacy, beforeAdd, true, 5},
+	} {
+		power, err := services.GetVoteStrengthAsOf(tc.wallet, tc.asOf)
+		require.NoError(t, err, name)
+		assert.Equal(t, tc.member, power.Member, name)
+		assert.Equal(t, tc.votePower, power.VotePower, name)
+	}
+}
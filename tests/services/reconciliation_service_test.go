@@ -0,0 +1,89 @@
+package services_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReconcileVotes проверяет отчет сверки и восстановление локальных голосов по блокчейну
+func TestReconcileVotes(t *testing.T) {
+	setupTestDB(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":{"txs":[
+			{"from":"d01alice","message":"за","hash":"HASH1"},
+			{"from":"d01bob","message":"против","hash":"HASH2"},
+			{"from":"d01carol","message":"да","hash":"HASH3"},
+			{"from":"d01dave","message":"привет","hash":"HASH4"},
+			{"from":"d01mallory","message":"за","hash":"HASH5"}
+		]}}`))
+	}))
+	defer server.Close()
+
+	defer func(url string) { services.ExplorerAPIURL = url }(services.ExplorerAPIURL)
+	services.ExplorerAPIURL = server.URL
+
+	voteID, err := repository.SaveVote(models.VoteInfo{Title: "t", WalletAddress: "d01proposal"})
+	require.NoError(t, err)
+
+	// alice совпадает, у bob расхождение выбора, erin не отправила транзакцию, carol нет в базе,
+	// mallory не участник DAO
+	for _, wallet := range []string{"d01alice", "d01bob", "d01carol", "d01erin"} {
+		require.NoError(t, repository.AddWalletStrength(wallet, 10))
+	}
+	for _, userVote := range []models.UserVote{
+		{VoteID: voteID, Voter: "d01alice", Choice: "За", VotePower: 10},
+		{VoteID: voteID, Voter: "d01bob", Choice: "За", VotePower: 20},
+		{VoteID: voteID, Voter: "d01erin", Choice: "Против", VotePower: 30},
+	} {
+		_, err := repository.AddUserVote(userVote)
+		require.NoError(t, err)
+	}
+
+	report, err := services.ReconcileVotes(voteID, false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.LocalVotes)
+	assert.Equal(t, 4, report.OnChainVotes)
+	assert.Equal(t, 1, report.Matched)
+	require.Len(t, report.MissingOnChain, 1)
+	assert.Equal(t, "d01erin", report.MissingOnChain[0].Voter)
+	require.Len(t, report.MissingLocal, 1)
+	assert.Equal(t, "d01carol", report.MissingLocal[0].From)
+	require.Len(t, report.NullVotePowerTxs, 1)
+	assert.Equal(t, "d01mallory", report.NullVotePowerTxs[0].From)
+	require.Len(t, report.ChoiceMismatches, 1)
+	assert.Equal(t, "d01bob", report.ChoiceMismatches[0].UserVote.Voter)
+	assert.False(t, report.Repaired)
+
+	report, err = services.ReconcileVotes(voteID, true)
+	require.NoError(t, err)
+	assert.True(t, report.Repaired)
+	assert.Equal(t, 2, report.RepairedRecords)
+
+	bob, err := repository.GetUserVoteByVoter(voteID, "d01bob")
+	require.NoError(t, err)
+	assert.Equal(t, "Против", bob.Choice)
+	assert.Equal(t, models.ReceiptStatusConfirmed, bob.Status)
+
+	carol, err := repository.GetUserVoteByVoter(voteID, "d01carol")
+	require.NoError(t, err)
+	assert.Equal(t, "За", carol.Choice)
+	assert.Equal(t, "HASH3", carol.TransactionHash)
+	assert.Equal(t, 10, carol.VotePower)
+	_, err = repository.GetUserVoteByVoter(voteID, "d01mallory")
+	assert.ErrorIs(t, err, repository.ErrUserVoteNotFound)
+
+	report, err = services.ReconcileVotes(voteID, false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Matched)
+	assert.Empty(t, report.MissingLocal)
+	assert.Empty(t, report.ChoiceMismatches)
+	assert.Len(t, report.NullVotePowerTxs, 1)
+}
@@ -0,0 +1,121 @@
+package services_test
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"bitbucket.org/decimalteam/dsc-go-sdk/wallet"
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"dao_vote/back-end/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMnemonic мнемоника тестового кошелька d01xp6aqad49te7vsfga6str8hrdeh24r9jhplgxv
+const testMnemonic = "gasp history river forget aware wide dance velvet weather rain rail dry cliff assault coach jelly choose spirit shoulder isolate kidney outer trust message"
+
+// signVote подписывает каноническое сообщение голоса ключом тестового кошелька
+func signVote(t *testing.T, account *wallet.Account, voteID int, choice string, timestamp int64) models.SignedVoteRequest {
+	t.Helper()
+	signature, err := account.Sign([]byte(services.SignedVoteMessage(voteID, choice, timestamp)))
+	require.NoError(t, err)
+	return models.SignedVoteRequest{
+		Voter:     account.Address(),
+		Choice:    choice,
+		Timestamp: timestamp,
+		Signature: hex.EncodeToString(signature),
+	}
+}
+
+// TestVerifyWalletSignature проверяет восстановление адреса кошелька Decimal из подписи
+func TestVerifyWalletSignature(t *testing.T) {
+	account, err := wallet.NewAccountFromMnemonicWords(testMnemonic, "")
+	require.NoError(t, err)
+
+	message := []byte("GODAO test message")
+	signature, err := account.Sign(message)
+	require.NoError(t, err)
+
+	address, err := utils.VerifyWalletSignature(message, signature, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "d01xp6aqad49te7vsfga6str8hrdeh24r9jhplgxv", address)
+
+	address, err = utils.VerifyWalletSignature(message, signature[:64], account.PubKey().Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, account.Address(), address)
+
+	_, err = utils.VerifyWalletSignature([]byte("other message"), signature, account.PubKey().Bytes())
+	assert.ErrorIs(t, err, utils.ErrInvalidSignature)
+}
+
+// TestSubmitSignedVote проверяет прием подписанного голоса участника DAO
+func TestSubmitSignedVote(t *testing.T) {
+	setupTestDB(t)
+
+	account, err := wallet.NewAccountFromMnemonicWords(testMnemonic, "")
+	require.NoError(t, err)
+	require.NoError(t, repository.AddWalletStrength(account.Address(), 500))
+
+	vote := models.VoteInfo{ID: 1, VoteMode: models.VoteModeOffChain, VoteChangePolicy: models.VoteChangeReject}
+	now := time.Now().Unix()
+
+	signedVote, err := services.SubmitSignedVote(vote, signVote(t, account, 1, "За", now))
+	require.NoError(t, err)
+	assert.Equal(t, 500, signedVote.VotePower)
+	assert.NotEmpty(t, signedVote.BallotHash)
+
+	_, err = services.SubmitSignedVote(vote, signVote(t, account, 1, "Против", now+1))
+	assert.ErrorIs(t, err, services.ErrAlreadyVoted)
+
+	// Подпись другого голосования не подходит к этому голосованию
+	req := signVote(t, account, 2, "За", now)
+	_, err = services.SubmitSignedVote(models.VoteInfo{ID: 3, VoteMode: models.VoteModeHybrid}, req)
+	assert.Error(t, err)
+
+	// Просроченная подпись отклоняется
+	_, err = services.SubmitSignedVote(models.VoteInfo{ID: 4, VoteMode: models.VoteModeHybrid}, signVote(t, account, 4, "За", now-3600))
+	assert.ErrorIs(t, err, services.ErrSignatureExpired)
+
+	// Голосование только с транзакциями не принимает подписанные голоса
+	_, err = services.SubmitSignedVote(models.VoteInfo{ID: 5, VoteMode: models.VoteModeOnChain}, signVote(t, account, 5, "За", now))
+	assert.ErrorIs(t, err, services.ErrSignedVotesNotAccepted)
+}
+
+// TestSubmitSignedVoteReplace проверяет, что при политике replace голос заменяет только более новая подпись
+func TestSubmitSignedVoteReplace(t *testing.T) {
+	setupTestDB(t)
+
+	account, err := wallet.NewAccountFromMnemonicWords(testMnemonic, "")
+	require.NoError(t, err)
+	require.NoError(t, repository.AddWalletStrength(account.Address(), 500))
+
+	vote := models.VoteInfo{ID: 1, VoteMode: models.VoteModeOffChain, VoteChangePolicy: models.VoteChangeReplace}
+	now := time.Now().Unix()
+
+	_, err = services.SubmitSignedVote(vote, signVote(t, account, 1, "За", now))
+	require.NoError(t, err)
+
+	_, err = services.SubmitSignedVote(vote, signVote(t, account, 1, "Против", now))
+	assert.ErrorIs(t, err, services.ErrStaleSignature)
+	_, err = services.SubmitSignedVote(vote, signVote(t, account, 1, "Против", now-60))
+	assert.ErrorIs(t, err, services.ErrStaleSignature)
+
+	signedVote, err := services.SubmitSignedVote(vote, signVote(t, account, 1, "Против", now+1))
+	require.NoError(t, err)
+	assert.Equal(t, "Против", signedVote.Choice)
+}
+
+// TestSubmitSignedVoteRequiresMembership проверяет, что кошелек вне vote_strength не может голосовать
+func TestSubmitSignedVoteRequiresMembership(t *testing.T) {
+	setupTestDB(t)
+
+	account, err := wallet.NewAccountFromMnemonicWords(testMnemonic, "")
+	require.NoError(t, err)
+
+	vote := models.VoteInfo{ID: 1, VoteMode: models.VoteModeOffChain}
+	_, err = services.SubmitSignedVote(vote, signVote(t, account, 1, "За", time.Now().Unix()))
+	assert.ErrorIs(t, err, services.ErrNotDAOMember)
+}
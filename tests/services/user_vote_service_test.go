@@ -0,0 +1,99 @@
+package services_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestDB инициализирует временную базу данных для тестов
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	require.NoError(t, repository.InitDB(filepath.Join(t.TempDir(), "votes.db")))
+}
+
+// TestCastUserVoteRejectsSecondVote проверяет, что повторный голос отклоняется при политике "reject"
+func TestCastUserVoteRejectsSecondVote(t *testing.T) {
+	setupTestDB(t)
+
+	vote := models.VoteInfo{ID: 1, VoteChangePolicy: models.VoteChangeReject}
+	userVote := models.UserVote{VoteID: 1, Voter: "d01voter", Choice: "За", VotePower: 10}
+
+	id, replaced, err := services.CastUserVote(vote, userVote)
+	require.NoError(t, err)
+	assert.NotZero(t, id)
+	assert.False(t, replaced)
+
+	userVote.Choice = "Против"
+	_, _, err = services.CastUserVote(vote, userVote)
+	assert.ErrorIs(t, err, services.ErrAlreadyVoted)
+
+	stored, err := repository.GetUserVoteByVoter(1, "d01voter")
+	require.NoError(t, err)
+	assert.Equal(t, "За", stored.Choice)
+}
+
+// TestCastUserVoteRetriesFailedVote проверяет, что голос с неотправленной транзакцией можно подать заново при политике "reject"
+func TestCastUserVoteRetriesFailedVote(t *testing.T) {
+	setupTestDB(t)
+
+	vote := models.VoteInfo{ID: 3, VoteChangePolicy: models.VoteChangeReject}
+	userVote := models.UserVote{VoteID: 3, Voter: "d01voter", Choice: "За", VotePower: 10}
+
+	firstID, _, err := services.CastUserVote(vote, userVote)
+	require.NoError(t, err)
+	require.NoError(t, repository.UpdateUserVoteStatus(firstID, models.ReceiptStatusFailed))
+
+	userVote.Choice = "Против"
+	secondID, replaced, err := services.CastUserVote(vote, userVote)
+	require.NoError(t, err)
+	assert.True(t, replaced)
+	assert.Equal(t, firstID, secondID)
+
+	stored, err := repository.GetUserVoteByVoter(3, "d01voter")
+	require.NoError(t, err)
+	assert.Equal(t, "Против", stored.Choice)
+	assert.Equal(t, models.ReceiptStatusPending, stored.Status)
+
+	_, _, err = services.CastUserVote(vote, userVote)
+	assert.ErrorIs(t, err, services.ErrAlreadyVoted)
+}
+
+// TestCastUserVoteReplacesChoice проверяет, что повторный голос заменяет выбор при политике "replace"
+func TestCastUserVoteReplacesChoice(t *testing.T) {
+	setupTestDB(t)
+
+	vote := models.VoteInfo{ID: 2, VoteChangePolicy: models.VoteChangeReplace}
+	userVote := models.UserVote{VoteID: 2, Voter: "d01voter", Choice: "За", VotePower: 10}
+
+	firstID, _, err := services.CastUserVote(vote, userVote)
+	require.NoError(t, err)
+
+	userVote.Choice = "Против"
+	secondID, replaced, err := services.CastUserVote(vote, userVote)
+	require.NoError(t, err)
+	assert.True(t, replaced)
+	assert.Equal(t, firstID, secondID)
+
+	votes, err := repository.GetUserVotes(2)
+	require.NoError(t, err)
+	require.Len(t, votes, 1)
+	assert.Equal(t, "Против", votes[0].Choice)
+}
+
+// TestAddUserVoteUniqueVoter проверяет ограничение уникальности (vote_id, voter)
+func TestAddUserVoteUniqueVoter(t *testing.T) {
+	setupTestDB(t)
+
+	userVote := models.UserVote{VoteID: 3, Voter: "d01voter", Choice: "За", VotePower: 10}
+	_, err := repository.AddUserVote(userVote)
+	require.NoError(t, err)
+
+	_, err = repository.AddUserVote(userVote)
+	assert.ErrorIs(t, err, repository.ErrUserVoteExists)
+}
@@ -0,0 +1,182 @@
+package services_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pro возвращает баланс в минимальных единицах PRO (18 знаков)
+func pro(coins int) string {
+	return fmt.Sprintf("%d000000000000000000", coins)
+}
+
+// TestVotePower проверяет расчет силы голоса по балансу: курс, порог, ограничение и некорректный баланс
+func TestVotePower(t *testing.T) {
+	formula := models.VotePowerFormula{Coin: "pro", Decimals: 18, CoinsPerVote: 1, MinVotePower: 1}
+
+	power, err := services.VotePower(formula, pro(25))
+	require.NoError(t, err)
+	assert.Equal(t, 25, power)
+
+	power, err = services.VotePower(formula, "999999999999999999")
+	require.NoError(t, err)
+	assert.Equal(t, 0, power, "меньше одной монеты - не участник")
+
+	formula.CoinsPerVote, formula.MinVotePower, formula.MaxVotePower = 10, 2, 5
+	for amount, expected := range map[string]int{pro(19): 0, pro(20): 2, pro(49): 4, pro(1000): 5, "1" + pro(1000000000000): 5} {
+		power, err := services.VotePower(formula, amount)
+		require.NoError(t, err)
+		assert.Equal(t, expected, power, amount)
+	}
+
+	for _, amount := range []string{"", "1.5", "-1", "ten"} {
+		_, err := services.VotePower(formula, amount)
+		assert.Error(t, err, amount)
+	}
+}
+
+// TestSyncVotePower проверяет синхронизацию с локальным сервером эксплорера: пробный запуск, применение,
+// держателей монеты, сохранение записи кошелька с ошибкой баланса и отчет запуска
+func TestSyncVotePower(t *testing.T) {
+	setupTestDB(t)
+	first := memberAddress(t, "0x0000000000000000000000000000000000000001")
+	second := memberAddress(t, "0x0000000000000000000000000000000000000002")
+	third := memberAddress(t, "0x0000000000000000000000000000000000000003")
+	fourth := memberAddress(t, "0x0000000000000000000000000000000000000004")
+	require.NoError(t, repository.AddWalletStrength(first, 10))
+	require.NoError(t, repository.AddWalletStrength(second, 20))
+	require.NoError(t, repository.AddWalletStrength(third, 30))
+
+	balances := map[string]string{first: pro(5), second: "0"}
+	holders := `{"result":{"count":2,"holders":[{"address":"` + first + `","amount":"` + pro(5) + `"},{"address":"0x0000000000000000000000000000000000000004","amount":"` + pro(2) + `"}]}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/coin/pro/holders") {
+			w.Write([]byte(holders))
+			return
+		}
+		address := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/address/"), "/balances")
+		amount, exists := balances[address]
+		if !exists {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"result":{"balances":[{"coin":"del","amount":"1"},{"coin":"PRO","amount":"` + amount + `"}]}}`))
+	}))
+	defer server.Close()
+
+	defer func(url string) { services.ExplorerAPIURL = url }(services.ExplorerAPIURL)
+	services.ExplorerAPIURL = server.URL
+	defer func(formula models.VotePowerFormula) { services.VotePowerFormulaConfig = formula }(services.VotePowerFormulaConfig)
+	services.VotePowerFormulaConfig = models.VotePowerFormula{Coin: "pro", Decimals: 18, CoinsPerVote: 1, MinVotePower: 1}
+
+	meta := models.VoteStrengthChangeMeta{ActorID: 7, Actor: "admin", Reason: "vote power sync"}
+	run, err := services.SyncVotePower(false, meta)
+	require.NoError(t, err)
+	assert.False(t, run.Applied)
+	assert.Equal(t, []models.MemberPowerChange{{WalletAddress: first, OldVotePower: 10, NewVotePower: 5}}, run.Changes.Changed)
+	assert.Equal(t, []models.Member{{WalletAddress: second, VotePower: 20}}, run.Changes.Removed)
+	assert.Empty(t, run.Changes.Added, "без discover держатели монеты не добавляются")
+	require.Len(t, run.Errors, 1)
+	assert.Equal(t, third, run.Errors[0].WalletAddress)
+	assert.Equal(t, 1, run.Unchanged)
+
+	members, err := repository.ListMembers()
+	require.NoError(t, err)
+	assert.Len(t, members, 3, "пробный запуск не меняет реестр")
+
+	services.VotePowerFormulaConfig.Discover = true
+	run, err = services.SyncVotePower(true, meta)
+	require.NoError(t, err)
+	assert.True(t, run.Applied)
+	assert.Equal(t, 2, run.Discovered)
+	assert.Equal(t, 3, run.Checked)
+	assert.Equal(t, 1, run.Failed)
+	assert.Equal(t, []models.Member{{WalletAddress: fourth, VotePower: 2}}, run.Changes.Added)
+
+	members, err = repository.ListMembers()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []models.Member{
+		{WalletAddress: first, VotePower: 5},
+		{WalletAddress: third, VotePower: 30},
+		{WalletAddress: fourth, VotePower: 2},
+	}, members)
+
+	history, err := repository.GetVoteStrengthHistory(first)
+	require.NoError(t, err)
+	require.NotEmpty(t, history)
+	assert.Equal(t, "admin", history[0].Actor)
+	assert.Equal(t, 5, *history[0].NewVotePower)
+
+	runs, err := services.ListVotePowerSyncRuns(10, 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, run.ID, runs[0].ID)
+	assert.Nil(t, runs[0].Changes)
+	assert.True(t, runs[0].Formula.Discover)
+
+	saved, err := services.GetVotePowerSyncRun(run.ID)
+	require.NoError(t, err)
+	assert.Equal(t, run.Changes.Added, saved.Changes.Added)
+	assert.Equal(t, run.Errors, saved.Errors)
+
+	_, err = services.GetVotePowerSyncRun(run.ID + 1)
+	assert.ErrorIs(t, err, repository.ErrVotePowerSyncRunNotFound)
+}
+
+// TestSyncVotePowerExplorerDown проверяет, что при недоступном списке держателей реестр не меняется, а запуск записывается с ошибкой
+func TestSyncVotePowerExplorerDown(t *testing.T) {
+	setupTestDB(t)
+	first := memberAddress(t, "0x0000000000000000000000000000000000000001")
+	require.NoError(t, repository.AddWalletStrength(first, 10))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	defer func(url string) { services.ExplorerAPIURL = url }(services.ExplorerAPIURL)
+	services.ExplorerAPIURL = server.URL
+	defer func(formula models.VotePowerFormula) { services.VotePowerFormulaConfig = formula }(services.VotePowerFormulaConfig)
+	services.VotePowerFormulaConfig = models.VotePowerFormula{Coin: "pro", Decimals: 18, CoinsPerVote: 1, MinVotePower: 1, Discover: true}
+
+	run, err := services.SyncVotePower(true, models.VoteStrengthChangeMeta{Actor: "admin"})
+	assert.ErrorIs(t, err, services.ErrExplorerRequest)
+	assert.NotZero(t, run.ID)
+
+	saved, err := services.GetVotePowerSyncRun(run.ID)
+	require.NoError(t, err)
+	assert.False(t, saved.Applied)
+	assert.Contains(t, saved.Error, "503")
+
+	power, err := repository.GetVoteStrength(first)
+	require.NoError(t, err)
+	assert.Equal(t, 10, power)
+}
+
+// TestLoadVotePowerSyncConfig проверяет настройки формулы и отказ для некорректных значений
+func TestLoadVotePowerSyncConfig(t *testing.T) {
+	defer func(formula models.VotePowerFormula) { services.VotePowerFormulaConfig = formula }(services.VotePowerFormulaConfig)
+
+	t.Setenv("VOTE_POWER_COIN", " PRO ")
+	t.Setenv("VOTE_POWER_COINS_PER_VOTE", "10")
+	t.Setenv("VOTE_POWER_CAP", "1000")
+	t.Setenv("VOTE_POWER_DISCOVER", "true")
+	require.NoError(t, services.LoadVotePowerSyncConfig())
+	assert.Equal(t, models.VotePowerFormula{Coin: "pro", Decimals: 18, CoinsPerVote: 10, MinVotePower: 1, MaxVotePower: 1000, Discover: true},
+		services.VotePowerFormulaConfig)
+
+	t.Setenv("VOTE_POWER_MIN", "2000")
+	assert.Error(t, services.LoadVotePowerSyncConfig())
+	t.Setenv("VOTE_POWER_MIN", "1")
+	t.Setenv("VOTE_POWER_COINS_PER_VOTE", "0")
+	assert.Error(t, services.LoadVotePowerSyncConfig())
+}
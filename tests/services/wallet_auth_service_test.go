@@ -0,0 +1,82 @@
+package services_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"bitbucket.org/decimalteam/dsc-go-sdk/wallet"
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/repository"
+	"dao_vote/back-end/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signChallenge подписывает сообщение nonce ключом тестового кошелька
+func signChallenge(t *testing.T, account *wallet.Account, challenge models.WalletChallenge) models.WalletSignInRequest {
+	t.Helper()
+	signature, err := account.Sign([]byte(challenge.Message))
+	require.NoError(t, err)
+	return models.WalletSignInRequest{Wallet: account.Address(), Nonce: challenge.Nonce, Signature: hex.EncodeToString(signature)}
+}
+
+// TestSignInWithWallet проверяет вход подписью кошелька, одноразовость nonce и завершение сессии
+func TestSignInWithWallet(t *testing.T) {
+	setupTestDB(t)
+
+	account, err := wallet.NewAccountFromMnemonicWords(testMnemonic, "")
+	require.NoError(t, err)
+	require.NoError(t, repository.AddWalletStrength(account.Address(), 500))
+
+	challenge, err := services.CreateWalletChallenge(account.Address())
+	require.NoError(t, err)
+	assert.Contains(t, challenge.Message, challenge.Nonce)
+	req := signChallenge(t, account, challenge)
+
+	resp, err := services.SignInWithWallet(req)
+	require.NoError(t, err)
+	assert.Equal(t, account.Address(), resp.Wallet)
+	assert.Equal(t, 500, resp.VotePower)
+
+	// Повторное использование nonce отклоняется
+	_, err = services.SignInWithWallet(req)
+	assert.ErrorIs(t, err, services.ErrInvalidWalletNonce)
+
+	session, err := services.AuthenticateWalletSession("Bearer " + resp.Token)
+	require.NoError(t, err)
+	assert.Equal(t, account.Address(), session.Wallet)
+
+	require.NoError(t, services.RevokeWalletSession(session.ID))
+	_, err = services.AuthenticateWalletSession(resp.Token)
+	assert.ErrorIs(t, err, services.ErrWalletSessionRevoked)
+	_, err = services.AuthenticateWalletSession(services.WalletSessionPrefix + "unknown")
+	assert.ErrorIs(t, err, services.ErrInvalidWalletSession)
+}
+
+// TestSignInWithWalletRejects проверяет отказ для чужой подписи и кошелька вне DAO
+func TestSignInWithWalletRejects(t *testing.T) {
+	setupTestDB(t)
+
+	account, err := wallet.NewAccountFromMnemonicWords(testMnemonic, "")
+	require.NoError(t, err)
+
+	// Подпись другого сообщения
+	challenge, err := services.CreateWalletChallenge(account.Address())
+	require.NoError(t, err)
+	other := challenge
+	other.Message = "GODAO other message"
+	_, err = services.SignInWithWallet(signChallenge(t, account, other))
+	assert.ErrorIs(t, err, services.ErrSignerMismatch)
+
+	// Nonce выдан другому кошельку
+	challenge, err = services.CreateWalletChallenge("0x0000000000000000000000000000000000000001")
+	require.NoError(t, err)
+	_, err = services.SignInWithWallet(signChallenge(t, account, challenge))
+	assert.ErrorIs(t, err, services.ErrInvalidWalletNonce)
+
+	// Кошелек без записи в vote_strength
+	challenge, err = services.CreateWalletChallenge(account.Address())
+	require.NoError(t, err)
+	_, err = services.SignInWithWallet(signChallenge(t, account, challenge))
+	assert.ErrorIs(t, err, services.ErrNotDAOMember)
+}
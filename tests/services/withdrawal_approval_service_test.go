@@ -0,0 +1,188 @@
+package services_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setApprovalConfig задает настройки одобрения выводов на время теста
+func setApprovalConfig(t *testing.T, threshold float64, quorum int, ttl time.Duration) {
+	threshold0, quorum0, ttl0 := services.WithdrawalApprovalThreshold, services.WithdrawalApprovalQuorum, services.WithdrawalApprovalTTL
+	t.Cleanup(func() {
+		services.WithdrawalApprovalThreshold, services.WithdrawalApprovalQuorum, services.WithdrawalApprovalTTL = threshold0, quorum0, ttl0
+	})
+	services.WithdrawalApprovalThreshold, services.WithdrawalApprovalQuorum, services.WithdrawalApprovalTTL = threshold, quorum, ttl
+}
+
+// TestWithdrawalApprovalQuorum проверяет, что крупный вывод отправляется во внешний API только после кворума одобрений
+func TestWithdrawalApprovalQuorum(t *testing.T) {
+	setupTestDB(t)
+	setApprovalConfig(t, 100, 2, time.Hour)
+	t.Setenv("DDAPPS_SERVICE_TOKEN", "Bearer service")
+
+	var submissions int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Вывод в пределах порога отправляется с токеном автора, одобренный - с сервисным токеном
+		if atomic.AddInt32(&submissions, 1) == 1 {
+			assert.Equal(t, "Bearer requester", r.Header.Get("Authorization"))
+		} else {
+			assert.Equal(t, "Bearer service", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"type":"success","data":{"transaction_id":91}}`))
+	}))
+	defer server.Close()
+
+	defer func(url string) { services.DDAppsAPIURL = url }(services.DDAppsAPIURL)
+	services.DDAppsAPIURL = server.URL
+
+	requester := models.Requester{UserID: 1, Wallet: "d01requester"}
+	adminA := models.Requester{UserID: 2, Wallet: "d01admina", Roles: []string{"admin"}}
+	adminB := models.Requester{UserID: 3, Wallet: "d01adminb", Roles: []string{"admin"}}
+
+	// Вывод в пределах порога отправляется сразу
+	small, err := services.CreateWithdrawal(requester, models.WithdrawRequest{Amount: 100, Address: "d01target"}, "Bearer requester")
+	require.NoError(t, err)
+	assert.Equal(t, models.WithdrawalStatusSubmitted, small.Status)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&submissions))
+
+	large, err := services.CreateWithdrawal(requester, models.WithdrawRequest{Amount: 500, Address: "d01target"}, "Bearer requester")
+	require.NoError(t, err)
+	assert.Equal(t, models.WithdrawalStatusPendingApproval, large.Status)
+	assert.Equal(t, 2, large.ApprovalsRequired)
+	require.NotNil(t, large.ExpiresAt)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&submissions))
+
+	_, err = services.ApproveWithdrawal(requester, large.ID, "")
+	assert.ErrorIs(t, err, services.ErrSelfApproval)
+
+	withdrawal, err := services.ApproveWithdrawal(adminA, large.ID, "checked")
+	require.NoError(t, err)
+	assert.Equal(t, models.WithdrawalStatusPendingApproval, withdrawal.Status)
+	require.Len(t, withdrawal.Approvals, 1)
+	assert.Equal(t, "checked", withdrawal.Approvals[0].Comment)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&submissions))
+
+	_, err = services.ApproveWithdrawal(adminA, large.ID, "")
+	assert.ErrorIs(t, err, services.ErrAlreadyDecided)
+
+	withdrawal, err = services.ApproveWithdrawal(adminB, large.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, models.WithdrawalStatusSubmitted, withdrawal.Status)
+	assert.Equal(t, 91, withdrawal.TransactionID)
+	assert.Len(t, withdrawal.Approvals, 2)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&submissions))
+
+	_, err = services.RejectWithdrawal(adminB, large.ID, "")
+	assert.ErrorIs(t, err, services.ErrWithdrawalNotPending)
+}
+
+// TestWithdrawalApprovalRejectAndExpiry проверяет отклонение и истечение срока одобрения
+func TestWithdrawalApprovalRejectAndExpiry(t *testing.T) {
+	setupTestDB(t)
+	setApprovalConfig(t, 10, 2, time.Hour)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected upstream call %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	defer func(url string) { services.DDAppsAPIURL = url }(services.DDAppsAPIURL)
+	services.DDAppsAPIURL = server.URL
+
+	requester := models.Requester{UserID: 1, Wallet: "d01requester"}
+	admin := models.Requester{UserID: 2, Wallet: "d01admin", Roles: []string{"admin"}}
+
+	rejected, err := services.CreateWithdrawal(requester, models.WithdrawRequest{Amount: 50, Address: "d01target"}, "Bearer requester")
+	require.NoError(t, err)
+	rejected, err = services.RejectWithdrawal(admin, rejected.ID, "unknown address")
+	require.NoError(t, err)
+	assert.Equal(t, models.WithdrawalStatusRejected, rejected.Status)
+	assert.NotNil(t, rejected.CompletedAt)
+	require.Len(t, rejected.Approvals, 1)
+	assert.Equal(t, models.ApprovalDecisionReject, rejected.Approvals[0].Decision)
+
+	services.WithdrawalApprovalTTL = -time.Second
+	expired, err := services.CreateWithdrawal(requester, models.WithdrawRequest{Amount: 50, Address: "d01target"}, "Bearer requester")
+	require.NoError(t, err)
+
+	_, err = services.ApproveWithdrawal(admin, expired.ID, "")
+	assert.ErrorIs(t, err, services.ErrWithdrawalExpired)
+
+	expired, err = services.GetWithdrawal(expired.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WithdrawalStatusExpired, expired.Status)
+	assert.Empty(t, expired.Approvals)
+}
+
+// TestWithdrawalTokenTTL проверяет, что срок одобрения не зависит от срока хранения токена автора:
+// одобренный вывод отправляется и опрашивается с сервисным токеном, а вывод без одобрения - только с токеном автора
+func TestWithdrawalTokenTTL(t *testing.T) {
+	setupTestDB(t)
+	setApprovalConfig(t, 10, 1, 48*time.Hour)
+	t.Setenv("DDAPPS_SERVICE_TOKEN", "Bearer service")
+
+	var servicePolls, requesterPolls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("Authorization")
+		switch {
+		case r.URL.Path == "/withdraw" && token == "Bearer service":
+			w.Write([]byte(`{"type":"success","data":{"transaction_id":93}}`))
+		case r.URL.Path == "/withdraw":
+			assert.Equal(t, "Bearer requester", token)
+			w.Write([]byte(`{"type":"success","data":{"transaction_id":94}}`))
+		case token == "Bearer service":
+			atomic.AddInt32(&servicePolls, 1)
+			w.Write([]byte(`{"data":{"id":93}}`))
+		default:
+			atomic.AddInt32(&requesterPolls, 1)
+			w.Write([]byte(`{"data":{"id":94}}`))
+		}
+	}))
+	defer server.Close()
+
+	defer func(url string) { services.DDAppsAPIURL = url }(services.DDAppsAPIURL)
+	services.DDAppsAPIURL = server.URL
+	defer func(ttl time.Duration) { services.WithdrawalTokenTTL = ttl }(services.WithdrawalTokenTTL)
+	services.WithdrawalTokenTTL = -time.Second
+
+	requester := models.Requester{UserID: 1, Wallet: "d01requester"}
+	admin := models.Requester{UserID: 2, Wallet: "d01admin", Roles: []string{"admin"}}
+	large, err := services.CreateWithdrawal(requester, models.WithdrawRequest{Amount: 50, Address: "d01target"}, "Bearer requester")
+	require.NoError(t, err)
+	require.NotNil(t, large.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(48*time.Hour), *large.ExpiresAt, time.Minute)
+
+	large, err = services.ApproveWithdrawal(admin, large.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, models.WithdrawalStatusSubmitted, large.Status)
+	assert.Equal(t, 93, large.TransactionID)
+
+	// Истекший токен автора не заменяется сервисным токеном при опросе вывода без одобрения
+	small, err := services.CreateWithdrawal(requester, models.WithdrawRequest{Amount: 5, Address: "d01target"}, "Bearer requester")
+	require.NoError(t, err)
+	assert.Equal(t, models.WithdrawalStatusSubmitted, small.Status)
+
+	services.PollWithdrawals()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&servicePolls))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&requesterPolls))
+	stored, err := services.GetWithdrawal(small.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WithdrawalStatusSubmitted, stored.Status)
+	assert.NotEmpty(t, stored.Error)
+	stored, err = services.GetWithdrawal(large.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Error)
+
+	// Порог одобрения без сервисного токена отклоняется при загрузке настроек
+	t.Setenv("WITHDRAWAL_APPROVAL_THRESHOLD", "10")
+	t.Setenv("DDAPPS_SERVICE_TOKEN", "")
+	assert.Error(t, services.LoadWithdrawalApprovalConfig())
+}
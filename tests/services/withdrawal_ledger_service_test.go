@@ -0,0 +1,102 @@
+package services_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWithdrawalLedgerLifecycle проверяет запись вывода в журнал и продвижение статуса опросом
+func TestWithdrawalLedgerLifecycle(t *testing.T) {
+	setupTestDB(t)
+
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/withdraw":
+			w.Write([]byte(`{"type":"success","data":{"transaction_id":77}}`))
+		case "/transactions/77":
+			if atomic.AddInt32(&polls, 1) == 1 {
+				w.Write([]byte(`{"data":{"id":77,"hash":"HASH77"}}`))
+				return
+			}
+			w.Write([]byte(`{"data":{"id":77,"hash":"HASH77","complete":true,"success":true}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	defer func(url string) { services.DDAppsAPIURL = url }(services.DDAppsAPIURL)
+	services.DDAppsAPIURL = server.URL
+
+	withdrawal, err := services.CreateWithdrawal(models.Requester{UserID: 5, Wallet: "d01requester"}, models.WithdrawRequest{Amount: 2.5, Address: "d01target"}, "Bearer token")
+	require.NoError(t, err)
+	assert.Equal(t, models.WithdrawalStatusSubmitted, withdrawal.Status)
+	assert.Equal(t, 77, withdrawal.TransactionID)
+
+	services.PollWithdrawals()
+	stored, err := services.GetWithdrawal(withdrawal.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WithdrawalStatusSent, stored.Status)
+	assert.Equal(t, "HASH77", stored.TransactionHash)
+	assert.Nil(t, stored.CompletedAt)
+
+	services.PollWithdrawals()
+	stored, err = services.GetWithdrawal(withdrawal.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WithdrawalStatusCompleted, stored.Status)
+	assert.NotNil(t, stored.CompletedAt)
+	assert.Equal(t, 2, stored.Attempts)
+
+	// Итоговый статус больше не опрашивается
+	services.PollWithdrawals()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&polls))
+
+	list, err := services.ListWithdrawals(models.WithdrawalFilter{UserID: 5, Status: models.WithdrawalStatusCompleted, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, withdrawal.ID, list[0].ID)
+
+	list, err = services.ListWithdrawals(models.WithdrawalFilter{UserID: 6, Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	// Нулевой UserID не снимает фильтр по пользователю, все выводы выбираются только с AllUsers
+	list, err = services.ListWithdrawals(models.WithdrawalFilter{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	list, err = services.ListWithdrawals(models.WithdrawalFilter{AllUsers: true, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+// TestWithdrawalUpstreamFailure проверяет, что ошибка внешнего API фиксируется в журнале
+func TestWithdrawalUpstreamFailure(t *testing.T) {
+	setupTestDB(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"message":"insufficient funds"}`))
+	}))
+	defer server.Close()
+
+	defer func(url string) { services.DDAppsAPIURL = url }(services.DDAppsAPIURL)
+	services.DDAppsAPIURL = server.URL
+
+	withdrawal, err := services.CreateWithdrawal(models.Requester{UserID: 5, Wallet: "d01requester"}, models.WithdrawRequest{Amount: 1, Address: "d01target"}, "Bearer token")
+	require.Error(t, err)
+	require.NotZero(t, withdrawal.ID)
+
+	stored, err := services.GetWithdrawal(withdrawal.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WithdrawalStatusFailed, stored.Status)
+	assert.Contains(t, stored.Error, "insufficient funds")
+	assert.NotNil(t, stored.CompletedAt)
+}
@@ -0,0 +1,112 @@
+package services_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dao_vote/back-end/models"
+	"dao_vote/back-end/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPolicy создает правило политики вывода средств
+func newPolicy(t *testing.T, req models.WithdrawalPolicyRequest) models.WithdrawalPolicy {
+	t.Helper()
+	policy, err := services.CreateWithdrawalPolicy(req)
+	require.NoError(t, err)
+	return policy
+}
+
+// requireViolation проверяет, что вывод отклонен указанным правилом
+func requireViolation(t *testing.T, err error, policy models.WithdrawalPolicy) {
+	t.Helper()
+	var violation *services.PolicyViolation
+	require.True(t, errors.As(err, &violation), "expected policy violation, got %v", err)
+	assert.Equal(t, policy.ID, violation.Policy.ID)
+}
+
+// TestWithdrawalPolicies проверяет лимиты, списки адресов и исключения для ролей
+func TestWithdrawalPolicies(t *testing.T) {
+	setupTestDB(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"type":"success","data":{"transaction_id":1}}`))
+	}))
+	defer server.Close()
+	defer func(url string) { services.DDAppsAPIURL = url }(services.DDAppsAPIURL)
+	services.DDAppsAPIURL = server.URL
+
+	maxPolicy := newPolicy(t, models.WithdrawalPolicyRequest{Type: models.PolicyPerTransactionMax, Amount: 100, ExemptRoles: []string{"Treasurer"}})
+	userCap := newPolicy(t, models.WithdrawalPolicyRequest{Type: models.PolicyUserDailyCap, Amount: 150})
+	denied := newPolicy(t, models.WithdrawalPolicyRequest{Type: models.PolicyDenylist, Address: "d01blocked"})
+
+	member := models.Requester{UserID: 1, Wallet: "d01member", Roles: []string{"member"}}
+	treasurer := models.Requester{UserID: 2, Wallet: "d01treasurer", Roles: []string{"treasurer"}}
+
+	_, err := services.CreateWithdrawal(member, models.WithdrawRequest{Amount: 101, Address: "d01target"}, "token")
+	requireViolation(t, err, maxPolicy)
+
+	_, err = services.CreateWithdrawal(treasurer, models.WithdrawRequest{Amount: 120, Address: "d01target"}, "token")
+	require.NoError(t, err)
+
+	_, err = services.CreateWithdrawal(member, models.WithdrawRequest{Amount: 100, Address: "d01target"}, "token")
+	require.NoError(t, err)
+	_, err = services.CreateWithdrawal(member, models.WithdrawRequest{Amount: 60, Address: "d01target"}, "token")
+	requireViolation(t, err, userCap)
+
+	_, err = services.CreateWithdrawal(member, models.WithdrawRequest{Amount: 1, Address: "d01blocked"}, "token")
+	requireViolation(t, err, denied)
+
+	// При наличии списка разрешенных адресов остальные адреса отклоняются
+	allowed := newPolicy(t, models.WithdrawalPolicyRequest{Type: models.PolicyAllowlist, Address: "d01treasury"})
+	_, err = services.CreateWithdrawal(treasurer, models.WithdrawRequest{Amount: 1, Address: "d01target"}, "token")
+	requireViolation(t, err, allowed)
+	_, err = services.CreateWithdrawal(treasurer, models.WithdrawRequest{Amount: 1, Address: "d01treasury"}, "token")
+	require.NoError(t, err)
+
+	rejections, err := services.ListPolicyRejections(10, 0)
+	require.NoError(t, err)
+	require.Len(t, rejections, 4)
+	assert.Equal(t, allowed.ID, rejections[0].PolicyID)
+	assert.Equal(t, models.PolicyAllowlist, rejections[0].PolicyType)
+	assert.Equal(t, maxPolicy.ID, rejections[3].PolicyID)
+}
+
+// TestWithdrawalDailyCapPerCoin проверяет, что дневной лимит без монеты суммирует выводы только в монете запроса
+func TestWithdrawalDailyCapPerCoin(t *testing.T) {
+	setupTestDB(t)
+	defer func(coins []string) { services.AllowedCoins = coins }(services.AllowedCoins)
+	services.AllowedCoins = []string{"del", "usdt"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"type":"success","data":{"transaction_id":1}}`))
+	}))
+	defer server.Close()
+	defer func(url string) { services.DDAppsAPIURL = url }(services.DDAppsAPIURL)
+	services.DDAppsAPIURL = server.URL
+
+	userCap := newPolicy(t, models.WithdrawalPolicyRequest{Type: models.PolicyUserDailyCap, Amount: 100})
+	member := models.Requester{UserID: 1, Wallet: "d01member", Roles: []string{"member"}}
+
+	_, err := services.CreateWithdrawal(member, models.WithdrawRequest{Amount: 80, Address: "d01target", Coin: "del"}, "token")
+	require.NoError(t, err)
+	_, err = services.CreateWithdrawal(member, models.WithdrawRequest{Amount: 80, Address: "d01target", Coin: "usdt"}, "token")
+	require.NoError(t, err)
+	_, err = services.CreateWithdrawal(member, models.WithdrawRequest{Amount: 30, Address: "d01target", Coin: "usdt"}, "token")
+	requireViolation(t, err, userCap)
+	assert.Contains(t, err.Error(), "already withdrawn today: 80")
+}
+
+// TestWithdrawalPolicyValidation проверяет отклонение некорректных правил
+func TestWithdrawalPolicyValidation(t *testing.T) {
+	setupTestDB(t)
+
+	_, err := services.CreateWithdrawalPolicy(models.WithdrawalPolicyRequest{Type: models.PolicyGlobalDailyCap})
+	assert.ErrorIs(t, err, services.ErrInvalidPolicy)
+
+	_, err = services.CreateWithdrawalPolicy(models.WithdrawalPolicyRequest{Type: models.PolicyAllowlist})
+	assert.ErrorIs(t, err, services.ErrInvalidPolicy)
+}
@@ -0,0 +1,84 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+
+	"dao_vote/back-end/utils"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAddress = "d01xp6aqad49te7vsfga6str8hrdeh24r9jhplgxv" // Адрес тестовой мнемоники
+
+// TestDecimalAddressValidation проверяет префикс, контрольную сумму и длину адреса d0…
+func TestDecimalAddressValidation(t *testing.T) {
+	assert.NoError(t, utils.ValidateDecimalAddress(testAddress))
+
+	// Опечатка в последнем символе ломает контрольную сумму
+	typo := testAddress[:len(testAddress)-1] + "w"
+	assert.ErrorIs(t, utils.ValidateDecimalAddress(typo), utils.ErrInvalidDecimalAddress)
+	assert.ErrorIs(t, utils.ValidateDecimalAddress("d01target"), utils.ErrInvalidDecimalAddress)
+
+	// Верный bech32 адрес с чужим префиксом
+	_, raw, err := bech32.DecodeAndConvert(testAddress)
+	require.NoError(t, err)
+	foreign, err := bech32.ConvertAndEncode("cosmos", raw)
+	require.NoError(t, err)
+	assert.ErrorIs(t, utils.ValidateDecimalAddress(foreign), utils.ErrInvalidDecimalAddress)
+	assert.ErrorIs(t, utils.ValidateDecimalAddress(""), utils.ErrInvalidDecimalAddress)
+}
+
+// TestEVMAddressConversion проверяет преобразование между формами d0… и 0x…
+func TestEVMAddressConversion(t *testing.T) {
+	evm, err := utils.DecimalToEVM(testAddress)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(evm, "0x"))
+	assert.Len(t, evm, 42)
+
+	back, err := utils.EVMToDecimal(evm)
+	require.NoError(t, err)
+	assert.Equal(t, testAddress, back)
+
+	normalized, err := utils.NormalizeAddress(strings.ToLower(evm))
+	require.NoError(t, err)
+	assert.Equal(t, testAddress, normalized)
+
+	normalized, err = utils.NormalizeAddress(strings.ToUpper(testAddress))
+	require.NoError(t, err)
+	assert.Equal(t, testAddress, normalized)
+
+	// Адрес в смешанном регистре с неверной контрольной суммой EIP-55
+	broken := []byte(evm)
+	for i := 2; i < len(broken); i++ {
+		if c := broken[i]; c >= 'a' && c <= 'f' {
+			broken[i] = c - 'a' + 'A'
+			break
+		} else if c >= 'A' && c <= 'F' {
+			broken[i] = c - 'A' + 'a'
+			break
+		}
+	}
+	assert.ErrorIs(t, utils.ValidateEVMAddress(string(broken)), utils.ErrInvalidEVMAddress)
+	assert.ErrorIs(t, utils.ValidateEVMAddress("0x1234"), utils.ErrInvalidEVMAddress)
+}
+
+// TestAddressValidatorTags проверяет теги validator/v10
+func TestAddressValidatorTags(t *testing.T) {
+	v := validator.New()
+	require.NoError(t, utils.RegisterAddressValidators(v))
+
+	type request struct {
+		Voter   string `validate:"decimal_address"`
+		Address string `validate:"decimal_or_evm_address"`
+	}
+	evm, err := utils.DecimalToEVM(testAddress)
+	require.NoError(t, err)
+
+	assert.NoError(t, v.Struct(request{Voter: testAddress, Address: evm}))
+	assert.NoError(t, v.Struct(request{Voter: testAddress, Address: testAddress}))
+	assert.Error(t, v.Struct(request{Voter: evm, Address: testAddress}))
+	assert.Error(t, v.Struct(request{Voter: testAddress, Address: "d01target"}))
+}
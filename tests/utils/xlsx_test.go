@@ -0,0 +1,62 @@
+package utils_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+
+	"dao_vote/back-end/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestXLSXColumnName проверяет буквенные обозначения колонок
+func TestXLSXColumnName(t *testing.T) {
+	for index, name := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		assert.Equal(t, name, utils.XLSXColumnName(index))
+	}
+}
+
+// TestXLSXWriter проверяет структуру книги, типы ячеек и экранирование строк
+func TestXLSXWriter(t *testing.T) {
+	var buffer bytes.Buffer
+	writer, err := utils.NewXLSXWriter(&buffer, "Proposals & <results>")
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteRow([]interface{}{"id", "title", "power"}))
+	require.NoError(t, writer.WriteRow([]interface{}{7, `<b>"За" & "Против"</b>`, nil, 1.5}))
+	require.NoError(t, writer.Close())
+
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	require.NoError(t, err)
+	parts := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		parts[file.Name] = string(content)
+
+		// Каждая часть книги должна быть корректным XML
+		decoder := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else {
+				require.NoError(t, err, file.Name)
+			}
+		}
+	}
+
+	require.Contains(t, parts, "[Content_Types].xml")
+	require.Contains(t, parts, "_rels/.rels")
+	require.Contains(t, parts, "xl/_rels/workbook.xml.rels")
+	assert.Contains(t, parts["xl/workbook.xml"], `name="Proposals &amp; &lt;results&gt;"`)
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve">id</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="A2"><v>7</v></c>`)
+	assert.Contains(t, sheet, `&lt;b&gt;&#34;За&#34; &amp; &#34;Против&#34;&lt;/b&gt;`)
+	assert.NotContains(t, sheet, `r="C2"`, "пустые значения не записываются")
+	assert.Contains(t, sheet, `<c r="D2"><v>1.5</v></c>`)
+}